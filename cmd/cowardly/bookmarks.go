@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cowardly/cowardly/internal/bookmarks"
+)
+
+// bookmarksCmd handles `cowardly bookmarks [show|import|export|clear]`.
+func bookmarksCmd(args []string) {
+	sub := "show"
+	if len(args) > 0 {
+		sub = args[0]
+		args = args[1:]
+	}
+	switch sub {
+	case "show", "list", "ls":
+		showBookmarks()
+	case "import":
+		name := ""
+		var rest []string
+		for _, a := range args {
+			if strings.HasPrefix(a, "--name=") {
+				name = strings.TrimPrefix(a, "--name=")
+				continue
+			}
+			rest = append(rest, a)
+		}
+		if len(rest) != 1 {
+			fmt.Fprintln(os.Stderr, "Usage: cowardly bookmarks import <file.yaml|bookmarks.html> [--name=<folder>]")
+			os.Exit(1)
+		}
+		tree, err := bookmarks.LoadFile(rest[0], name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "bookmarks: %v\n", err)
+			os.Exit(1)
+		}
+		if err := cfgStore.WriteBookmarks(*tree); err != nil {
+			fmt.Fprintf(os.Stderr, "bookmarks: could not save to cowardly.yaml: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Imported %d bookmark(s) into %q.\n", tree.Count(), tree.TopLevelName)
+		applyDesiredState("managed bookmarks")
+	case "export":
+		if len(args) != 1 {
+			fmt.Fprintln(os.Stderr, "Usage: cowardly bookmarks export <file.yaml>")
+			os.Exit(1)
+		}
+		desired, err := cfgStore.Read()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "bookmarks: %v\n", err)
+			os.Exit(1)
+		}
+		if desired == nil || desired.Bookmarks.IsEmpty() {
+			fmt.Fprintln(os.Stderr, "No managed bookmarks to export.")
+			os.Exit(1)
+		}
+		if err := bookmarks.WriteFile(args[0], desired.Bookmarks); err != nil {
+			fmt.Fprintf(os.Stderr, "bookmarks: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Exported managed bookmarks to %s\n", args[0])
+	case "clear":
+		if err := cfgStore.WriteBookmarks(bookmarks.Tree{}); err != nil {
+			fmt.Fprintf(os.Stderr, "bookmarks: could not save to cowardly.yaml: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Cleared managed bookmarks.")
+		applyDesiredState("managed bookmarks")
+	default:
+		fmt.Fprintf(os.Stderr, "bookmarks: unknown subcommand %q (use show, import, export, clear)\n", sub)
+		os.Exit(1)
+	}
+}
+
+func showBookmarks() {
+	desired, err := cfgStore.Read()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "bookmarks: %v\n", err)
+		os.Exit(1)
+	}
+	if desired == nil || desired.Bookmarks.IsEmpty() {
+		fmt.Println("No managed bookmarks. Use `cowardly bookmarks import <file>` or the TUI.")
+		return
+	}
+	fmt.Printf("%s/\n", desired.Bookmarks.TopLevelName)
+	for _, r := range desired.Bookmarks.Flatten() {
+		indent := strings.Repeat("  ", r.Depth+1)
+		if r.Node.IsFolder() {
+			fmt.Printf("%s%s/\n", indent, r.Node.Name)
+		} else {
+			fmt.Printf("%s%s  %s\n", indent, r.Node.Name, r.Node.URL)
+		}
+	}
+}
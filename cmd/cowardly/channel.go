@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cowardly/cowardly/internal/brave"
+)
+
+// channelCommand returns the command for arg (a flag without its dashes) if --channel=all can run it
+// once per installed channel, or nil. dispatch runs the same commands for a single channel.
+func channelCommand(arg string) func() error {
+	switch {
+	case arg == "apply" || arg == "a":
+		return func() error { return applyPreset("quick") }
+	case strings.HasPrefix(arg, "apply="):
+		return func() error { return applyPreset(strings.TrimPrefix(arg, "apply=")) }
+	case arg == "privacy-guides":
+		return func() error { return applyPrivacyGuides(parsePrivacyGuidesBase("privacy-guides")) }
+	case strings.HasPrefix(arg, "privacy-guides="):
+		return func() error { return applyPrivacyGuides(strings.TrimPrefix(arg, "privacy-guides=")) }
+	case strings.HasPrefix(arg, "apply-file="):
+		return func() error { return applyFile(strings.TrimPrefix(arg, "apply-file=")) }
+	case strings.HasPrefix(arg, "diff="):
+		return func() error { return diffPreset(strings.TrimPrefix(arg, "diff=")) }
+	case arg == "reapply":
+		return reapply
+	case arg == "install-login-hook":
+		return installLoginHook
+	}
+	return nil
+}
+
+// parseChannel removes --channel=<name|all> and --brave-app=<path> from args. --beta is the same as
+// --channel=beta (and stays in args, which subcommands skip); without either the channel is stable.
+func parseChannel(args []string) (all bool, v brave.Variant, app string, rest []string, err error) {
+	v = brave.VariantStable
+	chosen := false
+	for _, a := range args {
+		flag := strings.TrimLeft(a, "-")
+		if strings.HasPrefix(a, "-") && strings.HasPrefix(flag, "brave-app=") {
+			if app = strings.TrimPrefix(flag, "brave-app="); app == "" {
+				return false, "", "", nil, fmt.Errorf("%s: path is empty", a)
+			}
+			continue
+		}
+		if !strings.HasPrefix(a, "-") || !strings.HasPrefix(flag, "channel=") {
+			if flag == "beta" && !chosen {
+				v = brave.VariantBeta
+			}
+			rest = append(rest, a)
+			continue
+		}
+		name := strings.TrimPrefix(flag, "channel=")
+		chosen = true
+		if name == "all" {
+			all = true
+			continue
+		}
+		if v, err = brave.ParseVariant(name); err != nil {
+			return false, "", "", nil, err
+		}
+	}
+	return all, v, app, rest, nil
+}
+
+// useApp targets the app bundle given with --brave-app. Its channel comes from the bundle; a different
+// --channel is an error.
+func useApp(app string, all bool, v brave.Variant) error {
+	if all {
+		return fmt.Errorf("--brave-app cannot be combined with --channel=all")
+	}
+	got, err := brave.UseApp(app)
+	if err != nil {
+		return err
+	}
+	if v != brave.VariantStable && v != got {
+		return fmt.Errorf("%s is %s, not the %s channel", app, got.AppName(), v)
+	}
+	return nil
+}
+
+// errNotInstalled returns the error for when the current channel's app is missing.
+func errNotInstalled() error {
+	return fmt.Errorf("%s not found in /Applications or ~/Applications (use --brave-app=<path> for another location)", brave.CurrentVariant().AppName())
+}
+
+// runAllChannels runs the command in args once for every installed channel. A channel that fails does
+// not stop the others; a summary lists each channel's result and any failure exits 1 at the end.
+func runAllChannels(args []string) {
+	var run func() error
+	for _, a := range args {
+		if run = channelCommand(strings.TrimLeft(a, "-")); run != nil {
+			break
+		}
+	}
+	if run == nil {
+		fmt.Fprintln(os.Stderr, "--channel=all works with --apply, --privacy-guides, --apply-file, --diff, --reapply and --install-login-hook.")
+		os.Exit(1)
+	}
+	installed := brave.InstalledVariants()
+	if len(installed) == 0 {
+		fmt.Fprintln(os.Stderr, "No Brave channel found in /Applications or ~/Applications. Install Brave first.")
+		os.Exit(1)
+	}
+	errs := make([]error, len(installed))
+	for i, v := range installed {
+		brave.UseVariant(v)
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("== %s ==\n", v.AppName())
+		if errs[i] = run(); errs[i] != nil {
+			fmt.Fprintln(os.Stderr, errs[i])
+		}
+	}
+	failed := 0
+	fmt.Println("\nSummary:")
+	for i, v := range installed {
+		if errs[i] != nil {
+			failed++
+			fmt.Printf("  %s: failed: %v\n", v.AppName(), errs[i])
+		} else {
+			fmt.Printf("  %s: ok\n", v.AppName())
+		}
+	}
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "%d of %d channel(s) failed.\n", failed, len(installed))
+		os.Exit(1)
+	}
+}
+
+// channelArgs returns the flag that selects the current channel (or app bundle), for Launch Agents.
+func channelArgs() []string {
+	if app := brave.AppOverride(); app != "" {
+		return []string{"--brave-app=" + app}
+	}
+	if v := brave.CurrentVariant(); v != brave.VariantStable {
+		return []string{"--channel=" + string(v)}
+	}
+	return nil
+}
+
+// agentLabel returns the Launch Agent label for the current channel: base for stable, base.<channel> otherwise,
+// so each channel gets its own login hook and watcher.
+func agentLabel(base string) string {
+	if v := brave.CurrentVariant(); v != brave.VariantStable {
+		return base + "." + string(v)
+	}
+	return base
+}
+
+// agentLog returns the log file name for the current channel (e.g. reapply.log, reapply-beta.log).
+func agentLog(name string) string {
+	if v := brave.CurrentVariant(); v != brave.VariantStable {
+		return strings.TrimSuffix(name, ".log") + "-" + string(v) + ".log"
+	}
+	return name
+}
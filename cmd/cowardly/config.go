@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/cowardly/cowardly/internal/userconfig"
+)
+
+// configCmd handles `cowardly config [path|state-dir|migrate [--dry-run]]`.
+func configCmd(args []string) {
+	sub := "path"
+	if len(args) > 0 {
+		sub = args[0]
+		args = args[1:]
+	}
+	switch sub {
+	case "path":
+		fmt.Println(cfgPaths.Config)
+	case "state-dir":
+		fmt.Println(cfgPaths.State)
+	case "migrate":
+		dryRun := false
+		for _, a := range args {
+			if a != "--dry-run" {
+				fmt.Fprintf(os.Stderr, "config: unknown flag %q\n", a)
+				os.Exit(1)
+			}
+			dryRun = true
+		}
+		migrateConfig(dryRun)
+	default:
+		fmt.Fprintf(os.Stderr, "config: unknown subcommand %q (use path, state-dir, migrate)\n", sub)
+		os.Exit(1)
+	}
+}
+
+func migrateConfig(dryRun bool) {
+	from, steps, out, err := cfgStore.Migrate(dryRun)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "config migrate: %v\n", err)
+		os.Exit(1)
+	}
+	if len(steps) == 0 {
+		fmt.Printf("cowardly.yaml is at version %d (current); nothing to migrate.\n", from)
+		return
+	}
+	fmt.Printf("cowardly.yaml is at version %d; current is %d.\n", from, userconfig.CurrentVersion)
+	for _, s := range steps {
+		fmt.Printf("  v%d -> v%d: %s\n", s.From, s.From+1, s.Description)
+	}
+	if dryRun {
+		fmt.Printf("\nMigrated file (not written):\n\n%s", out)
+		return
+	}
+	path := cfgStore.Path()
+	fmt.Printf("Migrated %s (original kept as %s.v%d.bak).\n", path, path, from)
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cowardly/cowardly/internal/brave"
+	"github.com/cowardly/cowardly/internal/content"
+	"github.com/cowardly/cowardly/internal/presets"
+)
+
+// contentCmd handles `cowardly content [show|add|remove|default|diff|clear]`.
+func contentCmd(args []string) {
+	sub := "show"
+	if len(args) > 0 {
+		sub = args[0]
+		args = args[1:]
+	}
+	switch sub {
+	case "show", "list", "ls":
+		showContent()
+	case "add":
+		if len(args) < 3 {
+			fmt.Fprintln(os.Stderr, "Usage: cowardly content add <type> <allow|block|session_only> <pattern>...")
+			os.Exit(1)
+		}
+		t := parseContentType(args[0])
+		action := parseListAction(t, args[1])
+		updateContent(func(c content.Config) (string, error) {
+			r := c[t.Name]
+			list := r.List(action)
+			added := 0
+			for _, p := range args[2:] {
+				if !containsString(list, p) {
+					list = append(list, p)
+					added++
+				}
+			}
+			r.SetList(action, list)
+			c[t.Name] = r
+			return fmt.Sprintf("Added %d pattern(s) to %s %s.", added, t.Name, action), nil
+		})
+	case "remove", "rm":
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, "Usage: cowardly content remove <type> <pattern>...")
+			os.Exit(1)
+		}
+		t := parseContentType(args[0])
+		updateContent(func(c content.Config) (string, error) {
+			r := c[t.Name]
+			removed := 0
+			for _, a := range t.Actions() {
+				var kept []string
+				for _, p := range r.List(a) {
+					if containsString(args[1:], p) {
+						removed++
+						continue
+					}
+					kept = append(kept, p)
+				}
+				r.SetList(a, kept)
+			}
+			if removed == 0 {
+				return "", fmt.Errorf("no matching patterns")
+			}
+			c[t.Name] = r
+			return fmt.Sprintf("Removed %d pattern(s) from %s.", removed, t.Name), nil
+		})
+	case "default":
+		if len(args) != 2 {
+			fmt.Fprintln(os.Stderr, "Usage: cowardly content default <type> <allow|block|session_only|ask|unset>")
+			os.Exit(1)
+		}
+		t := parseContentType(args[0])
+		updateContent(func(c content.Config) (string, error) {
+			r := c[t.Name]
+			r.Default = content.Action(args[1])
+			if args[1] == "unset" {
+				r.Default = ""
+			}
+			c[t.Name] = r
+			return fmt.Sprintf("Default for %s: %s.", t.Name, args[1]), nil
+		})
+	case "diff":
+		desired, err := cfgStore.Read()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "content: %v\n", err)
+			os.Exit(1)
+		}
+		if desired == nil || desired.Content.IsEmpty() {
+			fmt.Println("No content settings saved.")
+			return
+		}
+		diff := brave.Diff(desired.Content.Settings(), presets.ValueNames())
+		if diff == "" {
+			fmt.Println("No changes (current content settings already match).")
+			return
+		}
+		fmt.Println("Content settings that would change (current -> saved):")
+		fmt.Println(diff)
+	case "clear":
+		updateContent(func(c content.Config) (string, error) {
+			if len(args) > 0 {
+				t := parseContentType(args[0])
+				delete(c, t.Name)
+				return fmt.Sprintf("Cleared %s.", t.Name), nil
+			}
+			for name := range c {
+				delete(c, name)
+			}
+			return "Cleared all content settings.", nil
+		})
+	default:
+		fmt.Fprintf(os.Stderr, "content: unknown subcommand %q (use show, add, remove, default, diff, clear)\n", sub)
+		os.Exit(1)
+	}
+}
+
+// parseContentType returns the content type named s, and exits otherwise.
+func parseContentType(s string) content.Type {
+	if t := content.FindType(strings.ToLower(s)); t != nil {
+		return *t
+	}
+	names := make([]string, len(content.Types))
+	for i, t := range content.Types {
+		names[i] = t.Name
+	}
+	fmt.Fprintf(os.Stderr, "content: unknown type %q (use %s)\n", s, strings.Join(names, ", "))
+	os.Exit(1)
+	return content.Type{}
+}
+
+// parseListAction returns the list action s of t, or exits listing the actions t supports.
+func parseListAction(t content.Type, s string) content.Action {
+	a := content.Action(strings.ToLower(s))
+	if _, ok := t.Lists[a]; ok {
+		return a
+	}
+	names := make([]string, 0, len(t.Lists))
+	for _, a := range t.Actions() {
+		names = append(names, string(a))
+	}
+	fmt.Fprintf(os.Stderr, "content: %s has no %q list (use %s)\n", t.Name, s, strings.Join(names, ", "))
+	os.Exit(1)
+	return ""
+}
+
+func showContent() {
+	desired, err := cfgStore.Read()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "content: %v\n", err)
+		os.Exit(1)
+	}
+	if desired == nil || desired.Content.IsEmpty() {
+		fmt.Println("No content settings. Use `cowardly content add cookies allow '[*.]example.com'`.")
+		return
+	}
+	for _, t := range content.Types {
+		r, ok := desired.Content[t.Name]
+		if !ok || r.IsEmpty() {
+			continue
+		}
+		fmt.Printf("%s:\n", t.Label)
+		if r.Default != "" {
+			fmt.Printf("  default: %s (%s = %d)\n", r.Default, t.DefaultKey, t.Defaults[r.Default])
+		}
+		for _, a := range t.Actions() {
+			for _, p := range r.List(a) {
+				fmt.Printf("  %-12s %s\n", a, p)
+			}
+		}
+	}
+}
+
+// updateContent applies fn to the saved content settings, validates and saves them in one config update, then
+// re-applies the desired state.
+func updateContent(fn func(c content.Config) (string, error)) {
+	var summary string
+	err := cfgStore.UpdateContent(func(c content.Config) error {
+		var err error
+		if summary, err = fn(c); err != nil {
+			return err
+		}
+		return c.Validate()
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "content: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(summary)
+	applyDesiredState("content settings")
+}
+
+func containsString(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
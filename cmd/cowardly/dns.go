@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cowardly/cowardly/internal/doh"
+)
+
+// dnsCmd handles `cowardly dns [show|list|set|off|clear]`.
+func dnsCmd(args []string) {
+	sub := "show"
+	if len(args) > 0 {
+		sub = args[0]
+		args = args[1:]
+	}
+	switch sub {
+	case "show":
+		showDNS()
+	case "list", "ls", "catalog":
+		listDNSResolvers()
+	case "set":
+		sel := doh.Selection{Mode: doh.ModeSecure}
+		var rest []string
+		for _, a := range args {
+			if !strings.HasPrefix(a, "--") {
+				rest = append(rest, a)
+				continue
+			}
+			name, val, ok := strings.Cut(strings.TrimPrefix(a, "--"), "=")
+			if !ok {
+				fmt.Fprintf(os.Stderr, "dns: expected --%s=<value>\n", name)
+				os.Exit(1)
+			}
+			if name == "mode" {
+				m, err := doh.ParseMode(val)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "dns: %v\n", err)
+					os.Exit(1)
+				}
+				sel.Mode = m
+				continue
+			}
+			if sel.Vars == nil {
+				sel.Vars = make(map[string]string)
+			}
+			sel.Vars[name] = val
+		}
+		if len(rest) != 1 {
+			fmt.Fprintln(os.Stderr, "Usage: cowardly dns set <resolver> [--mode=secure|automatic] [--<variable>=<value>]")
+			os.Exit(1)
+		}
+		sel.Resolver = rest[0]
+		saveDNS(sel)
+	case "automatic":
+		saveDNS(doh.Selection{Mode: doh.ModeAutomatic})
+	case "off":
+		saveDNS(doh.Selection{Mode: doh.ModeOff})
+	case "clear":
+		saveDNS(doh.Selection{})
+	default:
+		fmt.Fprintf(os.Stderr, "dns: unknown subcommand %q (use show, list, set, automatic, off, clear)\n", sub)
+		os.Exit(1)
+	}
+}
+
+func showDNS() {
+	desired, err := cfgStore.Read()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dns: %v\n", err)
+		os.Exit(1)
+	}
+	if desired == nil || desired.DNS.IsEmpty() {
+		fmt.Println("DNS over HTTPS is not managed. Use `cowardly dns set <resolver>` (see `cowardly dns list`).")
+		return
+	}
+	fmt.Printf("DNS over HTTPS: %s\n", desired.DNS.Describe())
+	for _, s := range desired.DNS.Settings() {
+		fmt.Printf("  %s = %v\n", s.Key, s.Value)
+	}
+}
+
+func listDNSResolvers() {
+	list, err := doh.Catalog()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dns: %v\n", err)
+		os.Exit(1)
+	}
+	for _, r := range list {
+		fmt.Printf("  %-16s %s — %s\n", r.ID, r.Name, r.Description)
+		for _, v := range r.Variables {
+			fmt.Printf("  %-16s   --%s=<value>  %s\n", "", v.Name, v.Description)
+		}
+	}
+}
+
+// saveDNS validates sel, saves it and re-applies the desired state.
+func saveDNS(sel doh.Selection) {
+	if !sel.IsEmpty() {
+		if err := sel.Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "dns: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if err := cfgStore.WriteDNS(sel); err != nil {
+		fmt.Fprintf(os.Stderr, "dns: could not save to cowardly.yaml: %v\n", err)
+		os.Exit(1)
+	}
+	if sel.IsEmpty() {
+		fmt.Println("DNS over HTTPS is no longer managed by cowardly (the preset's value, if any, applies).")
+	} else {
+		fmt.Printf("DNS over HTTPS: %s\n", sel.Describe())
+	}
+	applyDesiredState("DNS over HTTPS")
+}
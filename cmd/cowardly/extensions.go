@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/cowardly/cowardly/internal/brave"
+	"github.com/cowardly/cowardly/internal/extensions"
+	"github.com/cowardly/cowardly/internal/history"
+	"github.com/cowardly/cowardly/internal/userconfig"
+)
+
+// extensionsCmd handles `cowardly extensions [list|catalog|add|remove]`.
+func extensionsCmd(args []string) {
+	sub := "list"
+	if len(args) > 0 {
+		sub = args[0]
+		args = args[1:]
+	}
+	switch sub {
+	case "list", "ls":
+		listExtensions()
+	case "catalog":
+		listExtensionCatalog()
+	case "add":
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, "Usage: cowardly extensions add <force|block|allow> <id|name>...")
+			os.Exit(1)
+		}
+		mode, err := extensions.ParseMode(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "extensions: %v\n", err)
+			os.Exit(1)
+		}
+		updateExtensions(args[1:], func(p *extensions.Policy, id string) error {
+			return p.Set(id, mode)
+		})
+	case "remove", "rm":
+		if len(args) == 0 {
+			fmt.Fprintln(os.Stderr, "Usage: cowardly extensions remove <id|name>...")
+			os.Exit(1)
+		}
+		updateExtensions(args, func(p *extensions.Policy, id string) error {
+			if !p.Remove(id) {
+				return fmt.Errorf("%s is not managed", extensionLabel(id))
+			}
+			return nil
+		})
+	default:
+		fmt.Fprintf(os.Stderr, "extensions: unknown subcommand %q (use list, catalog, add, remove)\n", sub)
+		os.Exit(1)
+	}
+}
+
+func listExtensions() {
+	desired, err := cfgStore.Read()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "extensions: %v\n", err)
+		os.Exit(1)
+	}
+	if desired == nil || desired.Extensions.IsEmpty() {
+		fmt.Println("No managed extensions. Use `cowardly extensions add <force|block|allow> <id|name>`.")
+		return
+	}
+	for _, id := range desired.Extensions.IDs() {
+		fmt.Printf("  %-6s %s\n", desired.Extensions.ModeOf(id), extensionLabel(id))
+	}
+}
+
+func listExtensionCatalog() {
+	list, err := extensions.Catalog()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "extensions: %v\n", err)
+		os.Exit(1)
+	}
+	for _, e := range list {
+		fmt.Printf("  %s  %s\n", e.ID, e.Name)
+	}
+}
+
+// updateExtensions resolves each id or name, applies fn to the saved policy and saves it in one config update, then
+// re-applies the desired state.
+func updateExtensions(names []string, fn func(p *extensions.Policy, id string) error) {
+	ids := make([]string, len(names))
+	for i, name := range names {
+		id, err := extensions.Resolve(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "extensions: %v\n", err)
+			os.Exit(1)
+		}
+		ids[i] = id
+	}
+	err := cfgStore.UpdateExtensions(func(p *extensions.Policy) error {
+		for _, id := range ids {
+			if err := fn(p, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "extensions: %v\n", err)
+		os.Exit(1)
+	}
+	listExtensions()
+	applyDesiredState("extension policy")
+}
+
+// applyDesiredState applies the saved desired state (including layers) after a layer was edited, and
+// deletes the keys of emptied layers from user prefs.
+func applyDesiredState(what string) {
+	if !brave.BraveInstalled() {
+		fmt.Fprintf(os.Stderr, "Saved %s. %s not found; run --reapply once it is installed.\n", what, brave.CurrentVariant().AppName())
+		return
+	}
+	l, st, err := lockState()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "apply: %v\n", err)
+		os.Exit(1)
+	}
+	defer l.Release()
+	desired, err := st.Read()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "apply: %v\n", err)
+		os.Exit(1)
+	}
+	var settings []brave.Setting
+	if desired != nil {
+		settings = desired.Effective()
+	}
+	if brave.BraveRunning() {
+		fmt.Fprintln(os.Stderr, "Warning: Brave is running. Quit Brave for a clean apply.")
+	}
+	backupBeforeChange(l, brave.BackupInfo{Reason: brave.BackupApply, Source: what})
+	before := history.Take()
+	var saved brave.Target
+	if desired != nil {
+		saved = desired.Target
+	}
+	managed, err := brave.ApplySettingsTo(l, settings, brave.ResolveTarget(saved))
+	if err == nil {
+		err = brave.DeleteAll(userconfig.StaleLayerKeys(settings))
+	}
+	journal(history.ActionApply, what, before)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "apply failed: %v\n", err)
+		os.Exit(1)
+	}
+	saveTarget(st, managed)
+	if managed {
+		fmt.Printf("Applied %s (enforced). Restart Brave for changes to take effect.\n", what)
+	} else {
+		fmt.Printf("Applied %s to user prefs. Restart Brave. For enforced policies, approve the macOS authentication dialog when you run apply.\n", what)
+	}
+}
+
+// extensionLabel returns "Name (id)" for catalog extensions, or the id.
+func extensionLabel(id string) string {
+	if name := extensions.NameFor(id); name != "" {
+		return fmt.Sprintf("%s (%s)", name, id)
+	}
+	return id
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/cowardly/cowardly/internal/compare"
+	"github.com/cowardly/cowardly/internal/history"
+	"github.com/cowardly/cowardly/internal/userconfig"
+)
+
+// historyListLimit is how many entries `cowardly history` shows without --all.
+const historyListLimit = 20
+
+// journal records the changes made since before; a journal error is only a warning.
+func journal(action, source string, before history.Snapshot) {
+	if err := history.Record(cfgPaths, action, source, before); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: history not recorded: %v\n", err)
+	}
+}
+
+// reapplySource names what --reapply applied, for the journal.
+func reapplySource(d *userconfig.DesiredState) string {
+	switch {
+	case d.Preset == "privacy-guides":
+		return "privacy-guides:" + d.BasePreset
+	case d.Preset != "":
+		return d.Preset
+	}
+	return d.ApplyFile
+}
+
+// historyCmd handles `cowardly history [list|show <n>] [--all]`.
+func historyCmd(args []string) {
+	all := false
+	var rest []string
+	for _, a := range args {
+		if a == "--all" {
+			all = true
+			continue
+		}
+		rest = append(rest, a)
+	}
+	entries, err := history.List(cfgPaths)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "history: %v\n", err)
+		os.Exit(1)
+	}
+	if len(rest) > 0 && rest[0] == "show" {
+		if len(rest) != 2 {
+			fmt.Fprintln(os.Stderr, "Usage: cowardly history show <n>")
+			os.Exit(1)
+		}
+		n := parseEntryNumber(rest[1], len(entries))
+		showHistoryEntry(n, entries[n-1])
+		return
+	}
+	if len(rest) > 0 && rest[0] != "list" {
+		fmt.Fprintf(os.Stderr, "history: unknown subcommand %q (use list, show)\n", rest[0])
+		os.Exit(1)
+	}
+	if len(entries) == 0 {
+		fmt.Println("No history yet. Applies, resets, restores and reapplies are recorded from now on.")
+		return
+	}
+	start := 0
+	if !all && len(entries) > historyListLimit {
+		start = len(entries) - historyListLimit
+		fmt.Printf("(last %d of %d entries; --all for every entry)\n", historyListLimit, len(entries))
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tTIME\tACTION\tSOURCE\tPROFILE\tCHANGES")
+	for i := start; i < len(entries); i++ {
+		e := entries[i]
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n", i+1, e.Time.Local().Format("2006-01-02 15:04"), e.Action, e.Source, e.Profile, changeSummary(e.Changes))
+	}
+	if err := tw.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "history: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("\nDetails: cowardly history show <n>   Revert: cowardly undo [<n>]")
+}
+
+// changeSummary returns e.g. "12 keys (managed 10, user 2)".
+func changeSummary(changes []history.Change) string {
+	if len(changes) == 0 {
+		return "no changes"
+	}
+	var managed, user int
+	for _, c := range changes {
+		if c.Scope == history.Managed {
+			managed++
+		} else {
+			user++
+		}
+	}
+	return fmt.Sprintf("%d keys (managed %d, user %d)", len(changes), managed, user)
+}
+
+func showHistoryEntry(n int, e history.Entry) {
+	fmt.Printf("#%d %s %s", n, e.Time.Local().Format("2006-01-02 15:04:05"), e.Action)
+	if e.Source != "" {
+		fmt.Printf(" %s", e.Source)
+	}
+	if e.Profile != "" {
+		fmt.Printf(" (profile %s)", e.Profile)
+	}
+	fmt.Println()
+	if len(e.Changes) == 0 {
+		fmt.Println("  no changes")
+		return
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, c := range e.Changes {
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t-> %s\n", c.Scope, c.Key, historyValue(c.Key, c.Before), historyValue(c.Key, c.After))
+	}
+	if err := tw.Flush(); err != nil {
+		fmt.Fprintf(os.Stderr, "history: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func historyValue(key string, v *history.Value) string {
+	if v == nil {
+		return "(not set)"
+	}
+	return compare.FormatValue(v.Setting(key))
+}
+
+// parseEntryNumber parses a 1-based history entry number, exiting on error.
+func parseEntryNumber(s string, count int) int {
+	n, err := strconv.Atoi(strings.TrimPrefix(s, "#"))
+	if err != nil || n < 1 || n > count {
+		fmt.Fprintf(os.Stderr, "history: no entry %q (1-%d)\n", s, count)
+		os.Exit(1)
+	}
+	return n
+}
+
+// undoCmd handles `cowardly undo [<n>]`.
+func undoCmd(args []string) {
+	n := 0
+	if len(args) > 1 {
+		fmt.Fprintln(os.Stderr, "Usage: cowardly undo [<n>]")
+		os.Exit(1)
+	}
+	if len(args) == 1 {
+		entries, err := history.List(cfgPaths)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "undo: %v\n", err)
+			os.Exit(1)
+		}
+		n = parseEntryNumber(args[0], len(entries))
+	}
+	e, drifted, err := history.Undo(cfgPaths, n)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "undo: %v\n", err)
+		os.Exit(1)
+	}
+	if len(e.Changes) == 0 {
+		fmt.Printf("Nothing to undo: %s %s changed no keys.\n", e.Action, e.Source)
+		return
+	}
+	for _, c := range drifted {
+		fmt.Fprintf(os.Stderr, "Warning: %s %s changed since (now differs from %s); reverted anyway.\n", c.Scope, c.Key, historyValue(c.Key, c.After))
+	}
+	fmt.Printf("Reverted %s of %s %s. Restart Brave for changes to take effect.\n", changeSummary(e.Changes), e.Action, e.Source)
+}
@@ -3,121 +3,258 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/cowardly/cowardly/internal/bookmarks"
 	"github.com/cowardly/cowardly/internal/brave"
+	"github.com/cowardly/cowardly/internal/compare"
 	"github.com/cowardly/cowardly/internal/config"
+	"github.com/cowardly/cowardly/internal/content"
+	"github.com/cowardly/cowardly/internal/doh"
+	"github.com/cowardly/cowardly/internal/extensions"
+	"github.com/cowardly/cowardly/internal/history"
+	"github.com/cowardly/cowardly/internal/lock"
+	"github.com/cowardly/cowardly/internal/paths"
 	"github.com/cowardly/cowardly/internal/presets"
+	"github.com/cowardly/cowardly/internal/proxy"
+	"github.com/cowardly/cowardly/internal/score"
+	"github.com/cowardly/cowardly/internal/search"
+	"github.com/cowardly/cowardly/internal/shields"
+	"github.com/cowardly/cowardly/internal/startup"
 	"github.com/cowardly/cowardly/internal/ui"
+	"github.com/cowardly/cowardly/internal/urlfilter"
 	"github.com/cowardly/cowardly/internal/userconfig"
 )
 
 // Version is set at build time via -ldflags (e.g. -ldflags "-X main.Version=v0.2.0"). If unset, builds show "dev".
 var Version = "dev"
 
+// cfgPaths are the config file and state dir, resolved once in main from --config, --state-dir and the environment.
+var cfgPaths paths.Paths
+
+// cfgStore reads and writes the config file at cfgPaths.Config.
+var cfgStore userconfig.Store
+
 func main() {
 	if !brave.IsMacOS() {
 		fmt.Fprintln(os.Stderr, "cowardly only supports macOS.")
 		os.Exit(1)
 	}
 
-	args := os.Args[1:]
-	for _, arg := range args {
-		if strings.TrimLeft(arg, "-") == "beta" {
-			brave.UseBeta(true)
-			break
+	pathOpts, args, err := paths.ParseFlags(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cowardly: %v\n", err)
+		os.Exit(1)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cowardly: home dir: %v\n", err)
+		os.Exit(1)
+	}
+	cfgPaths, err = paths.Resolve(pathOpts, os.Getenv, home)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cowardly: %v\n", err)
+		os.Exit(1)
+	}
+	cfgStore = userconfig.New(cfgPaths)
+	wait, args := parseWait(args)
+	lock.UseWait(wait)
+	target, args, err := parseTarget(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cowardly: %v\n", err)
+		os.Exit(1)
+	}
+	brave.UseTarget(target)
+
+	all, variant, app, args, err := parseChannel(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cowardly: %v\n", err)
+		os.Exit(1)
+	}
+	if app != "" {
+		if err := useApp(app, all, variant); err != nil {
+			fmt.Fprintf(os.Stderr, "cowardly: %v\n", err)
+			os.Exit(1)
 		}
+	} else if all {
+		runAllChannels(args)
+		return
+	} else {
+		brave.UseVariant(variant)
+	}
+	if dispatch(args) {
+		return
 	}
-	for _, arg := range args {
+
+	if _, err := presets.AllWithError(); err != nil {
+		fmt.Fprintf(os.Stderr, "Presets failed to load: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !brave.BraveInstalled() {
+		fmt.Fprintf(os.Stderr, "%v. Install Brave first.\n", errNotInstalled())
+		os.Exit(1)
+	}
+
+	p := tea.NewProgram(ui.NewModel(cfgPaths), tea.WithAltScreen())
+	if _, err := p.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// dispatch runs the command in args for the current channel. It returns false if args has no command
+// (start the TUI).
+func dispatch(args []string) bool {
+	for i, arg := range args {
 		arg = strings.TrimLeft(arg, "-")
+		if run := channelCommand(arg); run != nil {
+			if err := run(); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return true
+		}
 		switch {
+		case arg == "extensions":
+			extensionsCmd(args[i+1:])
+			return true
+		case arg == "urls":
+			urlsCmd(args[i+1:])
+			return true
+		case arg == "bookmarks":
+			bookmarksCmd(args[i+1:])
+			return true
+		case arg == "dns":
+			dnsCmd(args[i+1:])
+			return true
+		case arg == "proxy":
+			proxyCmd(args[i+1:])
+			return true
+		case arg == "content":
+			contentCmd(args[i+1:])
+			return true
+		case arg == "history":
+			historyCmd(args[i+1:])
+			return true
+		case arg == "undo":
+			undoCmd(args[i+1:])
+			return true
+		case arg == "config":
+			configCmd(args[i+1:])
+			return true
+		case arg == "watch":
+			watchCmd(args[i+1:])
+			return true
+		case arg == "profile" || arg == "profiles":
+			profileCmd(args[i+1:])
+			return true
+		case arg == "set":
+			setCmd(args[i+1:])
+			return true
+		case strings.HasPrefix(arg, "set="):
+			setCmd(append([]string{strings.TrimPrefix(arg, "set=")}, args[i+1:]...))
+			return true
+		case arg == "unset":
+			unsetCmd(args[i+1:])
+			return true
+		case strings.HasPrefix(arg, "unset="):
+			unsetCmd(append([]string{strings.TrimPrefix(arg, "unset=")}, args[i+1:]...))
+			return true
 		case arg == "help" || arg == "h":
 			printUsage()
-			return
+			return true
 		case arg == "version" || arg == "v":
 			versionInfo()
-			return
+			return true
+		case arg == "score":
+			fmt.Println(score.Current().Format(10))
+			return true
 		case arg == "current" || arg == "c":
 			current()
-			return
+			return true
 		case arg == "reset" || arg == "r":
 			reset()
-			return
-		case arg == "apply" || arg == "a":
-			applyPreset("quick")
-			return
-		case strings.HasPrefix(arg, "apply="):
-			applyPreset(strings.TrimPrefix(arg, "apply="))
-			return
-		case arg == "privacy-guides":
-			applyPrivacyGuides(parsePrivacyGuidesBase("privacy-guides"))
-			return
-		case strings.HasPrefix(arg, "privacy-guides="):
-			applyPrivacyGuides(strings.TrimPrefix(arg, "privacy-guides="))
-			return
+			return true
 		case arg == "dry-run":
 			dryRun("quick")
-			return
+			return true
 		case strings.HasPrefix(arg, "dry-run="):
 			dryRun(strings.TrimPrefix(arg, "dry-run="))
-			return
-		case strings.HasPrefix(arg, "diff="):
-			diffPreset(strings.TrimPrefix(arg, "diff="))
-			return
+			return true
+		case strings.HasPrefix(arg, "compare="):
+			comparePresets(strings.TrimPrefix(arg, "compare="))
+			return true
 		case strings.HasPrefix(arg, "export="):
 			exportSettings(strings.TrimPrefix(arg, "export="))
-			return
-		case strings.HasPrefix(arg, "apply-file="):
-			applyFile(strings.TrimPrefix(arg, "apply-file="))
-			return
-		case arg == "reapply":
-			reapply()
-			return
-		case arg == "install-login-hook":
-			installLoginHook()
-			return
+			return true
 		case arg == "backups" || arg == "b":
-			listBackups()
-			return
+			backupsCmd(args[i+1:])
+			return true
+		case arg == "backup":
+			backupCmd(args)
+			return true
+		case strings.HasPrefix(arg, "backup-diff="):
+			backupDiff(strings.TrimPrefix(arg, "backup-diff="))
+			return true
 		case strings.HasPrefix(arg, "restore="):
 			restoreBackup(strings.TrimPrefix(arg, "restore="))
-			return
+			return true
 		case strings.HasPrefix(arg, "delete-backup="):
 			deleteBackup(strings.TrimPrefix(arg, "delete-backup="))
-			return
+			return true
 		}
 	}
+	return false
+}
 
-	if _, err := presets.AllWithError(); err != nil {
-		fmt.Fprintf(os.Stderr, "Presets failed to load: %v\n", err)
-		os.Exit(1)
+// parseWait removes --wait from args. With it, commands wait for another running cowardly instead of failing.
+func parseWait(args []string) (bool, []string) {
+	wait := false
+	var rest []string
+	for _, a := range args {
+		if a == "--wait" || a == "-wait" {
+			wait = true
+			continue
+		}
+		rest = append(rest, a)
 	}
+	return wait, rest
+}
 
-	if !brave.BraveInstalled() {
-		which := "Brave Browser"
-		if brave.IsBeta() {
-			which = "Brave Browser Beta"
+// parseTarget removes --target=<managed|user|auto> from args. Without it the target is "" (see brave.ResolveTarget).
+func parseTarget(args []string) (brave.Target, []string, error) {
+	var target brave.Target
+	var rest []string
+	for _, a := range args {
+		flag := strings.TrimLeft(a, "-")
+		if !strings.HasPrefix(a, "-") || !strings.HasPrefix(flag, "target=") {
+			rest = append(rest, a)
+			continue
 		}
-		fmt.Fprintf(os.Stderr, "%s not found in /Applications. Install Brave first.\n", which)
-		os.Exit(1)
+		t, err := brave.ParseTarget(strings.TrimPrefix(flag, "target="))
+		if err != nil {
+			return "", nil, err
+		}
+		target = t
 	}
+	return target, rest, nil
+}
 
-	p := tea.NewProgram(ui.NewModel(), tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
-		fmt.Fprintf(os.Stderr, "error: %v\n", err)
-		os.Exit(1)
+// saveTarget records in cowardly.yaml where an apply wrote, so --reapply and drift detection use the same scope.
+func saveTarget(st userconfig.Store, managed bool) {
+	if err := st.WriteTarget(managed); err != nil {
+		fmt.Fprintf(os.Stderr, "Note: could not save the apply target to cowardly.yaml: %v\n", err)
 	}
 }
 
 func versionInfo() {
 	fmt.Printf("Cowardly version: %s\n", Version)
-	which := "Brave"
-	if brave.IsBeta() {
-		which = "Brave Beta"
-	}
+	which := brave.CurrentVariant().AppName()
 	if v := brave.BraveVersion(); v != "" {
 		fmt.Printf("%s version: %s\n", which, v)
 	} else {
@@ -129,7 +266,7 @@ func versionInfo() {
 // "privacy-guides" -> config base if set, else "quick"; "privacy-guides:max-privacy" -> "max-privacy".
 func parsePrivacyGuidesBase(presetID string) string {
 	if presetID == "privacy-guides" {
-		base, _ := userconfig.PrivacyGuidesBaseFromConfig()
+		base, _ := cfgStore.PrivacyGuidesBaseFromConfig()
 		if base != "" {
 			return base
 		}
@@ -156,7 +293,7 @@ func findPreset(id string) *presets.Preset {
 
 func privacyGuidesSettings(baseID string) ([]brave.Setting, error) {
 	if baseID == "custom" {
-		desired, _ := userconfig.Read()
+		desired, _ := cfgStore.Read()
 		if desired == nil || len(desired.Settings) == 0 {
 			return nil, fmt.Errorf("no custom settings in config to use as base")
 		}
@@ -187,61 +324,84 @@ func dryRun(presetID string) {
 		settings = p.Settings
 	}
 	fmt.Println(brave.DryRun(settings))
+	if overrides := readOverrides("dry-run"); len(overrides) > 0 {
+		fmt.Println()
+		printOverrides(overrides)
+	}
 }
 
-func diffPreset(presetID string) {
+func diffPreset(presetID string) error {
 	var settings []brave.Setting
 	if baseID := parsePrivacyGuidesBase(presetID); baseID != "" {
 		var err error
 		settings, err = privacyGuidesSettings(baseID)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "privacy-guides: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("privacy-guides: %w", err)
 		}
 	} else {
 		p := findPreset(presetID)
 		if p == nil {
-			fmt.Fprintf(os.Stderr, "Preset %q not found.\n", presetID)
-			os.Exit(1)
+			return fmt.Errorf("preset %q not found", presetID)
 		}
 		settings = p.Settings
 	}
-	diff := brave.Diff(settings)
+	diff := brave.Diff(settings, presets.ValueNames())
 	if diff == "" {
 		fmt.Println("No changes (current values match preset).")
-		return
+		return nil
 	}
 	fmt.Println("Would change:")
 	fmt.Println(diff)
+	return nil
 }
 
-func applyPrivacyGuides(basePresetID string) {
+// comparePresets prints the keys that differ between two sources ("a,b"); with one source, compares it with current.
+func comparePresets(spec string) {
+	parts := strings.Split(spec, ",")
+	if len(parts) == 1 {
+		parts = append(parts, compare.Current)
+	}
+	if len(parts) != 2 {
+		fmt.Fprintln(os.Stderr, "Usage: cowardly --compare=<a>,<b>  (preset id, privacy-guides[:base], current, or a backup)")
+		os.Exit(1)
+	}
+	var sources [2]compare.Source
+	for i, p := range parts {
+		src, err := compare.Load(cfgPaths, p)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "compare: %v\n", err)
+			os.Exit(1)
+		}
+		sources[i] = src
+	}
+	fmt.Println(compare.Compare(sources[0], sources[1]).Format())
+}
+
+func applyPrivacyGuides(basePresetID string) error {
 	if basePresetID == "" {
 		basePresetID = presets.PrivacyGuidesBasePresetID
 	}
 	if !brave.BraveInstalled() {
-		which := "Brave Browser"
-		if brave.IsBeta() {
-			which = "Brave Browser Beta"
-		}
-		fmt.Fprintf(os.Stderr, "%s not found in /Applications.\n", which)
-		os.Exit(1)
+		return errNotInstalled()
 	}
 	if brave.BraveRunning() {
 		fmt.Fprintln(os.Stderr, "Warning: Brave is running. Quit Brave for a clean apply.")
 	}
 	settings, err := privacyGuidesSettings(basePresetID)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "privacy-guides: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("privacy-guides: %w", err)
 	}
-	if path, err := brave.BackupUserPlist(); err == nil {
-		fmt.Fprintf(os.Stderr, "Backed up user plist to %s\n", path)
+	l, st, err := lockState()
+	if err != nil {
+		return fmt.Errorf("apply failed: %w", err)
 	}
-	managed, err := brave.ApplySettings(settings)
+	defer l.Release()
+	backupBeforeChange(l, brave.BackupInfo{Reason: brave.BackupApply, Source: "privacy-guides:" + basePresetID})
+	before := history.Take()
+	managed, err := brave.ApplySettings(l, st.WithLayers(settings))
+	journal(history.ActionApply, "privacy-guides:"+basePresetID, before)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "apply failed: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("apply failed: %w", err)
 	}
 	if managed {
 		fmt.Printf("Applied Privacy Guides recommendations (enforced). Restart Brave for changes to take effect.\n")
@@ -249,83 +409,84 @@ func applyPrivacyGuides(basePresetID string) {
 		fmt.Printf("Applied Privacy Guides recommendations. Restart Brave. For enforced policies, approve the macOS authentication dialog when you run apply.\n")
 	}
 	fmt.Fprintf(os.Stderr, "Source: %s\n", presets.PrivacyGuidesURL)
-	if err := userconfig.WritePrivacyGuides(basePresetID); err != nil {
-		fmt.Fprintf(os.Stderr, "Note: could not save desired state to ~/.config/cowardly: %v\n", err)
+	if err := st.WritePrivacyGuides(basePresetID); err != nil {
+		fmt.Fprintf(os.Stderr, "Note: could not save desired state to cowardly.yaml: %v\n", err)
 	}
+	saveTarget(st, managed)
+	return nil
 }
 
-func applyPreset(presetID string) {
+func applyPreset(presetID string) error {
 	if !brave.BraveInstalled() {
-		which := "Brave Browser"
-		if brave.IsBeta() {
-			which = "Brave Browser Beta"
-		}
-		fmt.Fprintf(os.Stderr, "%s not found in /Applications.\n", which)
-		os.Exit(1)
+		return errNotInstalled()
 	}
 	if brave.BraveRunning() {
 		fmt.Fprintln(os.Stderr, "Warning: Brave is running. Quit Brave for a clean apply.")
 	}
 	p := findPreset(presetID)
 	if p == nil {
-		fmt.Fprintf(os.Stderr, "Preset %q not found. Use --current to list preset IDs from presets.\n", presetID)
-		os.Exit(1)
+		return fmt.Errorf("preset %q not found (use --current to list preset IDs)", presetID)
 	}
-	if path, err := brave.BackupUserPlist(); err == nil {
-		fmt.Fprintf(os.Stderr, "Backed up user plist to %s\n", path)
+	l, st, err := lockState()
+	if err != nil {
+		return fmt.Errorf("apply failed: %w", err)
 	}
-	managed, err := brave.ApplySettings(p.Settings)
+	defer l.Release()
+	backupBeforeChange(l, brave.BackupInfo{Reason: brave.BackupApply, Source: p.ID})
+	before := history.Take()
+	managed, err := brave.ApplySettings(l, st.WithLayers(p.Settings))
+	journal(history.ActionApply, p.ID, before)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "apply failed: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("apply failed: %w", err)
 	}
 	if managed {
 		fmt.Printf("Applied preset %q (enforced). Restart Brave for changes to take effect.\n", p.Name)
 	} else {
 		fmt.Printf("Applied preset %q to user prefs. Restart Brave. For enforced policies, approve the macOS authentication dialog when you run apply.\n", p.Name)
 	}
-	if err := userconfig.WritePreset(presetID, p.Settings); err != nil {
-		fmt.Fprintf(os.Stderr, "Note: could not save desired state to ~/.config/cowardly: %v\n", err)
+	if err := st.WritePreset(presetID, p.Settings); err != nil {
+		fmt.Fprintf(os.Stderr, "Note: could not save desired state to cowardly.yaml: %v\n", err)
 	}
+	saveTarget(st, managed)
+	return nil
 }
 
-func applyFile(path string) {
+func applyFile(path string) error {
 	if !brave.BraveInstalled() {
-		which := "Brave Browser"
-		if brave.IsBeta() {
-			which = "Brave Browser Beta"
-		}
-		fmt.Fprintf(os.Stderr, "%s not found in /Applications.\n", which)
-		os.Exit(1)
+		return errNotInstalled()
 	}
 	if brave.BraveRunning() {
 		fmt.Fprintln(os.Stderr, "Warning: Brave is running. Quit Brave for a clean apply.")
 	}
 	settings, err := presets.LoadSettingsFromFile(path)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Load file: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("load file: %w", err)
 	}
 	if len(settings) == 0 {
-		fmt.Fprintln(os.Stderr, "No settings in file.")
-		os.Exit(1)
+		return fmt.Errorf("no settings in %s", path)
 	}
-	if backupPath, err := brave.BackupUserPlist(); err == nil {
-		fmt.Fprintf(os.Stderr, "Backed up user plist to %s\n", backupPath)
+	l, st, err := lockState()
+	if err != nil {
+		return fmt.Errorf("apply failed: %w", err)
 	}
-	managed, err := brave.ApplySettings(settings)
+	defer l.Release()
+	backupBeforeChange(l, brave.BackupInfo{Reason: brave.BackupApply, Source: path})
+	before := history.Take()
+	managed, err := brave.ApplySettings(l, st.WithLayers(settings))
+	journal(history.ActionApply, path, before)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "apply failed: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("apply failed: %w", err)
 	}
 	if managed {
 		fmt.Printf("Applied %d setting(s) from file (enforced). Restart Brave.\n", len(settings))
 	} else {
 		fmt.Printf("Applied %d setting(s) from file to user prefs. Restart Brave.\n", len(settings))
 	}
-	if err := userconfig.WriteApplyFile(path, settings); err != nil {
-		fmt.Fprintf(os.Stderr, "Note: could not save desired state to ~/.config/cowardly: %v\n", err)
+	if err := st.WriteApplyFile(path, settings); err != nil {
+		fmt.Fprintf(os.Stderr, "Note: could not save desired state to cowardly.yaml: %v\n", err)
 	}
+	saveTarget(st, managed)
+	return nil
 }
 
 func exportSettings(path string) {
@@ -367,6 +528,20 @@ func exportKeysList() []string {
 			keys = append(keys, cs.Key)
 		}
 	}
+	var layerKeys []string
+	layerKeys = append(layerKeys, search.Keys...)
+	layerKeys = append(layerKeys, extensions.Keys...)
+	layerKeys = append(layerKeys, urlfilter.Keys...)
+	layerKeys = append(layerKeys, bookmarks.Key)
+	layerKeys = append(layerKeys, doh.Keys...)
+	layerKeys = append(layerKeys, proxy.Keys...)
+	layerKeys = append(layerKeys, content.Keys()...)
+	for _, k := range layerKeys {
+		if !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
 	return keys
 }
 
@@ -375,10 +550,16 @@ func reset() {
 		fmt.Fprintln(os.Stderr, "Brave is running. Quit Brave (Cmd+Q), then run reset again. If Brave is running, it can restore the plist from memory and the reset will not stick.")
 		os.Exit(1)
 	}
-	if path, err := brave.BackupUserPlist(); err == nil {
-		fmt.Fprintf(os.Stderr, "Backed up user plist to %s\n", path)
+	l, _, err := lockState()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "reset failed: %v\n", err)
+		os.Exit(1)
 	}
-	hadManaged, managedRemoved, err := brave.Reset()
+	defer l.Release()
+	backupBeforeChange(l, brave.BackupInfo{Reason: brave.BackupReset})
+	before := history.Take()
+	hadManaged, managedRemoved, err := brave.Reset(l)
+	journal(history.ActionReset, "", before)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "reset failed: %v\n", err)
 		os.Exit(1)
@@ -399,37 +580,188 @@ func current() {
 		"BraveRewardsDisabled", "BraveWalletDisabled", "BraveVPNDisabled",
 		"BraveAIChatEnabled", "TorDisabled", "SyncDisabled",
 		"ShoppingListEnabled", "AlwaysOpenPdfExternally", "TranslateEnabled",
-		"SpellcheckEnabled", "PromotionsEnabled", "DnsOverHttpsMode",
-	}
+		"SpellcheckEnabled", "PromotionsEnabled", doh.KeyMode, doh.KeyTemplates,
+	}
+	keys = append(keys, search.Keys...)
+	keys = append(keys, extensions.Keys...)
+	keys = append(keys, urlfilter.Keys...)
+	keys = append(keys, bookmarks.Key)
+	keys = append(keys, proxy.Keys...)
+	keys = append(keys, content.Keys()...)
+	keys = append(keys, shields.Keys()...)
+	keys = append(keys, startup.Keys...)
 	if brave.ManagedPlistExists() {
 		fmt.Println("(Managed plist present — enforced values shown when set)")
 	}
+	names := presets.ValueNames()
 	for _, key := range keys {
 		managedVal, managedOK := brave.ReadManaged(key)
 		userVal, userOK := brave.Read(key)
 		if managedOK {
-			fmt.Printf("  %s = %s (enforced)\n", key, managedVal)
+			fmt.Printf("  %s = %s (enforced)\n", key, names.Label(key, managedVal))
 		} else if userOK {
-			fmt.Printf("  %s = %s (user)\n", key, userVal)
+			fmt.Printf("  %s = %s (user)\n", key, names.Label(key, userVal))
 		} else {
 			fmt.Printf("  %s = (not set)\n", key)
 		}
 	}
+	fmt.Printf("\nProxy: %s\n", currentProxy())
+	if overrides := readOverrides("current"); len(overrides) > 0 {
+		fmt.Println()
+		printOverrides(overrides)
+		for _, s := range overrides {
+			if d := brave.Drifted([]brave.Setting{s}); len(d) > 0 {
+				fmt.Printf("  (%s is not applied yet; run --reapply)\n", s.Key)
+			}
+		}
+	}
 }
 
 func listBackups() {
-	paths, err := brave.ListBackups()
+	backups, err := brave.ListBackups(cfgPaths)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "list backups: %v\n", err)
 		os.Exit(1)
 	}
-	if len(paths) == 0 {
+	if len(backups) == 0 {
 		fmt.Println("No backups. Apply a preset or reset to create one.")
 		return
 	}
-	for _, p := range paths {
-		fmt.Println(p)
+	for _, b := range backups {
+		fmt.Println(b.Path)
+		if b.Info != nil && b.Info.Label != "" {
+			fmt.Printf("    %q\n", b.Info.Label)
+		}
+		fmt.Printf("    %s\n", b.Summary())
+	}
+}
+
+// backupsCmd handles `cowardly backups [list|prune [--dry-run]]`.
+func backupsCmd(args []string) {
+	sub := ""
+	dryRun := false
+	for _, a := range args {
+		switch {
+		case a == "--dry-run":
+			dryRun = true
+		case !strings.HasPrefix(a, "-") && sub == "":
+			sub = a
+		}
+	}
+	switch sub {
+	case "", "list":
+		listBackups()
+	case "prune":
+		pruneBackups(dryRun)
+	default:
+		fmt.Fprintln(os.Stderr, "Usage: cowardly backups [list|prune [--dry-run]]")
+		os.Exit(1)
+	}
+}
+
+// pruneBackups deletes the backups the retention policy in cowardly.yaml does not keep, or with dryRun
+// lists them.
+func pruneBackups(dryRun bool) {
+	r, err := cfgStore.Retention()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "prune: %v\n", err)
+		os.Exit(1)
+	}
+	var pruned []brave.Backup
+	verb := "Deleted"
+	if dryRun {
+		verb = "Would delete"
+		pruned, err = brave.ExpiredBackups(cfgPaths, r)
+	} else {
+		var l *lock.Lock
+		l, err = lock.Acquire(cfgPaths.State)
+		if err == nil {
+			pruned, err = brave.PruneBackups(l, cfgPaths, r)
+			l.Release()
+		}
+	}
+	for _, b := range pruned {
+		fmt.Printf("%s %s  (%s)\n", verb, b.Path, b.Summary())
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "prune: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s %d backup(s). Policy: %s; labelled backups are always kept.\n", verb, len(pruned), r)
+}
+
+// backupCmd handles `cowardly --backup [--label=<text>]`: take a manual snapshot of the Brave preferences.
+func backupCmd(args []string) {
+	info := brave.BackupInfo{Reason: brave.BackupManual}
+	for _, a := range args {
+		flag := strings.TrimLeft(a, "-")
+		if strings.HasPrefix(a, "-") && strings.HasPrefix(flag, "label=") {
+			info.Label = strings.TrimPrefix(flag, "label=")
+		}
+	}
+	l, err := lock.Acquire(cfgPaths.State)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "backup: %v\n", err)
+		os.Exit(1)
+	}
+	path, pruned, err := brave.CreateBackup(l, cfgPaths, retention(), info)
+	l.Release()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "backup: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Backed up Brave preferences to %s\n", path)
+	printPruned(os.Stdout, pruned)
+}
+
+// backupBeforeChange backs up the Brave preferences before an apply, reapply or reset and reports the
+// backup and the backups it pruned on stderr. A failed backup does not stop the change. l is the
+// cowardly lock the change holds.
+func backupBeforeChange(l *lock.Lock, info brave.BackupInfo) {
+	path, pruned, err := brave.CreateBackup(l, cfgPaths, retention(), info)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Backed up Brave preferences to %s\n", path)
+	printPruned(os.Stderr, pruned)
+}
+
+// lockState takes the cowardly lock for a change to the Brave preferences and returns it with a store
+// whose config writes run under it, so the backup, snapshot, apply, journal and save are one step for
+// other cowardly processes. The caller releases the lock.
+func lockState() (*lock.Lock, userconfig.Store, error) {
+	l, err := lock.Acquire(cfgPaths.State)
+	if err != nil {
+		return nil, userconfig.Store{}, err
 	}
+	return l, cfgStore.Holding(l), nil
+}
+
+// retention returns the backup retention policy from cowardly.yaml. An unreadable config prunes nothing;
+// `backups prune` reports the error.
+func retention() brave.Retention {
+	r, err := cfgStore.Retention()
+	if err != nil {
+		return brave.Retention{}
+	}
+	return r
+}
+
+// printPruned lists the backups the retention policy deleted after a new backup.
+func printPruned(w io.Writer, pruned []brave.Backup) {
+	for _, b := range pruned {
+		fmt.Fprintf(w, "Pruned old backup %s  (%s)\n", b.Path, b.Summary())
+	}
+}
+
+// backupDiff prints what restoring the backup name would change, key by key, in the user and managed plists.
+func backupDiff(name string) {
+	d, err := compare.DiffBackup(cfgPaths, name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "backup-diff: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(d.Format())
 }
 
 func restoreBackup(path string) {
@@ -441,7 +773,21 @@ func restoreBackup(path string) {
 	if brave.BraveRunning() {
 		fmt.Fprintln(os.Stderr, "Warning: Brave is running. Quit Brave for a clean restore.")
 	}
-	if err := brave.RestoreFromBackup(path); err != nil {
+	if b, err := brave.OpenBackup(path); err == nil && b.Managed != "" {
+		fmt.Println("The backup includes the managed plist; approve the macOS authentication dialog to restore it.")
+	} else if err == nil && b.RemovesManaged() && brave.ManagedPlistExists() {
+		fmt.Println("The backup was taken without a managed plist; approve the macOS authentication dialog to remove the current one.")
+	}
+	l, err := lock.Acquire(cfgPaths.State)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "restore failed: %v\n", err)
+		os.Exit(1)
+	}
+	defer l.Release()
+	before := history.Take()
+	err = brave.RestoreFromBackup(l, path)
+	journal(history.ActionRestore, filepath.Base(path), before)
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "restore failed: %v\n", err)
 		os.Exit(1)
 	}
@@ -461,104 +807,142 @@ func deleteBackup(path string) {
 	fmt.Println("Backup deleted.")
 }
 
-func reapply() {
+func reapply() error {
 	if !brave.BraveInstalled() {
-		which := "Brave Browser"
-		if brave.IsBeta() {
-			which = "Brave Browser Beta"
-		}
-		fmt.Fprintf(os.Stderr, "%s not found in /Applications.\n", which)
-		os.Exit(1)
+		return errNotInstalled()
 	}
 	if brave.BraveRunning() {
 		fmt.Fprintln(os.Stderr, "Warning: Brave is running. Quit Brave for a clean apply.")
 	}
-	desired, err := userconfig.Read()
+	l, st, err := lockState()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "reapply: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("reapply: %w", err)
 	}
-	if desired == nil || len(desired.Settings) == 0 {
-		fmt.Fprintln(os.Stderr, "No desired state saved. Apply a preset or use --apply-file first; then --reapply will restore it after a restart.")
-		os.Exit(1)
+	defer l.Release()
+	desired, err := st.Read()
+	if err != nil {
+		return fmt.Errorf("reapply: %w", err)
 	}
-	if path, err := brave.BackupUserPlist(); err == nil {
-		fmt.Fprintf(os.Stderr, "Backed up user plist to %s\n", path)
+	if desired == nil || len(desired.Effective()) == 0 {
+		return fmt.Errorf("no desired state saved; apply a preset or use --apply-file first, then --reapply will restore it after a restart")
 	}
-	managed, err := brave.ApplySettings(desired.Settings)
+	backupBeforeChange(l, brave.BackupInfo{Reason: brave.BackupApply, Source: reapplySource(desired)})
+	before := history.Take()
+	target := brave.ResolveTarget(desired.Target)
+	managed, err := brave.ApplySettingsTo(l, desired.Effective(), target)
+	journal(history.ActionReapply, reapplySource(desired), before)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "reapply failed: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("reapply failed: %w", err)
+	}
+	saveTarget(st, managed)
+	if profile, err := st.ActiveProfile(); err == nil && profile != userconfig.DefaultProfile {
+		fmt.Printf("Profile %q:\n", profile)
 	}
 	if desired.Preset != "" {
 		fmt.Printf("Re-applied preset %q. Restart Brave for changes to take effect.\n", desired.Preset)
 	} else if desired.ApplyFile != "" {
 		fmt.Printf("Re-applied %d setting(s) from saved config. Restart Brave.\n", len(desired.Settings))
 	} else {
-		fmt.Printf("Re-applied %d setting(s). Restart Brave.\n", len(desired.Settings))
+		fmt.Printf("Re-applied %d setting(s). Restart Brave.\n", len(desired.Effective()))
 	}
-	if managed {
+	switch {
+	case managed:
 		fmt.Println("(Enforced.)")
-	} else {
+	case target == brave.TargetUser:
+		fmt.Println("(User prefs.)")
+	default:
 		fmt.Println("(User prefs; approve the macOS dialog when you run apply for enforced policies.)")
 	}
+	return nil
 }
 
-func installLoginHook() {
-	dir, err := userconfig.ConfigDir()
+func installLoginHook() error {
+	args := append(append(pathArgs(), channelArgs()...), "--wait", "--reapply")
+	if t := brave.ChosenTarget(); t != "" {
+		args = append(args, "--target="+string(t))
+	}
+	plistPath, err := writeLaunchAgent(agentLabel("com.cowardly.reapply"), args, false, agentLog("reapply.log"))
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "install-login-hook: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("install-login-hook: %w", err)
 	}
+	fmt.Printf("Installed Launch Agent at %s\n", plistPath)
+	fmt.Println("Cowardly will run `cowardly --reapply` at login. To re-apply to managed preferences you may need to approve the macOS dialog when you log in.")
+	fmt.Println("To remove: rm", plistPath)
+	return nil
+}
+
+// pathArgs returns --config and --state-dir for a Launch Agent when the paths are not the defaults.
+// launchd does not pass the shell environment, so COWARDLY_CONFIG and XDG_* become flags.
+func pathArgs() []string {
 	home, err := os.UserHomeDir()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "install-login-hook: %v\n", err)
-		os.Exit(1)
+		return nil
+	}
+	def, err := paths.Resolve(paths.Options{}, func(string) string { return "" }, home)
+	if err != nil {
+		return nil
+	}
+	var out []string
+	if cfgPaths.Config != def.Config {
+		out = append(out, "--config="+cfgPaths.Config)
+	}
+	if cfgPaths.State != def.State {
+		out = append(out, "--state-dir="+cfgPaths.State)
+	}
+	return out
+}
+
+// writeLaunchAgent writes ~/Library/LaunchAgents/<label>.plist running this binary with args at login
+// (kept running if keepAlive), logging to logName in the state dir. Returns the plist path.
+func writeLaunchAgent(label string, args []string, keepAlive bool, logName string) (string, error) {
+	if err := os.MkdirAll(cfgPaths.State, 0755); err != nil {
+		return "", fmt.Errorf("create state dir: %w", err)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
 	}
 	launchAgentDir := filepath.Join(home, "Library", "LaunchAgents")
 	if err := os.MkdirAll(launchAgentDir, 0755); err != nil {
-		fmt.Fprintf(os.Stderr, "install-login-hook: %v\n", err)
-		os.Exit(1)
+		return "", err
 	}
 	cowardlyPath, err := os.Executable()
 	if err != nil {
 		cowardlyPath = "cowardly" // fallback to PATH
 	}
-	reapplyArgs := []string{"--reapply"}
-	if brave.IsBeta() {
-		reapplyArgs = []string{"--beta", "--reapply"}
-	}
-	plistPath := filepath.Join(launchAgentDir, "com.cowardly.reapply.plist")
-	programArgs := append([]string{cowardlyPath}, reapplyArgs...)
+	plistPath := filepath.Join(launchAgentDir, label+".plist")
+	programArgs := append([]string{cowardlyPath}, args...)
 	programArgsXML := ""
 	for _, a := range programArgs {
 		programArgsXML += fmt.Sprintf("    <string>%s</string>\n", escapePlistString(a))
 	}
+	keepAliveXML := ""
+	if keepAlive {
+		keepAliveXML = "  <key>KeepAlive</key>\n  <true/>\n"
+	}
+	logPath := escapePlistString(cfgPaths.Log(logName))
 	plist := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
 <!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
 <plist version="1.0">
 <dict>
   <key>Label</key>
-  <string>com.cowardly.reapply</string>
+  <string>%s</string>
   <key>ProgramArguments</key>
   <array>
 %s  </array>
   <key>RunAtLoad</key>
   <true/>
-  <key>StandardErrorPath</key>
-  <string>%s/reapply.log</string>
+%s  <key>StandardErrorPath</key>
+  <string>%s</string>
   <key>StandardOutPath</key>
-  <string>%s/reapply.log</string>
+  <string>%s</string>
 </dict>
 </plist>
-`, programArgsXML, dir, dir)
+`, escapePlistString(label), programArgsXML, keepAliveXML, logPath, logPath)
 	if err := os.WriteFile(plistPath, []byte(plist), 0644); err != nil {
-		fmt.Fprintf(os.Stderr, "install-login-hook: %v\n", err)
-		os.Exit(1)
+		return "", err
 	}
-	fmt.Printf("Installed Launch Agent at %s\n", plistPath)
-	fmt.Println("Cowardly will run `cowardly --reapply` at login. To re-apply to managed preferences you may need to approve the macOS dialog when you log in.")
-	fmt.Println("To remove: rm", plistPath)
+	return plistPath, nil
 }
 
 // escapePlistString escapes a string for use inside a plist <string> element.
@@ -573,24 +957,7 @@ func escapePlistString(s string) string {
 
 // resolveBackupPath returns the full path if path is a filename matching a backup, or path if it's already a full path that exists.
 func resolveBackupPath(path string) string {
-	path = strings.TrimSpace(path)
-	if path == "" {
-		return ""
-	}
-	paths, err := brave.ListBackups()
-	if err != nil {
-		return ""
-	}
-	for _, p := range paths {
-		if p == path || strings.HasSuffix(p, path) || strings.HasSuffix(p, "/"+path) {
-			return p
-		}
-	}
-	// path might be full path that wasn't in list (e.g. stale); still allow if file exists
-	if _, err := os.Stat(path); err == nil {
-		return path
-	}
-	return ""
+	return brave.ResolveBackup(cfgPaths, path)
 }
 
 func printUsage() {
@@ -599,22 +966,96 @@ func printUsage() {
 Usage:
   cowardly                        Start the TUI
   cowardly --beta                 Target Brave Browser Beta (use with any command)
+  cowardly --channel=<stable|beta|dev|nightly|all>
+                                   Target a channel; each has its own saved state, backups and login hook.
+                                   all runs --apply, --privacy-guides, --apply-file, --diff, --reapply or
+                                   --install-login-hook once per installed channel
+  cowardly --brave-app=<path>     Target the Brave app at <path> (channel read from its bundle ID)
+  cowardly --config=<file>        Use another cowardly.yaml (use with any command; or set COWARDLY_CONFIG)
+  cowardly --state-dir=<dir>      Keep backups, history and logs in <dir> (use with any command)
+  cowardly --wait                 Wait for another running cowardly instead of failing (use with any command)
+  cowardly --target=<managed|user|auto>
+                                   Where apply writes: managed (enforced; fails if the dialog is cancelled),
+                                   user prefs, or auto (managed, else user; default). Saved per profile;
+                                   --reapply and drift checks use the saved target unless one is given
   cowardly --apply, -a             Apply Quick Debloat preset and exit
   cowardly --apply=<id>            Apply preset by ID (e.g. quick, max-privacy)
   cowardly --privacy-guides [=base] Apply Privacy Guides supplement (default base: quick)
   cowardly --apply-file=<path>    Apply settings from a YAML file
-  cowardly --reapply              Re-apply last saved desired state (~/.config/cowardly)
+  cowardly --reapply              Re-apply the active profile's saved desired state (~/.config/cowardly)
   cowardly --install-login-hook    Install Launch Agent to run --reapply at login
   cowardly --dry-run [=<id>]       Show what would be applied (default: quick)
   cowardly --diff=<id>             Show which keys would change (current -> preset)
+  cowardly --compare=<a>[,<b>]     Compare two presets, privacy-guides[:base], current, or a backup
+                                   (only in a, only in b, different values; b defaults to current)
   cowardly --export=<path>         Export current settings to YAML file
   cowardly --reset, -r             Reset all Brave policy settings and exit
   cowardly --version, -v          Print cowardly and Brave version and exit
   cowardly --current, -c          Print current settings and exit
-  cowardly --backups, -b           List all backup plist paths
+  cowardly --score                 Score the current state per category and list the top missing settings
+  cowardly --backups, -b           List all backups with their reason, preset, Brave version and label
+  cowardly --backup [--label=<text>]  Back up the Brave preferences now, optionally labelled
+  cowardly backups prune [--dry-run]  Delete backups the retention policy (backups: in cowardly.yaml) does not keep
+  cowardly --backup-diff=<name>    Show what restoring a backup would change (user and managed plists)
   cowardly --restore=<path>        Restore user prefs from a backup (path or filename)
   cowardly --delete-backup=<path>  Delete a backup file
+  cowardly extensions [list]       List managed extensions (force-install, block, allow)
+  cowardly extensions catalog      List extension names known to cowardly
+  cowardly extensions add <force|block|allow> <id|name>...
+                                   Manage extensions and apply (kept for --reapply)
+  cowardly extensions remove <id|name>...
+                                   Stop managing extensions and apply
+  cowardly urls [list]             List URLBlocklist / URLAllowlist entries
+  cowardly urls add <block|allow> <domain|url>...
+  cowardly urls remove <domain|url>...
+  cowardly urls import <block|allow> <file> [--format=auto|hosts|domains|adguard]
+                                   Import a hosts file, domain list or AdGuard list
+  cowardly urls clear [block|allow]
+  cowardly bookmarks [show]        Show the managed bookmarks folder (ManagedBookmarks)
+  cowardly bookmarks import <file.yaml|bookmarks.html> [--name=<folder>]
+                                   Import YAML or a browser HTML export and apply
+  cowardly bookmarks export <file.yaml>
+  cowardly bookmarks clear
+  cowardly dns [show]              Show the managed DNS-over-HTTPS resolver
+  cowardly dns list                List resolvers (Quad9, Mullvad, Cloudflare, NextDNS, custom)
+  cowardly dns set <resolver> [--mode=secure|automatic] [--<variable>=<value>]
+                                   Set DnsOverHttpsMode and DnsOverHttpsTemplates and apply
+  cowardly dns automatic|off       Set the mode without a resolver
+  cowardly dns clear               Stop managing DNS over HTTPS
+  cowardly proxy [show]            Show the saved and current proxy configuration
+  cowardly proxy set <mode> [--server=host:port] [--pac-url=URL] [--bypass=a,b] [--dict]
+                                   Modes: direct, system, auto_detect, pac_script, fixed_servers
+  cowardly proxy clear             Stop managing the proxy
+  cowardly content [show]          Show per-site content settings (cookies, javascript, popups, notifications, images)
+  cowardly content add <type> <allow|block|session_only> <pattern>...
+  cowardly content remove <type> <pattern>...
+  cowardly content default <type> <allow|block|session_only|ask|unset>
+  cowardly content diff            Show which content policies would change
+  cowardly content clear [<type>]
+  cowardly profile [list]          List profiles in cowardly.yaml (* = active)
+  cowardly profile create <name> [--from=<profile>]
+                                   Add an empty profile, or a copy of another
+  cowardly profile switch <name> [--no-apply]
+                                   Make a profile active and apply it
+  cowardly profile delete <name>   Delete a profile (not the active one)
+  cowardly history [--all]         List applies, resets, restores and reapplies with the keys they changed
+  cowardly history show <n>        Show every before/after value of entry n
+  cowardly undo [<n>]              Revert the key changes of entry n (default: the latest), managed and user
+  cowardly watch [--managed] [--interval=2s] [--debounce=3s] [--once]
+                                   Re-apply drifted keys of the active profile whenever the
+                                   user or managed plist changes (Ctrl+C to stop)
+  cowardly watch install|uninstall Run watch as a Launch Agent (log: watch.log in the state dir)
+  cowardly config [path]           Print the path of cowardly.yaml
+  cowardly config state-dir        Print the state dir (backups, history, logs)
+  cowardly config migrate [--dry-run]
+                                   Upgrade cowardly.yaml to the current schema version
+  cowardly --set [--force] <Key>=<Value>...  Override single keys on top of the preset and layers and apply (--force for unknown keys)
+                                   (kept across preset applies; no arguments lists the overrides)
+  cowardly --unset <Key>...        Remove overrides and apply
   cowardly --help, -h              Show this help
 
-Use --beta to target Brave Browser Beta instead of stable. Restart Brave after applying or resetting settings.`)
+Paths: the config file is $XDG_CONFIG_HOME/cowardly/cowardly.yaml (default ~/.config/cowardly) and the
+state dir $XDG_STATE_HOME/cowardly (default ~/Library/Application Support/cowardly).
+
+Use --channel to target Beta, Dev or Nightly instead of stable. Restart Brave after applying or resetting settings.`)
 }
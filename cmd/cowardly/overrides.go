@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cowardly/cowardly/internal/brave"
+	"github.com/cowardly/cowardly/internal/compare"
+	"github.com/cowardly/cowardly/internal/userconfig"
+)
+
+// setCmd handles `cowardly --set [--force] Key=Value...`: save per-key overrides in the active profile and
+// apply. Keys cowardly does not know need --force. Without arguments it lists the overrides.
+func setCmd(args []string) {
+	force := false
+	for _, a := range args {
+		if a == "--force" || a == "-force" {
+			force = true
+		}
+	}
+	var settings []brave.Setting
+	for _, a := range overrideArgs(args) {
+		s, err := userconfig.ParseOverride(a, force)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "set: %v\n", err)
+			os.Exit(1)
+		}
+		settings = append(settings, s)
+	}
+	if len(settings) == 0 {
+		printOverrides(readOverrides("set"))
+		return
+	}
+	for _, s := range settings {
+		if err := cfgStore.SetOverride(s); err != nil {
+			fmt.Fprintf(os.Stderr, "set: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Override %s = %s\n", s.Key, compare.FormatValue(s))
+	}
+	applyDesiredState("overrides")
+}
+
+// unsetCmd handles `cowardly --unset Key...`: drop overrides and apply, so the preset or layer value is used again.
+func unsetCmd(args []string) {
+	keys := overrideArgs(args)
+	if len(keys) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: cowardly --unset <Key>...")
+		os.Exit(1)
+	}
+	removed := 0
+	for _, k := range keys {
+		ok, err := cfgStore.UnsetOverride(k)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "unset: %v\n", err)
+			os.Exit(1)
+		}
+		if !ok {
+			fmt.Fprintf(os.Stderr, "No override for %s.\n", k)
+			continue
+		}
+		removed++
+		fmt.Printf("Removed override %s\n", k)
+	}
+	if removed > 0 {
+		applyDesiredState("overrides")
+	}
+}
+
+// overrideArgs returns the arguments of --set/--unset, skipping other flags (e.g. --beta).
+func overrideArgs(args []string) []string {
+	var out []string
+	for _, a := range args {
+		if strings.HasPrefix(a, "-") {
+			continue
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+func readOverrides(cmd string) []brave.Setting {
+	desired, err := cfgStore.Read()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", cmd, err)
+		os.Exit(1)
+	}
+	if desired == nil {
+		return nil
+	}
+	return desired.Overrides
+}
+
+// printOverrides lists overrides under a heading naming the active profile.
+func printOverrides(overrides []brave.Setting) {
+	profile, _ := cfgStore.ActiveProfile()
+	if len(overrides) == 0 {
+		fmt.Printf("No overrides in profile %q. Add one with: cowardly --set Key=Value\n", profile)
+		return
+	}
+	fmt.Printf("Overrides (profile %q, applied on top of the preset and layers):\n", profile)
+	for _, s := range overrides {
+		fmt.Printf("  %s = %s\n", s.Key, compare.FormatValue(s))
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cowardly/cowardly/internal/userconfig"
+)
+
+// profileCmd handles `cowardly profile [list|create|switch|delete]`.
+func profileCmd(args []string) {
+	sub := "list"
+	if len(args) > 0 {
+		sub = args[0]
+		args = args[1:]
+	}
+	var from string
+	noApply := false
+	var rest []string
+	for _, a := range args {
+		switch {
+		case strings.HasPrefix(a, "--from="):
+			from = strings.TrimPrefix(a, "--from=")
+		case a == "--no-apply":
+			noApply = true
+		case strings.HasPrefix(a, "--"):
+			fmt.Fprintf(os.Stderr, "profile: unknown flag %q\n", a)
+			os.Exit(1)
+		default:
+			rest = append(rest, a)
+		}
+	}
+	switch sub {
+	case "list", "ls":
+		listProfiles()
+	case "create":
+		if len(rest) != 1 {
+			fmt.Fprintln(os.Stderr, "Usage: cowardly profile create <name> [--from=<profile>]")
+			os.Exit(1)
+		}
+		if err := cfgStore.CreateProfile(rest[0], from); err != nil {
+			fmt.Fprintf(os.Stderr, "profile: %v\n", err)
+			os.Exit(1)
+		}
+		if from != "" {
+			fmt.Printf("Created profile %q from %q. Switch to it with: cowardly profile switch %s\n", rest[0], from, rest[0])
+		} else {
+			fmt.Printf("Created empty profile %q. Switch to it with: cowardly profile switch %s\n", rest[0], rest[0])
+		}
+	case "switch", "use":
+		if len(rest) != 1 {
+			fmt.Fprintln(os.Stderr, "Usage: cowardly profile switch <name> [--no-apply]")
+			os.Exit(1)
+		}
+		switchProfile(rest[0], noApply)
+	case "delete", "rm":
+		if len(rest) != 1 {
+			fmt.Fprintln(os.Stderr, "Usage: cowardly profile delete <name>")
+			os.Exit(1)
+		}
+		if err := cfgStore.DeleteProfile(rest[0]); err != nil {
+			fmt.Fprintf(os.Stderr, "profile: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Deleted profile %q.\n", rest[0])
+	default:
+		fmt.Fprintf(os.Stderr, "profile: unknown subcommand %q (use list, create, switch, delete)\n", sub)
+		os.Exit(1)
+	}
+}
+
+func listProfiles() {
+	names, err := cfgStore.Profiles()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "profile: %v\n", err)
+		os.Exit(1)
+	}
+	active, err := cfgStore.ActiveProfile()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "profile: %v\n", err)
+		os.Exit(1)
+	}
+	for _, name := range names {
+		marker := " "
+		if name == active {
+			marker = "*"
+		}
+		desc := "empty"
+		if desired, err := cfgStore.ReadProfile(name); err != nil {
+			desc = "error: " + err.Error()
+		} else if desired != nil {
+			desc = describeDesired(desired)
+		}
+		fmt.Printf("%s %-16s %s\n", marker, name, desc)
+	}
+}
+
+// describeDesired returns a short summary of a desired state, e.g. "preset quick (34 settings)".
+func describeDesired(d *userconfig.DesiredState) string {
+	n := len(d.Effective())
+	switch {
+	case d.Preset == "privacy-guides":
+		return fmt.Sprintf("privacy-guides on %s (%d settings)", d.BasePreset, n)
+	case d.Preset != "":
+		return fmt.Sprintf("preset %s (%d settings)", d.Preset, n)
+	case d.ApplyFile != "":
+		return fmt.Sprintf("file %s (%d settings)", d.ApplyFile, n)
+	}
+	return fmt.Sprintf("%d settings", n)
+}
+
+// switchProfile makes name active and applies its desired state unless noApply is set or it is empty.
+func switchProfile(name string, noApply bool) {
+	if err := cfgStore.SwitchProfile(name); err != nil {
+		fmt.Fprintf(os.Stderr, "profile: %v\n", err)
+		os.Exit(1)
+	}
+	desired, err := cfgStore.Read()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "profile: %v\n", err)
+		os.Exit(1)
+	}
+	if desired == nil || len(desired.Effective()) == 0 {
+		fmt.Printf("Switched to profile %q. It is empty: apply a preset, file or Custom selection to fill it.\n", name)
+		return
+	}
+	if noApply {
+		fmt.Printf("Switched to profile %q. Run --reapply to apply it.\n", name)
+		return
+	}
+	applyDesiredState(fmt.Sprintf("profile %q", name))
+}
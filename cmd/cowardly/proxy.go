@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cowardly/cowardly/internal/brave"
+	"github.com/cowardly/cowardly/internal/proxy"
+)
+
+// proxyCmd handles `cowardly proxy [show|set|clear]`.
+func proxyCmd(args []string) {
+	sub := "show"
+	if len(args) > 0 {
+		sub = args[0]
+		args = args[1:]
+	}
+	switch sub {
+	case "show":
+		showProxy()
+	case "set":
+		var c proxy.Config
+		var rest []string
+		for _, a := range args {
+			switch {
+			case strings.HasPrefix(a, "--server="):
+				c.Server = strings.TrimPrefix(a, "--server=")
+			case strings.HasPrefix(a, "--pac-url="):
+				c.PacURL = strings.TrimPrefix(a, "--pac-url=")
+			case strings.HasPrefix(a, "--bypass="):
+				for _, b := range strings.Split(strings.TrimPrefix(a, "--bypass="), ",") {
+					if b = strings.TrimSpace(b); b != "" {
+						c.Bypass = append(c.Bypass, b)
+					}
+				}
+			case a == "--dict":
+				c.Dict = true
+			case strings.HasPrefix(a, "--"):
+				fmt.Fprintf(os.Stderr, "proxy: unknown flag %q\n", a)
+				os.Exit(1)
+			default:
+				rest = append(rest, a)
+			}
+		}
+		if len(rest) != 1 {
+			fmt.Fprintln(os.Stderr, "Usage: cowardly proxy set <direct|system|auto_detect|pac_script|fixed_servers> [--server=host:port] [--pac-url=URL] [--bypass=a,b] [--dict]")
+			os.Exit(1)
+		}
+		m, err := proxy.ParseMode(rest[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "proxy: %v\n", err)
+			os.Exit(1)
+		}
+		c.Mode = m
+		if err := c.Validate(); err != nil {
+			fmt.Fprintf(os.Stderr, "proxy: %v\n", err)
+			os.Exit(1)
+		}
+		saveProxy(c)
+	case "clear":
+		saveProxy(proxy.Config{})
+	default:
+		fmt.Fprintf(os.Stderr, "proxy: unknown subcommand %q (use show, set, clear)\n", sub)
+		os.Exit(1)
+	}
+}
+
+func showProxy() {
+	desired, err := cfgStore.Read()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "proxy: %v\n", err)
+		os.Exit(1)
+	}
+	if desired == nil || desired.Proxy.IsEmpty() {
+		fmt.Println("Saved: proxy is not managed. Use `cowardly proxy set <mode>`.")
+	} else {
+		fmt.Printf("Saved: %s\n", desired.Proxy.Describe())
+	}
+	fmt.Printf("Current: %s\n", currentProxy())
+}
+
+// currentProxy returns the proxy policy Brave currently uses as readable text.
+func currentProxy() string {
+	if c, ok, err := proxy.FromPolicy(brave.ReadManaged); ok {
+		if err != nil {
+			return err.Error()
+		}
+		return c.Describe() + " (enforced)"
+	}
+	if c, ok, err := proxy.FromPolicy(brave.Read); ok {
+		if err != nil {
+			return err.Error()
+		}
+		return c.Describe() + " (user)"
+	}
+	return "not set (Brave uses the system proxy settings)"
+}
+
+// saveProxy saves c and re-applies the desired state.
+func saveProxy(c proxy.Config) {
+	if err := cfgStore.WriteProxy(c); err != nil {
+		fmt.Fprintf(os.Stderr, "proxy: could not save to cowardly.yaml: %v\n", err)
+		os.Exit(1)
+	}
+	if c.IsEmpty() {
+		fmt.Println("Proxy is no longer managed by cowardly (the preset's value, if any, applies).")
+	} else {
+		fmt.Printf("Proxy: %s\n", c.Describe())
+	}
+	applyDesiredState("proxy settings")
+}
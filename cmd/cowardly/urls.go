@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cowardly/cowardly/internal/urlfilter"
+)
+
+// urlsCmd handles `cowardly urls [list|add|remove|import|clear]`.
+func urlsCmd(args []string) {
+	sub := "list"
+	if len(args) > 0 {
+		sub = args[0]
+		args = args[1:]
+	}
+	switch sub {
+	case "list", "ls":
+		listURLFilters()
+	case "add":
+		if len(args) < 2 {
+			fmt.Fprintln(os.Stderr, "Usage: cowardly urls add <block|allow> <domain|url>...")
+			os.Exit(1)
+		}
+		allow := parseURLListName(args[0])
+		updateURLFilters(func(l *urlfilter.Lists) (string, error) {
+			n, err := l.Add(allow, args[1:]...)
+			return fmt.Sprintf("Added %d entr%s.", n, plural(n, "y", "ies")), err
+		})
+	case "remove", "rm":
+		if len(args) == 0 {
+			fmt.Fprintln(os.Stderr, "Usage: cowardly urls remove <domain|url>...")
+			os.Exit(1)
+		}
+		updateURLFilters(func(l *urlfilter.Lists) (string, error) {
+			n := l.Remove(args...)
+			if n == 0 {
+				return "", fmt.Errorf("no matching entries")
+			}
+			return fmt.Sprintf("Removed %d entr%s.", n, plural(n, "y", "ies")), nil
+		})
+	case "import":
+		format := urlfilter.FormatAuto
+		var rest []string
+		for _, a := range args {
+			if strings.HasPrefix(a, "--format=") {
+				f, err := urlfilter.ParseFormat(strings.TrimPrefix(a, "--format="))
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "urls: %v\n", err)
+					os.Exit(1)
+				}
+				format = f
+				continue
+			}
+			rest = append(rest, a)
+		}
+		if len(rest) != 2 {
+			fmt.Fprintln(os.Stderr, "Usage: cowardly urls import <block|allow> <file> [--format=auto|hosts|domains|adguard]")
+			os.Exit(1)
+		}
+		allow := parseURLListName(rest[0])
+		res, err := urlfilter.ImportFile(rest[1], format)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "urls: %v\n", err)
+			os.Exit(1)
+		}
+		updateURLFilters(func(l *urlfilter.Lists) (string, error) {
+			// AdGuard @@ exceptions always go to the allow list.
+			nBlock, err := l.Add(allow, res.Block...)
+			if err != nil {
+				return "", err
+			}
+			nAllow, err := l.Add(true, res.Allow...)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("Imported %d new entr%s (%d allow exceptions); skipped %d unsupported line(s).",
+				nBlock+nAllow, plural(nBlock+nAllow, "y", "ies"), nAllow, res.Skipped), nil
+		})
+	case "clear":
+		which := "all"
+		if len(args) > 0 {
+			which = args[0]
+		}
+		updateURLFilters(func(l *urlfilter.Lists) (string, error) {
+			switch which {
+			case "block":
+				l.Block = nil
+			case "allow":
+				l.Allow = nil
+			case "all":
+				*l = urlfilter.Lists{}
+			default:
+				return "", fmt.Errorf("unknown list %q (use block, allow or all)", which)
+			}
+			return "Cleared.", nil
+		})
+	default:
+		fmt.Fprintf(os.Stderr, "urls: unknown subcommand %q (use list, add, remove, import, clear)\n", sub)
+		os.Exit(1)
+	}
+}
+
+// parseURLListName returns true for "allow", false for "block", and exits otherwise.
+func parseURLListName(s string) bool {
+	switch strings.ToLower(s) {
+	case "block", "blocklist":
+		return false
+	case "allow", "allowlist":
+		return true
+	}
+	fmt.Fprintf(os.Stderr, "urls: unknown list %q (use block or allow)\n", s)
+	os.Exit(1)
+	return false
+}
+
+func listURLFilters() {
+	desired, err := cfgStore.Read()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "urls: %v\n", err)
+		os.Exit(1)
+	}
+	if desired == nil || desired.URLFilters.IsEmpty() {
+		fmt.Println("No URL filters. Use `cowardly urls add block <domain>` or `cowardly urls import block <file>`.")
+		return
+	}
+	l := desired.URLFilters
+	fmt.Printf("%s (%d/%d):\n", urlfilter.KeyBlocklist, len(l.Block), urlfilter.MaxEntries)
+	for _, e := range l.Block {
+		fmt.Printf("  %s\n", e)
+	}
+	fmt.Printf("%s (%d/%d):\n", urlfilter.KeyAllowlist, len(l.Allow), urlfilter.MaxEntries)
+	for _, e := range l.Allow {
+		fmt.Printf("  %s\n", e)
+	}
+}
+
+// updateURLFilters applies fn to the saved lists and saves them in one config update, then re-applies the desired state.
+func updateURLFilters(fn func(l *urlfilter.Lists) (string, error)) {
+	var summary string
+	err := cfgStore.UpdateURLFilters(func(l *urlfilter.Lists) error {
+		var err error
+		summary, err = fn(l)
+		return err
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "urls: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(summary)
+	applyDesiredState("URL filters")
+}
+
+// plural returns one if n == 1, else many.
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cowardly/cowardly/internal/watch"
+)
+
+// watchAgentLabel is the Launch Agent label of `cowardly watch install` (see agentLabel for other channels).
+const watchAgentLabel = "com.cowardly.watch"
+
+// watchCmd handles `cowardly watch [install|uninstall] [--managed] [--interval=] [--debounce=] [--once]`.
+func watchCmd(args []string) {
+	opts := watch.Options{Paths: cfgPaths}
+	once := false
+	var rest []string
+	for _, a := range args {
+		switch {
+		case a == "--managed":
+			opts.Managed = true
+		case a == "--once":
+			once = true
+		case strings.HasPrefix(a, "--interval="):
+			opts.Interval = parseWatchDuration(a, "--interval=")
+		case strings.HasPrefix(a, "--debounce="):
+			opts.Debounce = parseWatchDuration(a, "--debounce=")
+		case a == "--beta" || a == "-beta":
+		case strings.HasPrefix(a, "-"):
+			fmt.Fprintf(os.Stderr, "watch: unknown flag %q\n", a)
+			os.Exit(1)
+		default:
+			rest = append(rest, a)
+		}
+	}
+	if len(rest) > 0 {
+		switch rest[0] {
+		case "install":
+			installWatchAgent(args)
+		case "uninstall":
+			uninstallWatchAgent()
+		default:
+			fmt.Fprintf(os.Stderr, "watch: unknown subcommand %q (use install, uninstall)\n", rest[0])
+			os.Exit(1)
+		}
+		return
+	}
+	logger := log.New(os.Stdout, "", log.LstdFlags)
+	opts.Logf = logger.Printf
+	if once {
+		ev, err := watch.Check(opts, nil)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "watch: %v\n", err)
+			os.Exit(1)
+		}
+		if len(ev.Drifted) == 0 {
+			fmt.Println("No drift: Brave matches the desired state.")
+		}
+		return
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := watch.Run(ctx, opts); err != nil {
+		fmt.Fprintf(os.Stderr, "watch: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func parseWatchDuration(arg, prefix string) time.Duration {
+	d, err := time.ParseDuration(strings.TrimPrefix(arg, prefix))
+	if err != nil || d <= 0 {
+		fmt.Fprintf(os.Stderr, "watch: invalid duration in %s (e.g. 2s, 500ms)\n", arg)
+		os.Exit(1)
+	}
+	return d
+}
+
+// installWatchAgent writes and loads a Launch Agent that runs `cowardly watch` with flags, kept alive by launchd.
+func installWatchAgent(args []string) {
+	agentArgs := append(append(pathArgs(), channelArgs()...), "watch")
+	for _, a := range args {
+		if strings.HasPrefix(a, "--") && a != "--beta" && a != "--once" {
+			agentArgs = append(agentArgs, a)
+		}
+	}
+	plistPath, err := writeLaunchAgent(agentLabel(watchAgentLabel), agentArgs, true, agentLog("watch.log"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "watch: %v\n", err)
+		os.Exit(1)
+	}
+	_ = exec.Command("launchctl", "unload", plistPath).Run() // not loaded yet is fine
+	if out, err := exec.Command("launchctl", "load", "-w", plistPath).CombinedOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "watch: launchctl load: %v: %s\n", err, strings.TrimSpace(string(out)))
+		os.Exit(1)
+	}
+	fmt.Printf("Installed and started Launch Agent at %s\n", plistPath)
+	fmt.Printf("Log: %s. To remove: cowardly watch uninstall\n", cfgPaths.Log(agentLog("watch.log")))
+}
+
+func uninstallWatchAgent() {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "watch: %v\n", err)
+		os.Exit(1)
+	}
+	plistPath := filepath.Join(home, "Library", "LaunchAgents", agentLabel(watchAgentLabel)+".plist")
+	if _, err := os.Stat(plistPath); err != nil {
+		fmt.Println("Watch Launch Agent is not installed.")
+		return
+	}
+	_ = exec.Command("launchctl", "unload", plistPath).Run()
+	if err := os.Remove(plistPath); err != nil {
+		fmt.Fprintf(os.Stderr, "watch: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("Stopped and removed the watch Launch Agent.")
+}
@@ -15,3 +15,18 @@ var PresetsFS embed.FS
 //
 //go:embed supplements/privacy-guides/*.yaml
 var PrivacyGuidesFS embed.FS
+
+// SearchFS contains the default search provider catalog (configs/search/providers.yaml).
+//
+//go:embed search/*.yaml
+var SearchFS embed.FS
+
+// ExtensionsFS contains the extension catalog (configs/extensions/catalog.yaml).
+//
+//go:embed extensions/*.yaml
+var ExtensionsFS embed.FS
+
+// DNSFS contains the DNS-over-HTTPS resolver catalog (configs/dns/resolvers.yaml).
+//
+//go:embed dns/*.yaml
+var DNSFS embed.FS
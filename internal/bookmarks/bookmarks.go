@@ -0,0 +1,176 @@
+// Package bookmarks builds the ManagedBookmarks policy from a YAML file or a Netscape
+// bookmarks HTML export, and provides the tree operations used by the TUI editor.
+package bookmarks
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cowardly/cowardly/internal/brave"
+	"gopkg.in/yaml.v3"
+)
+
+// Key is the policy key for managed bookmarks.
+const Key = "ManagedBookmarks"
+
+// DefaultTopLevelName is the folder name used when an import does not provide one.
+const DefaultTopLevelName = "Managed bookmarks"
+
+// maxDepth limits folder nesting so a malformed import cannot produce an unusable tree.
+const maxDepth = 16
+
+// Node is a bookmark (URL set) or a folder (Children set).
+type Node struct {
+	Name     string `yaml:"name"`
+	URL      string `yaml:"url,omitempty"`
+	Children []Node `yaml:"children,omitempty"`
+}
+
+// IsFolder returns true if n is a folder.
+func (n Node) IsFolder() bool {
+	return n.URL == ""
+}
+
+// Tree is the managed bookmarks folder: its name in the bookmarks bar and its contents.
+type Tree struct {
+	TopLevelName string `yaml:"toplevel_name"`
+	Bookmarks    []Node `yaml:"bookmarks"`
+}
+
+// IsEmpty returns true if the tree has no bookmarks.
+func (t Tree) IsEmpty() bool {
+	return len(t.Bookmarks) == 0
+}
+
+// Count returns the number of bookmarks (not folders) in the tree.
+func (t Tree) Count() int {
+	return countNodes(t.Bookmarks)
+}
+
+func countNodes(nodes []Node) int {
+	var n int
+	for _, node := range nodes {
+		if node.IsFolder() {
+			n += countNodes(node.Children)
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// Validate checks names, URLs and nesting depth.
+func (t Tree) Validate() error {
+	if strings.TrimSpace(t.TopLevelName) == "" {
+		return fmt.Errorf("toplevel_name is empty")
+	}
+	return validateNodes(t.Bookmarks, t.TopLevelName, 1)
+}
+
+func validateNodes(nodes []Node, parent string, depth int) error {
+	if depth > maxDepth {
+		return fmt.Errorf("%s: folders nested deeper than %d levels", parent, maxDepth)
+	}
+	for i, n := range nodes {
+		if strings.TrimSpace(n.Name) == "" {
+			return fmt.Errorf("%s: item %d has no name", parent, i+1)
+		}
+		if n.IsFolder() {
+			if err := validateNodes(n.Children, parent+"/"+n.Name, depth+1); err != nil {
+				return err
+			}
+			continue
+		}
+		if len(n.Children) > 0 {
+			return fmt.Errorf("%s/%s: a bookmark cannot have both url and children", parent, n.Name)
+		}
+		if err := ValidateURL(n.URL); err != nil {
+			return fmt.Errorf("%s/%s: %w", parent, n.Name, err)
+		}
+	}
+	return nil
+}
+
+// ValidateURL checks that u is an absolute URL with a scheme (http, https, file, etc.).
+func ValidateURL(u string) error {
+	parsed, err := url.Parse(strings.TrimSpace(u))
+	if err != nil {
+		return fmt.Errorf("invalid URL %q: %w", u, err)
+	}
+	if parsed.Scheme == "" || (parsed.Host == "" && parsed.Opaque == "" && parsed.Path == "") {
+		return fmt.Errorf("invalid URL %q: must be absolute (e.g. https://example.com)", u)
+	}
+	return nil
+}
+
+// Settings returns the ManagedBookmarks setting: a list whose first element names the folder.
+func (t Tree) Settings() []brave.Setting {
+	if t.IsEmpty() {
+		return nil
+	}
+	l := []interface{}{map[string]interface{}{"toplevel_name": t.TopLevelName}}
+	l = append(l, nodesToPolicy(t.Bookmarks)...)
+	return []brave.Setting{{Key: Key, Value: l, Type: brave.TypeList}}
+}
+
+func nodesToPolicy(nodes []Node) []interface{} {
+	out := make([]interface{}, len(nodes))
+	for i, n := range nodes {
+		if n.IsFolder() {
+			out[i] = map[string]interface{}{"name": n.Name, "children": nodesToPolicy(n.Children)}
+		} else {
+			out[i] = map[string]interface{}{"name": n.Name, "url": n.URL}
+		}
+	}
+	return out
+}
+
+// LoadFile reads a YAML (.yaml/.yml) or Netscape HTML (.html/.htm) bookmarks file and validates it.
+// topLevelName overrides the folder name from the file; for HTML it defaults to DefaultTopLevelName.
+func LoadFile(path, topLevelName string) (*Tree, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open bookmarks: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	var t *Tree
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm":
+		nodes, err := ParseNetscapeHTML(f)
+		if err != nil {
+			return nil, err
+		}
+		t = &Tree{TopLevelName: DefaultTopLevelName, Bookmarks: nodes}
+	case ".yaml", ".yml":
+		t = &Tree{}
+		dec := yaml.NewDecoder(f)
+		dec.KnownFields(true)
+		if err := dec.Decode(t); err != nil {
+			return nil, fmt.Errorf("parse bookmarks YAML: %w", err)
+		}
+		if t.TopLevelName == "" {
+			t.TopLevelName = DefaultTopLevelName
+		}
+	default:
+		return nil, fmt.Errorf("unsupported bookmarks file %q (use .yaml or an exported .html)", path)
+	}
+	if topLevelName != "" {
+		t.TopLevelName = topLevelName
+	}
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// WriteFile saves t as YAML (the same format LoadFile reads).
+func WriteFile(path string, t Tree) error {
+	data, err := yaml.Marshal(&t)
+	if err != nil {
+		return fmt.Errorf("marshal bookmarks: %w", err)
+	}
+	return os.WriteFile(path, data, 0600)
+}
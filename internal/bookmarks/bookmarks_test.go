@@ -0,0 +1,116 @@
+package bookmarks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const exportHTML = `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+<DL><p>
+    <DT><H3 ADD_DATE="1700000000">Team</H3>
+    <DL><p>
+        <DT><A HREF="https://intranet.example.com/" ADD_DATE="1700000000">Intranet &amp; Wiki</A>
+        <DT><H3>Tools</H3>
+        <DL><p>
+            <DT><A HREF="https://jira.example.com">Jira</A>
+            <DT><A HREF="javascript:alert(1)">Bookmarklet</A>
+        </DL><p>
+    </DL><p>
+    <DT><A HREF='https://example.org'>Example</A>
+</DL><p>
+`
+
+func TestParseNetscapeHTML(t *testing.T) {
+	nodes, err := ParseNetscapeHTML(strings.NewReader(exportHTML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 2 || nodes[0].Name != "Team" || !nodes[0].IsFolder() {
+		t.Fatalf("unexpected top level: %+v", nodes)
+	}
+	team := nodes[0].Children
+	if len(team) != 2 || team[0].Name != "Intranet & Wiki" || team[0].URL != "https://intranet.example.com/" {
+		t.Errorf("unexpected Team folder: %+v", team)
+	}
+	if tools := team[1].Children; len(tools) != 1 || tools[0].Name != "Jira" {
+		t.Errorf("expected bookmarklet to be dropped, got %+v", tools)
+	}
+	if nodes[1].URL != "https://example.org" {
+		t.Errorf("unexpected single-quoted href: %+v", nodes[1])
+	}
+	if _, err := ParseNetscapeHTML(strings.NewReader("<html>nothing</html>")); err == nil {
+		t.Error("expected error for non-bookmarks HTML")
+	}
+}
+
+func TestLoadFileYAML(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "team.yaml")
+	yaml := `toplevel_name: Team
+bookmarks:
+  - name: Intranet
+    url: https://intranet.example.com
+  - name: Tools
+    children:
+      - name: Jira
+        url: https://jira.example.com
+`
+	if err := os.WriteFile(path, []byte(yaml), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tree, err := LoadFile(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.TopLevelName != "Team" || tree.Count() != 2 {
+		t.Errorf("unexpected tree: %+v", tree)
+	}
+	settings := tree.Settings()
+	l := settings[0].Value.([]interface{})
+	if l[0].(map[string]interface{})["toplevel_name"] != "Team" || len(l) != 3 {
+		t.Errorf("unexpected ManagedBookmarks: %v", l)
+	}
+
+	if err := os.WriteFile(path, []byte("toplevel_name: Team\nbookmarks:\n  - name: Bad\n    url: not a url\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFile(path, ""); err == nil {
+		t.Error("expected error for relative URL")
+	}
+}
+
+func TestTreeEdit(t *testing.T) {
+	tree := Tree{TopLevelName: "Team", Bookmarks: []Node{
+		{Name: "A", URL: "https://a.example"},
+		{Name: "F"},
+	}}
+	if err := tree.Insert([]int{1}, Node{Name: "B", URL: "https://b.example"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Insert([]int{0}, Node{Name: "C", URL: "https://c.example"}); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, r := range tree.Flatten() {
+		names = append(names, strings.Repeat(">", r.Depth)+r.Node.Name)
+	}
+	if got := strings.Join(names, ","); got != "A,C,F,>B" {
+		t.Errorf("Flatten = %s", got)
+	}
+	if err := tree.Update([]int{2, 0}, "B2", "nope"); err == nil {
+		t.Error("expected URL validation error")
+	}
+	if err := tree.Delete([]int{2}); err != nil || tree.Count() != 2 {
+		t.Errorf("Delete: %v, count %d", err, tree.Count())
+	}
+	for _, path := range [][]int{{2}, {-1}, {1, 0}, {0, 0}} {
+		if err := tree.Insert(path, Node{Name: "X", URL: "https://x.example"}); err == nil {
+			t.Errorf("Insert(%v): expected invalid path error", path)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package bookmarks
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// maxHTMLBytes caps how much of a bookmarks export is read.
+const maxHTMLBytes = 16 << 20
+
+// tagRegex matches the start and end tags that matter in a Netscape bookmarks export.
+var tagRegex = regexp.MustCompile(`(?is)<(/?)(dl|h3|a)\b([^>]*)>`)
+
+// hrefRegex extracts the HREF attribute from an <A> tag.
+var hrefRegex = regexp.MustCompile(`(?is)\bhref\s*=\s*("([^"]*)"|'([^']*)'|([^\s>]+))`)
+
+// ParseNetscapeHTML parses the Netscape bookmarks format exported by Brave, Chrome, Firefox and Safari.
+// The outermost <DL> becomes the returned list; <H3> folders and <A> links nest under it.
+func ParseNetscapeHTML(r io.Reader) ([]Node, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxHTMLBytes))
+	if err != nil {
+		return nil, fmt.Errorf("read bookmarks HTML: %w", err)
+	}
+	doc := string(data)
+	matches := tagRegex.FindAllStringSubmatchIndex(doc, -1)
+
+	type frame struct {
+		name  string
+		nodes []Node
+	}
+	var stack []*frame
+	var root *frame
+	pendingFolder := ""
+	for i := 0; i < len(matches); i++ {
+		m := matches[i]
+		closing := doc[m[2]:m[3]] == "/"
+		tag := strings.ToLower(doc[m[4]:m[5]])
+		attrs := doc[m[6]:m[7]]
+		switch {
+		case tag == "dl" && !closing:
+			f := &frame{name: pendingFolder}
+			pendingFolder = ""
+			if root == nil {
+				root = f
+			}
+			stack = append(stack, f)
+		case tag == "dl" && closing:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("bookmarks HTML: unbalanced </DL>")
+			}
+			f := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.nodes = append(parent.nodes, Node{Name: f.name, Children: f.nodes})
+			}
+		case (tag == "h3" || tag == "a") && !closing:
+			text, next := textUntilClose(doc, matches, i, tag)
+			i = next
+			if tag == "h3" {
+				pendingFolder = text
+				continue
+			}
+			if len(stack) == 0 {
+				continue
+			}
+			href := ""
+			if hm := hrefRegex.FindStringSubmatch(attrs); hm != nil {
+				href = hm[2] + hm[3] + hm[4]
+			}
+			href = html.UnescapeString(href)
+			if href == "" || strings.HasPrefix(strings.ToLower(href), "javascript:") || strings.HasPrefix(strings.ToLower(href), "place:") {
+				continue // bookmarklets and Firefox smart folders cannot be managed bookmarks
+			}
+			if text == "" {
+				text = href
+			}
+			f := stack[len(stack)-1]
+			f.nodes = append(f.nodes, Node{Name: text, URL: href})
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("bookmarks HTML: no <DL> list found (is this a bookmarks export?)")
+	}
+	// Exports that omit closing </DL> tags leave frames open; fold them into their parents.
+	for len(stack) > 1 {
+		f := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		parent := stack[len(stack)-1]
+		parent.nodes = append(parent.nodes, Node{Name: f.name, Children: f.nodes})
+	}
+	return root.nodes, nil
+}
+
+// textUntilClose returns the unescaped text between matches[i] and its closing tag, and the index of that closing tag.
+func textUntilClose(doc string, matches [][]int, i int, tag string) (string, int) {
+	start := matches[i][1]
+	for j := i + 1; j < len(matches); j++ {
+		m := matches[j]
+		if doc[m[2]:m[3]] == "/" && strings.EqualFold(doc[m[4]:m[5]], tag) {
+			return cleanText(doc[start:m[0]]), j
+		}
+	}
+	return "", i
+}
+
+var innerTagRegex = regexp.MustCompile(`<[^>]*>`)
+
+func cleanText(s string) string {
+	s = innerTagRegex.ReplaceAllString(s, "")
+	return strings.Join(strings.Fields(html.UnescapeString(s)), " ")
+}
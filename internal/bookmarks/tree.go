@@ -0,0 +1,97 @@
+package bookmarks
+
+import "fmt"
+
+// Row is one visible line of the tree: a node and its position.
+type Row struct {
+	Path  []int // indices from the top level down to the node
+	Depth int
+	Node  Node
+}
+
+// Flatten returns every node in display order (depth-first).
+func (t Tree) Flatten() []Row {
+	var rows []Row
+	var walk func(nodes []Node, prefix []int)
+	walk = func(nodes []Node, prefix []int) {
+		for i, n := range nodes {
+			path := append(append([]int(nil), prefix...), i)
+			rows = append(rows, Row{Path: path, Depth: len(prefix), Node: n})
+			if n.IsFolder() {
+				walk(n.Children, path)
+			}
+		}
+	}
+	walk(t.Bookmarks, nil)
+	return rows
+}
+
+// siblings returns the slice that contains the node at path (parent's children or the top level).
+// Every index of path must name an existing node.
+func (t *Tree) siblings(path []int) (*[]Node, error) {
+	list := &t.Bookmarks
+	for _, i := range path[:len(path)-1] {
+		if i < 0 || i >= len(*list) || !(*list)[i].IsFolder() {
+			return nil, fmt.Errorf("invalid bookmark path %v", path)
+		}
+		list = &(*list)[i].Children
+	}
+	if last := path[len(path)-1]; last < 0 || last >= len(*list) {
+		return nil, fmt.Errorf("invalid bookmark path %v", path)
+	}
+	return list, nil
+}
+
+// Insert adds n next to the node at path: inside it if it is a folder, otherwise right after it.
+// An empty path appends to the top level.
+func (t *Tree) Insert(path []int, n Node) error {
+	if len(path) == 0 {
+		t.Bookmarks = append(t.Bookmarks, n)
+		return nil
+	}
+	list, err := t.siblings(path)
+	if err != nil {
+		return err
+	}
+	i := path[len(path)-1]
+	if (*list)[i].IsFolder() {
+		(*list)[i].Children = append((*list)[i].Children, n)
+		return nil
+	}
+	*list = append((*list)[:i+1], append([]Node{n}, (*list)[i+1:]...)...)
+	return nil
+}
+
+// Delete removes the node at path (and its children).
+func (t *Tree) Delete(path []int) error {
+	if len(path) == 0 {
+		return fmt.Errorf("invalid bookmark path")
+	}
+	list, err := t.siblings(path)
+	if err != nil {
+		return err
+	}
+	i := path[len(path)-1]
+	*list = append((*list)[:i], (*list)[i+1:]...)
+	return nil
+}
+
+// Update replaces the name and URL of the node at path (children are kept).
+func (t *Tree) Update(path []int, name, url string) error {
+	if len(path) == 0 {
+		return fmt.Errorf("invalid bookmark path")
+	}
+	list, err := t.siblings(path)
+	if err != nil {
+		return err
+	}
+	i := path[len(path)-1]
+	if !(*list)[i].IsFolder() {
+		if err := ValidateURL(url); err != nil {
+			return err
+		}
+		(*list)[i].URL = url
+	}
+	(*list)[i].Name = name
+	return nil
+}
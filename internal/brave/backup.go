@@ -0,0 +1,414 @@
+package brave
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cowardly/cowardly/internal/atomicfile"
+	"github.com/cowardly/cowardly/internal/lock"
+	"github.com/cowardly/cowardly/internal/paths"
+)
+
+// File names in a backup directory: the plist copies and the manifest.
+const (
+	BackupUserFile     = "user.plist"
+	BackupManagedFile  = "managed.plist"
+	BackupManifestFile = "backup.json"
+)
+
+// Reasons recorded in a backup manifest.
+const (
+	BackupApply  = "apply"
+	BackupReset  = "reset"
+	BackupManual = "manual"
+)
+
+// States of the managed plist recorded in a backup manifest.
+const (
+	ManagedCopied     = "copied"     // managed.plist in the backup is a copy of it
+	ManagedAbsent     = "absent"     // there was no managed plist; restoring removes the current one
+	ManagedUnreadable = "unreadable" // it exists but could not be read, so the backup has no copy
+)
+
+// BackupInfo is the manifest of a backup (backup.json). The caller sets Reason, Source and Label;
+// CreateBackup fills in the rest.
+type BackupInfo struct {
+	Reason  string    `json:"reason"`           // BackupApply, BackupReset or BackupManual
+	Source  string    `json:"source,omitempty"` // what was applied: a preset id, privacy-guides:<base>, custom, a file
+	Label   string    `json:"label,omitempty"`  // user label (cowardly --backup --label=...)
+	Channel Variant   `json:"channel"`
+	Brave   string    `json:"brave_version,omitempty"`
+	Managed string    `json:"managed,omitempty"` // ManagedCopied, ManagedAbsent or ManagedUnreadable; "" in older manifests
+	Keys    int       `json:"keys"`              // keys in the backed-up plists (managed and user together)
+	Created time.Time `json:"created"`
+}
+
+// backupTimeFormat names backup directories; it sorts lexicographically. A backup taken in the same
+// second as an existing one gets a -01, -02, ... suffix (see newBackupDir), which sorts after it.
+const backupTimeFormat = "2006-01-02T15-04-05"
+
+// maxBackupsPerSecond bounds the suffixes newBackupDir tries.
+const maxBackupsPerSecond = 100
+
+// legacyBackupSuffix is the suffix of the single-file user plist backups of older versions
+// (<timestamp>-user.plist). They are still listed, restored and deleted.
+const legacyBackupSuffix = "-user.plist"
+
+// BackupDir returns the backups directory of the current channel: p.BackupDir() (default
+// ~/Library/Application Support/cowardly/backups) for stable, and a subdirectory named after the
+// channel (e.g. backups/beta) for the others.
+func BackupDir(p paths.Paths) (string, error) {
+	if !IsMacOS() {
+		return "", fmt.Errorf("cowardly only supports macOS")
+	}
+	dir := p.BackupDir()
+	if currentVariant != VariantStable {
+		dir = filepath.Join(dir, string(currentVariant))
+	}
+	return dir, nil
+}
+
+// Backup is one snapshot: a directory holding a copy of the user plist, the managed plist or both,
+// or a <timestamp>-user.plist file written by older versions.
+type Backup struct {
+	Path    string      // the backup directory (or legacy file)
+	User    string      // copy of the user plist; "" if the backup has none
+	Managed string      // copy of the managed plist; "" if there was none (or it was not readable)
+	Info    *BackupInfo // the manifest; nil for legacy backups
+}
+
+// Name returns the backup's file name (its timestamp).
+func (b Backup) Name() string {
+	return filepath.Base(b.Path)
+}
+
+// Scopes returns the plists the backup contains: "user", "managed" or both.
+func (b Backup) Scopes() []string {
+	var out []string
+	if b.User != "" {
+		out = append(out, "user")
+	}
+	if b.Managed != "" {
+		out = append(out, "managed")
+	}
+	return out
+}
+
+// OpenBackup returns the backup at path (a backup directory or a legacy user plist backup).
+func OpenBackup(path string) (Backup, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return Backup{}, fmt.Errorf("backup: %w", err)
+	}
+	b := Backup{Path: path}
+	if !info.IsDir() {
+		b.User = path
+		return b, nil
+	}
+	if _, err := os.Stat(filepath.Join(path, BackupUserFile)); err == nil {
+		b.User = filepath.Join(path, BackupUserFile)
+	}
+	if _, err := os.Stat(filepath.Join(path, BackupManagedFile)); err == nil {
+		b.Managed = filepath.Join(path, BackupManagedFile)
+	}
+	if b.User == "" && b.Managed == "" {
+		return Backup{}, fmt.Errorf("backup %s has no plist", path)
+	}
+	if data, err := os.ReadFile(filepath.Join(path, BackupManifestFile)); err == nil {
+		var info BackupInfo
+		if json.Unmarshal(data, &info) == nil {
+			b.Info = &info
+		}
+	}
+	return b, nil
+}
+
+// RemovesManaged reports whether restoring the backup removes the managed plist: the backup was taken
+// when there was none. Backups without that record (older or legacy ones) leave it alone.
+func (b Backup) RemovesManaged() bool {
+	return b.Managed == "" && b.Info != nil && b.Info.Managed == ManagedAbsent
+}
+
+// Summary describes the backup in one line: its scopes and, from the manifest, reason, source,
+// Brave version and key count (e.g. "user + managed · apply quick · Brave 1.70.117 · 42 keys").
+func (b Backup) Summary() string {
+	parts := []string{strings.Join(b.Scopes(), " + ")}
+	if b.Info == nil {
+		return parts[0]
+	}
+	what := b.Info.Reason
+	if b.Info.Source != "" {
+		what += " " + b.Info.Source
+	}
+	parts = append(parts, what)
+	if b.Info.Brave != "" {
+		parts = append(parts, "Brave "+b.Info.Brave)
+	}
+	parts = append(parts, fmt.Sprintf("%d keys", b.Info.Keys))
+	if b.Info.Managed == ManagedUnreadable {
+		parts = append(parts, "managed plist unreadable")
+	}
+	return strings.Join(parts, " · ")
+}
+
+// CreateBackup copies the user plist and the managed plist (when present and readable) into a new
+// <backup dir>/<timestamp>/ directory, with info as its manifest, then prunes older backups by r (see
+// PruneBackups). The manifest records whether the managed plist was copied, absent or unreadable.
+// Returns its path and the pruned backups, or an error if neither plist exists. l is the cowardly lock,
+// held by the caller.
+func CreateBackup(l *lock.Lock, p paths.Paths, r Retention, info BackupInfo) (path string, pruned []Backup, err error) {
+	if err := l.Check(); err != nil {
+		return "", nil, err
+	}
+	user, err := UserPreferencesPath()
+	if err != nil {
+		return "", nil, err
+	}
+	managed := ManagedPreferencesPath() + ".plist"
+	var sources [][2]string // plist, name in the backup
+	if _, err := os.Stat(user); err == nil {
+		sources = append(sources, [2]string{user, BackupUserFile})
+	}
+	if f, err := os.Open(managed); err == nil {
+		_ = f.Close()
+		sources = append(sources, [2]string{managed, BackupManagedFile})
+		info.Managed = ManagedCopied
+	} else if os.IsNotExist(err) {
+		info.Managed = ManagedAbsent
+	} else {
+		info.Managed = ManagedUnreadable
+	}
+	if len(sources) == 0 {
+		return "", nil, fmt.Errorf("no user or managed plist to back up")
+	}
+	dir, err := BackupDir(p)
+	if err != nil {
+		return "", nil, err
+	}
+	dst, err := newBackupDir(dir, time.Now())
+	if err != nil {
+		return "", nil, err
+	}
+	for _, s := range sources {
+		data, err := os.ReadFile(s[0])
+		if err != nil {
+			return "", nil, fmt.Errorf("read %s: %w", s[0], err)
+		}
+		if err := atomicfile.WriteFile(filepath.Join(dst, s[1]), data, 0600); err != nil {
+			return "", nil, fmt.Errorf("write backup: %w", err)
+		}
+	}
+	info.Channel = currentVariant
+	info.Brave = BraveVersion()
+	info.Created = time.Now()
+	if settings, err := ReadBackup(dst); err == nil {
+		info.Keys = len(settings)
+	}
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return "", nil, fmt.Errorf("encode manifest: %w", err)
+	}
+	if err := atomicfile.WriteFile(filepath.Join(dst, BackupManifestFile), append(data, '\n'), 0600); err != nil {
+		return "", nil, fmt.Errorf("write manifest: %w", err)
+	}
+	// The backup is taken; a failed prune only leaves older backups behind (backups prune reports it).
+	pruned, _ = PruneBackups(l, p, r)
+	return dst, pruned, nil
+}
+
+// newBackupDir creates a backup directory in dir named after now and returns its path. It never reuses an
+// existing directory, so two backups in the same second do not overwrite each other.
+func newBackupDir(dir string, now time.Time) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("create backup dir: %w", err)
+	}
+	name := now.Format(backupTimeFormat)
+	for i := 0; i < maxBackupsPerSecond; i++ {
+		dst := filepath.Join(dir, name)
+		if i > 0 {
+			dst += fmt.Sprintf("-%02d", i)
+		}
+		err := os.Mkdir(dst, 0755)
+		if err == nil {
+			return dst, nil
+		}
+		if !os.IsExist(err) {
+			return "", fmt.Errorf("create backup dir: %w", err)
+		}
+	}
+	return "", fmt.Errorf("create backup dir: %d backups already taken at %s", maxBackupsPerSecond, name)
+}
+
+// parseBackupTime returns the time in a backup directory name, with or without its -NN suffix.
+func parseBackupTime(name string) (time.Time, error) {
+	n := len(backupTimeFormat)
+	if len(name) == n+3 && name[n] == '-' {
+		if _, err := strconv.Atoi(name[n+1:]); err == nil {
+			name = name[:n]
+		}
+	}
+	return time.ParseInLocation(backupTimeFormat, name, time.Local)
+}
+
+// ListBackups returns the backups of the current channel, newest first (by timestamp).
+func ListBackups(p paths.Paths) ([]Backup, error) {
+	dir, err := BackupDir(p)
+	if err != nil {
+		return nil, err
+	}
+	return listBackupsIn(dir)
+}
+
+func listBackupsIn(dir string) ([]Backup, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read backup dir: %w", err)
+	}
+	var out []Backup
+	for _, e := range entries {
+		if e.IsDir() {
+			// Other channels' backups live in subdirectories named after the channel.
+			if _, err := parseBackupTime(e.Name()); err != nil {
+				continue
+			}
+		} else if !strings.HasSuffix(e.Name(), legacyBackupSuffix) {
+			continue
+		}
+		if b, err := OpenBackup(filepath.Join(dir, e.Name())); err == nil {
+			out = append(out, b)
+		}
+	}
+	// Newest first: both name formats start with the timestamp.
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name() > out[j].Name()
+	})
+	return out, nil
+}
+
+// RestoreFromBackup puts the plists of the backup at path back (l is the cowardly lock, held by the
+// caller): the user plist directly, the managed plist through the admin dialog. If the backup was taken
+// when there was no managed plist (RemovesManaged), the current one is removed through the admin dialog.
+// Other plists the backup does not contain are left alone. Restart Brave for changes to take effect.
+func RestoreFromBackup(l *lock.Lock, path string) error {
+	if err := l.Check(); err != nil {
+		return err
+	}
+	b, err := OpenBackup(path)
+	if err != nil {
+		return err
+	}
+	if b.User != "" {
+		dst, err := UserPreferencesPath()
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(b.User)
+		if err != nil {
+			return fmt.Errorf("read backup: %w", err)
+		}
+		if err := atomicfile.WriteFile(dst, data, 0600); err != nil {
+			return fmt.Errorf("write user plist: %w", err)
+		}
+	}
+	if b.Managed != "" {
+		if err := installManagedPlist(b.Managed); err != nil {
+			return fmt.Errorf("restore managed plist: %w", err)
+		}
+	} else if b.RemovesManaged() && ManagedPlistExists() {
+		if err := RemoveManagedPlist(); err != nil {
+			return fmt.Errorf("restore managed plist: %w", err)
+		}
+	}
+	return nil
+}
+
+// ResolveBackup returns the path of the backup matching name (a full path or a name from ListBackups),
+// or "" if there is none.
+func ResolveBackup(p paths.Paths, name string) string {
+	name = strings.TrimSuffix(strings.TrimSpace(name), "/")
+	if name == "" {
+		return ""
+	}
+	backups, err := ListBackups(p)
+	if err != nil {
+		return ""
+	}
+	for _, b := range backups {
+		if b.Path == name || strings.HasSuffix(b.Path, name) {
+			return b.Path
+		}
+	}
+	// name might be a full path that wasn't in the list (e.g. another channel's); still allow if it is a backup
+	if _, err := OpenBackup(name); err == nil {
+		return name
+	}
+	return ""
+}
+
+// DeleteBackup removes a backup from disk. A backup directory that holds anything besides its plists
+// is left alone.
+func DeleteBackup(backupPath string) error {
+	b, err := OpenBackup(backupPath)
+	if err != nil {
+		return fmt.Errorf("delete backup: %w", err)
+	}
+	if b.Path != b.User {
+		entries, err := os.ReadDir(b.Path)
+		if err != nil {
+			return fmt.Errorf("delete backup: %w", err)
+		}
+		for _, e := range entries {
+			if e.Name() != BackupUserFile && e.Name() != BackupManagedFile && e.Name() != BackupManifestFile {
+				return fmt.Errorf("delete backup: %s contains %s, which is not part of a backup", b.Path, e.Name())
+			}
+		}
+	}
+	for _, p := range []string{b.User, b.Managed} {
+		if p == "" {
+			continue
+		}
+		if err := os.Remove(p); err != nil {
+			return fmt.Errorf("delete backup: %w", err)
+		}
+	}
+	if b.Path != b.User {
+		if err := os.Remove(filepath.Join(b.Path, BackupManifestFile)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("delete backup: %w", err)
+		}
+		if err := os.Remove(b.Path); err != nil {
+			return fmt.Errorf("delete backup: %w", err)
+		}
+	}
+	return nil
+}
+
+// ReadBackup returns the settings in the backup at path as Brave would see them: managed overrides user.
+func ReadBackup(path string) (map[string]Setting, error) {
+	b, err := OpenBackup(path)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]Setting{}
+	for _, p := range []string{b.User, b.Managed} {
+		if p == "" {
+			continue
+		}
+		settings, err := ReadPlistFile(p)
+		if err != nil {
+			return nil, err
+		}
+		for k, s := range settings {
+			out[k] = s
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,118 @@
+package brave
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestListBackups(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string) {
+		t.Helper()
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("<plist/>"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("2026-01-01T10-00-00-user.plist") // legacy
+	write("2026-02-01T10-00-00/user.plist")
+	write("2026-02-01T10-00-00/managed.plist")
+	if err := os.WriteFile(filepath.Join(dir, "2026-02-01T10-00-00", BackupManifestFile), []byte(`{"reason":"apply","source":"quick","label":"before trip","channel":"stable","brave_version":"1.70.117","keys":3}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	write("2026-03-01T10-00-00/managed.plist")
+	write("beta/2026-04-01T10-00-00/user.plist") // another channel
+	if err := os.Mkdir(filepath.Join(dir, "2026-05-01T10-00-00"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, err := listBackupsIn(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	var scopes [][]string
+	for _, b := range backups {
+		names = append(names, b.Name())
+		scopes = append(scopes, b.Scopes())
+	}
+	wantNames := []string{"2026-03-01T10-00-00", "2026-02-01T10-00-00", "2026-01-01T10-00-00-user.plist"}
+	wantScopes := [][]string{{"managed"}, {"user", "managed"}, {"user"}}
+	if !reflect.DeepEqual(names, wantNames) || !reflect.DeepEqual(scopes, wantScopes) {
+		t.Errorf("listBackupsIn() = %v %v, want %v %v", names, scopes, wantNames, wantScopes)
+	}
+	if info := backups[1].Info; info == nil || info.Label != "before trip" || info.Keys != 3 {
+		t.Errorf("backup manifest = %+v", info)
+	}
+	if got, want := backups[1].Summary(), "user + managed · apply quick · Brave 1.70.117 · 3 keys"; got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+	if backups[0].Info != nil || backups[0].Summary() != "managed" {
+		t.Errorf("backup without manifest: Info = %+v, Summary() = %q", backups[0].Info, backups[0].Summary())
+	}
+
+	if err := DeleteBackup(backups[1].Path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(backups[1].Path); !os.IsNotExist(err) {
+		t.Errorf("backup dir still exists: %v", err)
+	}
+	write("2026-03-01T10-00-00/notes.txt")
+	if err := DeleteBackup(backups[0].Path); err == nil {
+		t.Error("DeleteBackup() removed a directory with other files")
+	}
+	if _, err := os.Stat(backups[0].Managed); err != nil {
+		t.Errorf("DeleteBackup() removed the plist of a directory it refused: %v", err)
+	}
+}
+
+func TestNewBackupDir(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 3, 20, 12, 0, 5, 0, time.Local)
+	var got []string
+	for i := 0; i < 3; i++ {
+		p, err := newBackupDir(dir, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, filepath.Base(p))
+	}
+	want := []string{"2026-03-20T12-00-05", "2026-03-20T12-00-05-01", "2026-03-20T12-00-05-02"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("newBackupDir() = %v, want %v", got, want)
+	}
+	if err := os.WriteFile(filepath.Join(dir, want[2], BackupUserFile), []byte("<plist/>"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	backups, err := listBackupsIn(dir)
+	if err != nil || len(backups) != 1 || backups[0].Name() != want[2] || !backups[0].Time().Equal(now) {
+		t.Errorf("listBackupsIn() = %+v, %v; want %s at %s", backups, err, want[2], now)
+	}
+}
+
+func TestRemovesManaged(t *testing.T) {
+	for _, tc := range []struct {
+		b    Backup
+		want bool
+	}{
+		{Backup{User: "u", Info: &BackupInfo{Managed: ManagedAbsent}}, true},
+		{Backup{User: "u", Info: &BackupInfo{Managed: ManagedUnreadable}}, false},
+		{Backup{User: "u", Managed: "m", Info: &BackupInfo{Managed: ManagedCopied}}, false},
+		{Backup{User: "u", Info: &BackupInfo{}}, false}, // manifest from before the managed state was recorded
+		{Backup{User: "u"}, false},                      // legacy backup
+	} {
+		if got := tc.b.RemovesManaged(); got != tc.want {
+			t.Errorf("RemovesManaged() of %+v (info %+v) = %v, want %v", tc.b, tc.b.Info, got, tc.want)
+		}
+	}
+	b := Backup{User: "u", Info: &BackupInfo{Reason: BackupApply, Managed: ManagedUnreadable, Keys: 2}}
+	if got, want := b.Summary(), "user · apply · 2 keys · managed plist unreadable"; got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,105 @@
+package brave
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// BackupChange is one key that restoring a backup would change, in one plist.
+type BackupChange struct {
+	Scope   Target // TargetUser or TargetManaged
+	Key     string
+	Current *Setting // the value now; nil if the key is not set
+	Backup  *Setting // the value in the backup; nil if the backup does not set it (restore removes it)
+}
+
+// Values describes the change the way Diff does: "current -> backup" with "(not set)" and value names,
+// or "+added -removed" for lists of strings.
+func (c BackupChange) Values(names ValueNames) string {
+	if c.Current != nil && c.Backup != nil && c.Backup.Type == TypeList {
+		if delta, ok := listDeltaOf(c.Current.Value, c.Backup.Value); ok {
+			return delta
+		}
+	}
+	str := func(s *Setting) string {
+		if s == nil {
+			return "(not set)"
+		}
+		return names.Label(c.Key, settingValueStr(*s))
+	}
+	return str(c.Current) + " -> " + str(c.Backup)
+}
+
+// DiffBackup compares the backup at path key by key with the current preferences. A restore replaces
+// each plist the backup contains and leaves the others alone, so each backup plist is compared with the
+// current plist of the same scope: user, then managed. When the backup records that there was no managed
+// plist, every current managed key shows as removed. Changes are sorted by key within a scope.
+func DiffBackup(path string) ([]BackupChange, error) {
+	b, err := OpenBackup(path)
+	if err != nil {
+		return nil, err
+	}
+	user, err := UserPreferencesPath()
+	if err != nil {
+		return nil, err
+	}
+	var out []BackupChange
+	for _, sc := range []struct {
+		scope           Target
+		backup, current string
+		absent          bool // the backup records that this plist did not exist: restore removes it
+	}{
+		{TargetUser, b.User, user, false},
+		{TargetManaged, b.Managed, ManagedPreferencesPath() + ".plist", b.RemovesManaged()},
+	} {
+		if sc.backup == "" && !sc.absent {
+			continue
+		}
+		backup := map[string]Setting{}
+		if sc.backup != "" {
+			if backup, err = ReadPlistFile(sc.backup); err != nil {
+				return nil, err
+			}
+		}
+		current := map[string]Setting{}
+		if _, err := os.Stat(sc.current); err == nil {
+			if current, err = ReadPlistFile(sc.current); err != nil {
+				return nil, fmt.Errorf("current %s preferences: %w", sc.scope, err)
+			}
+		}
+		out = append(out, diffPlists(sc.scope, current, backup)...)
+	}
+	return out, nil
+}
+
+// diffPlists returns the keys whose value in backup differs from current, including keys set on one side only.
+func diffPlists(scope Target, current, backup map[string]Setting) []BackupChange {
+	keys := make(map[string]bool, len(current)+len(backup))
+	for k := range current {
+		keys[k] = true
+	}
+	for k := range backup {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+	var out []BackupChange
+	for _, k := range sorted {
+		c := BackupChange{Scope: scope, Key: k}
+		if s, ok := current[k]; ok {
+			c.Current = &s
+		}
+		if s, ok := backup[k]; ok {
+			c.Backup = &s
+		}
+		if c.Current != nil && c.Backup != nil && settingValueStr(*c.Current) == settingValueStr(*c.Backup) {
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
@@ -0,0 +1,40 @@
+package brave
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffPlists(t *testing.T) {
+	current := map[string]Setting{
+		"BraveRewardsDisabled": {Key: "BraveRewardsDisabled", Value: false, Type: TypeBool},
+		"DnsOverHttpsMode":     {Key: "DnsOverHttpsMode", Value: "secure", Type: TypeString},
+		"URLBlocklist":         {Key: "URLBlocklist", Value: []interface{}{"a.com", "b.com"}, Type: TypeList},
+		"TorDisabled":          {Key: "TorDisabled", Value: true, Type: TypeBool},
+	}
+	backup := map[string]Setting{
+		"BraveRewardsDisabled":      {Key: "BraveRewardsDisabled", Value: true, Type: TypeBool},
+		"DnsOverHttpsMode":          {Key: "DnsOverHttpsMode", Value: "secure", Type: TypeString},
+		"URLBlocklist":              {Key: "URLBlocklist", Value: []interface{}{"a.com", "c.com"}, Type: TypeList},
+		"IncognitoModeAvailability": {Key: "IncognitoModeAvailability", Value: 1, Type: TypeInteger},
+	}
+	var got []string
+	for _, c := range diffPlists(TargetUser, current, backup) {
+		if c.Scope != TargetUser {
+			t.Errorf("%s: scope = %q", c.Key, c.Scope)
+		}
+		got = append(got, c.Key+": "+c.Values(nil))
+	}
+	want := []string{
+		"BraveRewardsDisabled: 0 -> 1",
+		"IncognitoModeAvailability: (not set) -> 1",
+		"TorDisabled: 1 -> (not set)",
+		"URLBlocklist: +c.com -b.com",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diffPlists() =\n%q\nwant\n%q", got, want)
+	}
+	if got := diffPlists(TargetManaged, backup, backup); len(got) != 0 {
+		t.Errorf("diffPlists() of identical plists = %+v", got)
+	}
+}
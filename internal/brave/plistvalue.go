@@ -0,0 +1,310 @@
+package brave
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// writePlistValue writes v as a plist XML element (true/false, integer, string, array, dict).
+// Nested lists and dicts are used by list- and dictionary-type policies (e.g. ExtensionSettings).
+func writePlistValue(b *strings.Builder, v interface{}) {
+	switch x := v.(type) {
+	case bool:
+		if x {
+			b.WriteString("<true/>")
+		} else {
+			b.WriteString("<false/>")
+		}
+	case int:
+		b.WriteString(fmt.Sprintf("<integer>%d</integer>", x))
+	case int64:
+		b.WriteString(fmt.Sprintf("<integer>%d</integer>", x))
+	case float64:
+		if x == float64(int64(x)) {
+			b.WriteString(fmt.Sprintf("<integer>%d</integer>", int64(x)))
+		} else {
+			b.WriteString(fmt.Sprintf("<real>%v</real>", x))
+		}
+	case []interface{}:
+		b.WriteString("<array>")
+		for _, e := range x {
+			writePlistValue(b, e)
+		}
+		b.WriteString("</array>")
+	case []string:
+		b.WriteString("<array>")
+		for _, e := range x {
+			writePlistValue(b, e)
+		}
+		b.WriteString("</array>")
+	case map[string]interface{}:
+		b.WriteString("<dict>")
+		for _, k := range sortedKeys(x) {
+			b.WriteString("<key>")
+			b.WriteString(plistEscapeString(k))
+			b.WriteString("</key>")
+			writePlistValue(b, x[k])
+		}
+		b.WriteString("</dict>")
+	default:
+		b.WriteString("<string>")
+		b.WriteString(plistEscapeString(fmt.Sprintf("%v", v)))
+		b.WriteString("</string>")
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// canonicalValue returns v with every scalar converted to the string form `defaults read` prints
+// (bools as "1"/"0", numbers in decimal), so a written value and a read-back value compare equal.
+func canonicalValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case bool:
+		if x {
+			return "1"
+		}
+		return "0"
+	case []interface{}:
+		out := make([]interface{}, len(x))
+		for i, e := range x {
+			out[i] = canonicalValue(e)
+		}
+		return out
+	case []string:
+		out := make([]interface{}, len(x))
+		for i, e := range x {
+			out[i] = e
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(x))
+		for k, e := range x {
+			out[k] = canonicalValue(e)
+		}
+		return out
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// listDelta describes how the list in raw (`defaults read` text) changes into next, as "+added -removed"
+// entries. ok is false unless both are non-empty lists of scalars, in which case the full values are shown instead.
+func listDelta(raw string, next interface{}) (string, bool) {
+	if raw == "" {
+		return "", false
+	}
+	v, err := ParseDefaultsValue(raw)
+	if err != nil {
+		return "", false
+	}
+	return listDeltaOf(v, next)
+}
+
+// listDeltaOf is listDelta for a current value that is already parsed (e.g. from a plist file).
+func listDeltaOf(current, next interface{}) (string, bool) {
+	cur, ok := scalarStrings(canonicalValue(current))
+	if !ok || len(cur) == 0 {
+		return "", false
+	}
+	nxt, ok := scalarStrings(canonicalValue(next))
+	if !ok {
+		return "", false
+	}
+	inCur := make(map[string]bool, len(cur))
+	for _, e := range cur {
+		inCur[e] = true
+	}
+	inNext := make(map[string]bool, len(nxt))
+	for _, e := range nxt {
+		inNext[e] = true
+	}
+	var parts []string
+	for _, e := range nxt {
+		if !inCur[e] {
+			parts = append(parts, "+"+e)
+		}
+	}
+	for _, e := range cur {
+		if !inNext[e] {
+			parts = append(parts, "-"+e)
+		}
+	}
+	if len(parts) == 0 {
+		parts = append(parts, "(reordered)")
+	}
+	return strings.Join(parts, " "), true
+}
+
+// scalarStrings returns the elements of v as strings if v is a list of scalars.
+func scalarStrings(v interface{}) ([]string, bool) {
+	l, ok := v.([]interface{})
+	if !ok {
+		return nil, false
+	}
+	out := make([]string, len(l))
+	for i, e := range l {
+		s, ok := e.(string)
+		if !ok {
+			return nil, false
+		}
+		out[i] = s
+	}
+	return out, true
+}
+
+// formatCompositeValue returns a compact, deterministic string for a list or dict value (JSON with sorted keys).
+func formatCompositeValue(v interface{}) string {
+	data, err := json.Marshal(canonicalValue(v))
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
+
+// ParseDefaultsValue parses the old-style (NeXTSTEP) property list text printed by `defaults read`
+// for a single value: arrays "( a, b )", dicts "{ k = v; }", quoted or bare strings.
+// Scalars are returned as strings; arrays as []interface{}; dicts as map[string]interface{}.
+func ParseDefaultsValue(raw string) (interface{}, error) {
+	p := &defaultsParser{s: raw}
+	v, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos != len(p.s) {
+		return nil, fmt.Errorf("unexpected %q at offset %d", p.s[p.pos:], p.pos)
+	}
+	return v, nil
+}
+
+type defaultsParser struct {
+	s   string
+	pos int
+}
+
+func (p *defaultsParser) skipSpace() {
+	for p.pos < len(p.s) && strings.ContainsRune(" \t\r\n", rune(p.s[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *defaultsParser) value() (interface{}, error) {
+	p.skipSpace()
+	if p.pos >= len(p.s) {
+		return nil, fmt.Errorf("unexpected end of input")
+	}
+	switch p.s[p.pos] {
+	case '(':
+		return p.array()
+	case '{':
+		return p.dict()
+	case '"':
+		return p.quoted()
+	default:
+		return p.bare()
+	}
+}
+
+func (p *defaultsParser) array() (interface{}, error) {
+	p.pos++ // (
+	out := []interface{}{}
+	for {
+		p.skipSpace()
+		if p.pos < len(p.s) && p.s[p.pos] == ')' {
+			p.pos++
+			return out, nil
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+		p.skipSpace()
+		if p.pos < len(p.s) && p.s[p.pos] == ',' {
+			p.pos++
+			continue
+		}
+		if p.pos < len(p.s) && p.s[p.pos] == ')' {
+			continue
+		}
+		return nil, fmt.Errorf("expected ',' or ')' at offset %d", p.pos)
+	}
+}
+
+func (p *defaultsParser) dict() (interface{}, error) {
+	p.pos++ // {
+	out := map[string]interface{}{}
+	for {
+		p.skipSpace()
+		if p.pos < len(p.s) && p.s[p.pos] == '}' {
+			p.pos++
+			return out, nil
+		}
+		k, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			return nil, fmt.Errorf("dict key at offset %d is not a string", p.pos)
+		}
+		p.skipSpace()
+		if p.pos >= len(p.s) || p.s[p.pos] != '=' {
+			return nil, fmt.Errorf("expected '=' at offset %d", p.pos)
+		}
+		p.pos++
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		out[key] = v
+		p.skipSpace()
+		if p.pos >= len(p.s) || p.s[p.pos] != ';' {
+			return nil, fmt.Errorf("expected ';' at offset %d", p.pos)
+		}
+		p.pos++
+	}
+}
+
+func (p *defaultsParser) quoted() (interface{}, error) {
+	start := p.pos
+	p.pos++ // opening quote
+	for p.pos < len(p.s) {
+		switch p.s[p.pos] {
+		case '\\':
+			p.pos += 2
+		case '"':
+			p.pos++
+			s, err := strconv.Unquote(p.s[start:p.pos])
+			if err != nil {
+				// defaults uses \U escapes that strconv does not accept; keep the raw text.
+				return p.s[start+1 : p.pos-1], nil
+			}
+			return s, nil
+		default:
+			p.pos++
+		}
+	}
+	return nil, fmt.Errorf("unterminated string at offset %d", start)
+}
+
+func (p *defaultsParser) bare() (interface{}, error) {
+	start := p.pos
+	for p.pos < len(p.s) && !strings.ContainsRune(" \t\r\n,;=(){}\"", rune(p.s[p.pos])) {
+		p.pos++
+	}
+	if p.pos == start {
+		return nil, fmt.Errorf("unexpected %q at offset %d", p.s[p.pos], p.pos)
+	}
+	return p.s[start:p.pos], nil
+}
@@ -0,0 +1,92 @@
+package brave
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDefaultsValue(t *testing.T) {
+	raw := `(
+    "cjpalhdlnbpafiamejdnhcphjbkeiagm;https://clients2.google.com/service/update2/crx",
+    nngceckbapebfimnlniiiahkandclblb
+)`
+	v, err := ParseDefaultsValue(raw)
+	if err != nil {
+		t.Fatalf("ParseDefaultsValue: %v", err)
+	}
+	l, ok := v.([]interface{})
+	if !ok || len(l) != 2 {
+		t.Fatalf("expected 2-element list, got %#v", v)
+	}
+	if l[1] != "nngceckbapebfimnlniiiahkandclblb" {
+		t.Errorf("unexpected bare element %#v", l[1])
+	}
+
+	raw = `{
+    "*" =     {
+        "installation_mode" = blocked;
+    };
+    cjpalhdlnbpafiamejdnhcphjbkeiagm =     {
+        "installation_mode" = "force_installed";
+        "toolbar_pin" = 1;
+    };
+}`
+	v, err = ParseDefaultsValue(raw)
+	if err != nil {
+		t.Fatalf("ParseDefaultsValue: %v", err)
+	}
+	d, ok := v.(map[string]interface{})
+	if !ok || len(d) != 2 {
+		t.Fatalf("expected 2-key dict, got %#v", v)
+	}
+	if d["*"].(map[string]interface{})["installation_mode"] != "blocked" {
+		t.Errorf("unexpected nested value %#v", d["*"])
+	}
+
+	for _, bad := range []string{"(", "{ a = b }", `("x"`, "( a b )"} {
+		if _, err := ParseDefaultsValue(bad); err == nil {
+			t.Errorf("ParseDefaultsValue(%q): expected error", bad)
+		}
+	}
+}
+
+func TestCompositeRoundTrip(t *testing.T) {
+	written := Setting{
+		Key:  "ExtensionSettings",
+		Type: TypeDict,
+		Value: map[string]interface{}{
+			"cjpalhdlnbpafiamejdnhcphjbkeiagm": map[string]interface{}{"installation_mode": "force_installed", "toolbar_pin": true},
+		},
+	}
+	raw := `{ cjpalhdlnbpafiamejdnhcphjbkeiagm = { "installation_mode" = "force_installed"; "toolbar_pin" = 1; }; }`
+	if got, want := readValueStr(raw, TypeDict), settingValueStr(written); got != want {
+		t.Errorf("read %q != written %q", got, want)
+	}
+}
+
+func TestSettingsToPlistXMLList(t *testing.T) {
+	xml := settingsToPlistXML([]Setting{
+		{Key: "URLBlocklist", Type: TypeList, Value: []interface{}{"example.com", "a&b.org"}},
+		{Key: "ExtensionSettings", Type: TypeDict, Value: map[string]interface{}{"*": map[string]interface{}{"installation_mode": "blocked"}}},
+	})
+	if !strings.Contains(xml, "<array><string>example.com</string><string>a&amp;b.org</string></array>") {
+		t.Errorf("unexpected list XML: %s", xml)
+	}
+	if !strings.Contains(xml, "<dict><key>*</key><dict><key>installation_mode</key><string>blocked</string></dict></dict>") {
+		t.Errorf("unexpected dict XML: %s", xml)
+	}
+}
+
+func TestListDelta(t *testing.T) {
+	raw := "(\n    \"a.example\",\n    \"b.example\"\n)"
+	got, ok := listDelta(raw, []interface{}{"b.example", "c.example"})
+	if !ok || got != "+c.example -a.example" {
+		t.Errorf("listDelta = %q, %v", got, ok)
+	}
+	if _, ok := listDelta("", []interface{}{"a"}); ok {
+		t.Error("expected ok=false for unset current value")
+	}
+	if _, ok := listDelta(`({ name = x; })`, []interface{}{"a"}); ok {
+		t.Error("expected ok=false for a list of dicts")
+	}
+}
@@ -0,0 +1,141 @@
+package brave
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ParsePlistXML returns the top-level keys of an XML property list as Settings with their plist types.
+// Nested values keep their types (bool, int, float64, string, []interface{}, map[string]interface{}).
+// Top-level date and data values have no policy type and are skipped.
+func ParsePlistXML(data []byte) (map[string]Setting, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil, fmt.Errorf("plist: no top-level dict")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("plist: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local == "plist" {
+			continue
+		}
+		if start.Name.Local != "dict" {
+			return nil, fmt.Errorf("plist: top-level element is <%s>, want <dict>", start.Name.Local)
+		}
+		out := make(map[string]Setting)
+		err = plistDictEntries(dec, func(key string, v interface{}, elem string) {
+			switch elem {
+			case "true", "false":
+				out[key] = Setting{Key: key, Value: v, Type: TypeBool}
+			case "integer":
+				out[key] = Setting{Key: key, Value: v, Type: TypeInteger}
+			case "string", "real":
+				out[key] = Setting{Key: key, Value: fmt.Sprintf("%v", v), Type: TypeString}
+			case "array":
+				out[key] = Setting{Key: key, Value: v, Type: TypeList}
+			case "dict":
+				out[key] = Setting{Key: key, Value: v, Type: TypeDict}
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		return out, nil
+	}
+}
+
+// plistDictEntries reads <key>/value pairs until </dict>, calling fn with each value and its element name.
+func plistDictEntries(dec *xml.Decoder, fn func(key string, v interface{}, elem string)) error {
+	var key string
+	haveKey := false
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("plist: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.EndElement:
+			return nil
+		case xml.StartElement:
+			if t.Name.Local == "key" {
+				var k string
+				if err := dec.DecodeElement(&k, &t); err != nil {
+					return fmt.Errorf("plist: %w", err)
+				}
+				key, haveKey = k, true
+				continue
+			}
+			if !haveKey {
+				return fmt.Errorf("plist: <%s> without a <key>", t.Name.Local)
+			}
+			v, err := plistValue(dec, t)
+			if err != nil {
+				return fmt.Errorf("plist: key %s: %w", key, err)
+			}
+			fn(key, v, t.Name.Local)
+			haveKey = false
+		}
+	}
+}
+
+// plistValue decodes the element started by start into a Go value.
+func plistValue(dec *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	switch start.Name.Local {
+	case "true", "false":
+		if err := dec.Skip(); err != nil {
+			return nil, err
+		}
+		return start.Name.Local == "true", nil
+	case "dict":
+		out := map[string]interface{}{}
+		err := plistDictEntries(dec, func(key string, v interface{}, _ string) {
+			out[key] = v
+		})
+		return out, err
+	case "array":
+		out := []interface{}{}
+		for {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := tok.(type) {
+			case xml.EndElement:
+				return out, nil
+			case xml.StartElement:
+				v, err := plistValue(dec, t)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, v)
+			}
+		}
+	}
+	var text string
+	if err := dec.DecodeElement(&text, &start); err != nil {
+		return nil, err
+	}
+	switch start.Name.Local {
+	case "integer":
+		n, err := strconv.Atoi(strings.TrimSpace(text))
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q", text)
+		}
+		return n, nil
+	case "real":
+		f, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid real %q", text)
+		}
+		return f, nil
+	}
+	return text, nil // string, date, data
+}
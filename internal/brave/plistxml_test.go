@@ -0,0 +1,77 @@
+package brave
+
+import "testing"
+
+func TestParsePlistXML(t *testing.T) {
+	data := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>BraveRewardsDisabled</key>
+	<true/>
+	<key>DefaultBraveAdblockSetting</key>
+	<integer>1</integer>
+	<key>DnsOverHttpsMode</key>
+	<string>secure</string>
+	<key>URLBlocklist</key>
+	<array>
+		<string>example.com</string>
+		<string>ads.example</string>
+	</array>
+	<key>ExtensionSettings</key>
+	<dict>
+		<key>*</key>
+		<dict>
+			<key>installation_mode</key>
+			<string>blocked</string>
+			<key>blocked_install_message</key>
+			<string>no &amp; never</string>
+		</dict>
+	</dict>
+	<key>LastUpdate</key>
+	<date>2026-01-02T03:04:05Z</date>
+</dict>
+</plist>
+`)
+	got, err := ParsePlistXML(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := got["BraveRewardsDisabled"]; s.Type != TypeBool || s.Value != true {
+		t.Errorf("BraveRewardsDisabled = %+v", s)
+	}
+	if s := got["DefaultBraveAdblockSetting"]; s.Type != TypeInteger || s.Value != 1 {
+		t.Errorf("DefaultBraveAdblockSetting = %+v, want integer 1 (not bool)", s)
+	}
+	if s := got["DnsOverHttpsMode"]; s.Type != TypeString || s.Value != "secure" {
+		t.Errorf("DnsOverHttpsMode = %+v", s)
+	}
+	if s := got["URLBlocklist"]; s.Type != TypeList || len(s.Value.([]interface{})) != 2 {
+		t.Errorf("URLBlocklist = %+v", s)
+	}
+	ext := got["ExtensionSettings"]
+	star, _ := ext.Value.(map[string]interface{})["*"].(map[string]interface{})
+	if ext.Type != TypeDict || star["blocked_install_message"] != "no & never" {
+		t.Errorf("ExtensionSettings = %+v", ext)
+	}
+	if _, ok := got["LastUpdate"]; ok {
+		t.Error("date value should be skipped")
+	}
+}
+
+func TestParsePlistXMLRoundTrip(t *testing.T) {
+	in := []Setting{
+		{Key: "A", Value: true, Type: TypeBool},
+		{Key: "B", Value: 5, Type: TypeInteger},
+		{Key: "C", Value: []interface{}{"x", map[string]interface{}{"k": 1}}, Type: TypeList},
+	}
+	got, err := ParsePlistXML([]byte(settingsToPlistXML(in)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range in {
+		if ValueString(got[s.Key]) != ValueString(s) || got[s.Key].Type != s.Type {
+			t.Errorf("%s = %+v, want %+v", s.Key, got[s.Key], s)
+		}
+	}
+}
@@ -8,9 +8,10 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
-	"sort"
 	"strings"
 	"time"
+
+	"github.com/cowardly/cowardly/internal/lock"
 )
 
 // Timeouts for subprocess calls to avoid hanging.
@@ -40,6 +41,8 @@ const (
 	TypeBool    ValueType = "bool"
 	TypeInteger ValueType = "integer"
 	TypeString  ValueType = "string"
+	TypeList    ValueType = "list" // Value is []interface{} (strings, or dicts for e.g. ManagedBookmarks)
+	TypeDict    ValueType = "dict" // Value is map[string]interface{} (e.g. ExtensionSettings)
 )
 
 // Setting represents a single Brave preference key and its value.
@@ -79,6 +82,8 @@ func settingsToPlistXML(settings []Setting) string {
 			b.WriteString("<string>")
 			b.WriteString(plistEscapeString(fmt.Sprintf("%v", s.Value)))
 			b.WriteString("</string>")
+		case TypeList, TypeDict:
+			writePlistValue(&b, s.Value)
 		default:
 			b.WriteString("<string>")
 			b.WriteString(plistEscapeString(fmt.Sprintf("%v", s.Value)))
@@ -131,6 +136,11 @@ func writeToPath(path string, s Setting) error {
 		args = append(args, "-integer", v)
 	case TypeString:
 		args = append(args, "-string", fmt.Sprintf("%v", s.Value))
+	case TypeList, TypeDict:
+		// defaults accepts a single plist-formatted (XML) argument for arrays and nested dicts.
+		var b strings.Builder
+		writePlistValue(&b, s.Value)
+		args = append(args, b.String())
 	default:
 		return fmt.Errorf("unsupported type %q", s.Type)
 	}
@@ -161,11 +171,27 @@ func WriteAll(settings []Setting) error {
 	return nil
 }
 
-// ApplySettings writes to managed preferences (enforced) when possible; otherwise to user prefs.
+// ApplySettings writes settings to the target chosen with UseTarget (default TargetAuto).
 // Returns true if managed path was used (policies will be enforced; restart Brave).
-func ApplySettings(settings []Setting) (managed bool, err error) {
-	if err := WriteAllToManaged(settings); err == nil {
-		return true, nil
+func ApplySettings(l *lock.Lock, settings []Setting) (managed bool, err error) {
+	return ApplySettingsTo(l, settings, ResolveTarget(""))
+}
+
+// ApplySettingsTo writes settings to t. TargetAuto writes to managed preferences (enforced) when possible,
+// otherwise to user prefs; TargetManaged fails instead of falling back. Returns true if managed path was used.
+// l is the cowardly lock (see package lock), held by the caller.
+func ApplySettingsTo(l *lock.Lock, settings []Setting, t Target) (managed bool, err error) {
+	if err := l.Check(); err != nil {
+		return false, err
+	}
+	if t != TargetUser {
+		err := WriteAllToManaged(settings)
+		if err == nil {
+			return true, nil
+		}
+		if t == TargetManaged {
+			return false, fmt.Errorf("%w (target is managed; use --target=user or --target=auto to write user preferences)", err)
+		}
 	}
 	if err := WriteAll(settings); err != nil {
 		return false, err
@@ -191,8 +217,13 @@ func WriteAllToManaged(settings []Setting) error {
 	if err := os.WriteFile(src, []byte(xmlContent), 0600); err != nil {
 		return fmt.Errorf("write temp plist: %w", err)
 	}
+	return installManagedPlist(src)
+}
 
-	// Only the temp file path (src) is passed to the shell; preset data is in the plist content
+// installManagedPlist copies the plist at src to the managed plist of the current channel, asking
+// for admin privileges (GUI dialog).
+func installManagedPlist(src string) error {
+	// Only the file path (src) is passed to the shell; preset data is in the plist content
 	// and never interpolated into the shell command, so there is no shell injection from presets.
 	// Use AppleScript "with administrator privileges" so a GUI dialog appears (password or Touch ID).
 	// chmod 644 so the plist is readable by Brave (per hi-one / managed preferences practice).
@@ -275,14 +306,28 @@ func Delete(key string) error {
 	return nil
 }
 
+// DeleteAll removes multiple keys from user preferences; stops on first error.
+func DeleteAll(keys []string) error {
+	for _, k := range keys {
+		if err := Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Reset removes the user preferences plist and the managed plist (if present).
 // Brave must be quit first; otherwise the app or cfprefsd can rewrite the plist from cache.
 // Returns (hadManaged, managedRemoved, nil) on success. hadManaged is true if a managed plist
 // existed (so an auth dialog may have been shown). managedRemoved is true if it was removed.
-func Reset() (hadManaged, managedRemoved bool, err error) {
+// l is the cowardly lock, held by the caller.
+func Reset(l *lock.Lock) (hadManaged, managedRemoved bool, err error) {
 	if !IsMacOS() {
 		return false, false, fmt.Errorf("cowardly only supports macOS")
 	}
+	if err := l.Check(); err != nil {
+		return false, false, err
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), defaultsTimeout)
 	defer cancel()
 	cmd := exec.CommandContext(ctx, "defaults", "delete", Domain())
@@ -299,20 +344,32 @@ func Reset() (hadManaged, managedRemoved bool, err error) {
 	if userPath, pathErr := UserPreferencesPath(); pathErr == nil {
 		_ = os.Remove(userPath)
 	}
-	managedPath := ManagedPreferencesPath() + ".plist"
-	if _, statErr := os.Stat(managedPath); statErr == nil {
+	if ManagedPlistExists() {
 		hadManaged = true
-		script := `do shell script "rm -f ` + escapeForAppleScript(shellSingleQuoted(managedPath)) + `" with administrator privileges`
-		ctx2, cancel2 := context.WithTimeout(context.Background(), osascriptTimeout)
-		defer cancel2()
-		cmd := exec.CommandContext(ctx2, "osascript", "-e", script)
-		_ = cmd.Run() // ignore error if user cancels
-		_, stillExists := os.Stat(managedPath)
-		managedRemoved = stillExists != nil
+		_ = RemoveManagedPlist() // ignore error if user cancels
+		managedRemoved = !ManagedPlistExists()
 	}
 	return hadManaged, managedRemoved, nil
 }
 
+// RemoveManagedPlist deletes the managed plist, asking for admin privileges (GUI dialog).
+func RemoveManagedPlist() error {
+	if !IsMacOS() {
+		return fmt.Errorf("cowardly only supports macOS")
+	}
+	managedPath := ManagedPreferencesPath() + ".plist"
+	script := `do shell script "rm -f ` + escapeForAppleScript(shellSingleQuoted(managedPath)) + `" with administrator privileges`
+	ctx, cancel := context.WithTimeout(context.Background(), osascriptTimeout)
+	defer cancel()
+	if out, err := exec.CommandContext(ctx, "osascript", "-e", script).CombinedOutput(); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("remove managed preferences: %w", ctx.Err())
+		}
+		return fmt.Errorf("remove managed preferences: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
 // BraveInstalled checks if Brave Browser is installed at BraveAppPath().
 func BraveInstalled() bool {
 	if !IsMacOS() {
@@ -369,100 +426,6 @@ func UserPreferencesPath() (string, error) {
 	return filepath.Join(home, "Library", "Preferences", Domain()+".plist"), nil
 }
 
-// BackupDir returns the backups directory path (~/Library/Application Support/cowardly/backups).
-func BackupDir() (string, error) {
-	if !IsMacOS() {
-		return "", fmt.Errorf("cowardly only supports macOS")
-	}
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("home dir: %w", err)
-	}
-	return filepath.Join(home, "Library", "Application Support", "cowardly", "backups"), nil
-}
-
-// BackupUserPlist copies the user preferences plist to ~/Library/Application Support/cowardly/backups/<timestamp>-user.plist.
-// Creates the backup directory if needed. Returns the backup path, or error if the source plist does not exist or copy fails.
-func BackupUserPlist() (string, error) {
-	src, err := UserPreferencesPath()
-	if err != nil {
-		return "", err
-	}
-	if _, err := os.Stat(src); err != nil {
-		return "", fmt.Errorf("user plist not found: %w", err)
-	}
-	backupDir, err := BackupDir()
-	if err != nil {
-		return "", err
-	}
-	if err := os.MkdirAll(backupDir, 0755); err != nil {
-		return "", fmt.Errorf("create backup dir: %w", err)
-	}
-	ts := time.Now().Format("2006-01-02T15-04-05")
-	dst := filepath.Join(backupDir, ts+"-user.plist")
-	data, err := os.ReadFile(src)
-	if err != nil {
-		return "", fmt.Errorf("read user plist: %w", err)
-	}
-	if err := os.WriteFile(dst, data, 0600); err != nil {
-		return "", fmt.Errorf("write backup: %w", err)
-	}
-	return dst, nil
-}
-
-// ListBackups returns paths to existing backup plists in the backup directory, newest first (by filename timestamp).
-func ListBackups() ([]string, error) {
-	dir, err := BackupDir()
-	if err != nil {
-		return nil, err
-	}
-	entries, err := os.ReadDir(dir)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil
-		}
-		return nil, fmt.Errorf("read backup dir: %w", err)
-	}
-	var paths []string
-	for _, e := range entries {
-		if e.IsDir() {
-			continue
-		}
-		if strings.HasSuffix(e.Name(), "-user.plist") {
-			paths = append(paths, filepath.Join(dir, e.Name()))
-		}
-	}
-	// Newest first (filename format 2006-01-02T15-04-05-user.plist sorts lexicographically)
-	sort.Slice(paths, func(i, j int) bool {
-		return paths[i] > paths[j]
-	})
-	return paths, nil
-}
-
-// RestoreFromBackup copies a backup plist over the current user preferences. Restart Brave for changes to take effect.
-func RestoreFromBackup(backupPath string) error {
-	dst, err := UserPreferencesPath()
-	if err != nil {
-		return err
-	}
-	data, err := os.ReadFile(backupPath)
-	if err != nil {
-		return fmt.Errorf("read backup: %w", err)
-	}
-	if err := os.WriteFile(dst, data, 0600); err != nil {
-		return fmt.Errorf("write user plist: %w", err)
-	}
-	return nil
-}
-
-// DeleteBackup removes a backup file from disk.
-func DeleteBackup(backupPath string) error {
-	if err := os.Remove(backupPath); err != nil {
-		return fmt.Errorf("delete backup: %w", err)
-	}
-	return nil
-}
-
 // DryRun returns a human-readable description of what ApplySettings would write (without writing).
 func DryRun(settings []Setting) string {
 	var b strings.Builder
@@ -480,6 +443,8 @@ func DryRun(settings []Setting) string {
 			val = fmt.Sprintf("%v", s.Value)
 		case TypeString:
 			val = fmt.Sprintf("%q", s.Value)
+		case TypeList, TypeDict:
+			val = formatCompositeValue(s.Value)
 		default:
 			val = fmt.Sprintf("%v", s.Value)
 		}
@@ -488,6 +453,12 @@ func DryRun(settings []Setting) string {
 	return strings.TrimSpace(b.String())
 }
 
+// ValueString returns the canonical string form of s's value (bools as 1/0), so values from presets,
+// `defaults read` and backups can be compared.
+func ValueString(s Setting) string {
+	return settingValueStr(s)
+}
+
 // settingValueStr returns the string form of a setting's value for comparison.
 func settingValueStr(s Setting) string {
 	switch s.Type {
@@ -500,32 +471,101 @@ func settingValueStr(s Setting) string {
 		return fmt.Sprintf("%v", s.Value)
 	case TypeString:
 		return fmt.Sprintf("%v", s.Value)
+	case TypeList, TypeDict:
+		return formatCompositeValue(s.Value)
 	default:
 		return fmt.Sprintf("%v", s.Value)
 	}
 }
 
+// readValueStr returns the string form of a raw `defaults read` value comparable with settingValueStr for t.
+// List and dict values are parsed from the old-style plist text and re-formatted canonically.
+func readValueStr(raw string, t ValueType) string {
+	if raw == "" || (t != TypeList && t != TypeDict) {
+		return raw
+	}
+	v, err := ParseDefaultsValue(raw)
+	if err != nil {
+		return raw
+	}
+	return formatCompositeValue(v)
+}
+
+// ValueNames maps policy key -> raw value -> display name, for integer policies whose values have
+// names (e.g. Shields levels). The packages that own such policies build it; a nil ValueNames names nothing.
+type ValueNames map[string]map[string]string
+
+// Label returns v with its name appended, e.g. "3 (standard)", or v unchanged.
+func (n ValueNames) Label(key, v string) string {
+	if name := n[key][strings.TrimSpace(v)]; name != "" {
+		return fmt.Sprintf("%s (%s)", v, name)
+	}
+	return v
+}
+
 // Diff returns a human-readable list of changes that would be made (current value -> new value).
-// Only includes keys where the effective current value differs from the new value.
-func Diff(settings []Setting) string {
+// Only includes keys where the effective current value differs from the new value. Values are
+// labelled with names.
+func Diff(settings []Setting, names ValueNames) string {
+	return DiffIn(settings, TargetAuto, names)
+}
+
+// DiffIn is Diff against the plist of scope: TargetManaged and TargetUser compare only that plist,
+// TargetAuto (or "") the effective value.
+func DiffIn(settings []Setting, scope Target, names ValueNames) string {
 	var b strings.Builder
 	for _, s := range settings {
-		current, _ := ReadManaged(s.Key)
-		if current == "" {
-			current, _ = Read(s.Key)
+		raw, current, differs := compareIn(s, scope)
+		if !differs {
+			continue
 		}
 		newStr := settingValueStr(s)
-		if current == newStr {
+		if delta, ok := listDelta(raw, s.Value); ok && s.Type == TypeList {
+			b.WriteString(fmt.Sprintf("  %s: %s\n", s.Key, delta))
 			continue
 		}
 		if current == "" {
 			current = "(not set)"
 		}
-		b.WriteString(fmt.Sprintf("  %s: %s -> %s\n", s.Key, current, newStr))
+		b.WriteString(fmt.Sprintf("  %s: %s -> %s\n", s.Key, names.Label(s.Key, current), names.Label(s.Key, newStr)))
 	}
 	return strings.TrimSpace(b.String())
 }
 
+// Drifted returns the settings whose effective current value differs from s (the keys Diff lists).
+func Drifted(settings []Setting) []Setting {
+	return DriftedIn(settings, TargetAuto)
+}
+
+// DriftedIn returns the settings whose value in scope differs from s (the keys DiffIn lists).
+func DriftedIn(settings []Setting, scope Target) []Setting {
+	var out []Setting
+	for _, s := range settings {
+		if _, _, differs := compareIn(s, scope); differs {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// compareIn reads the value of s.Key in scope (for TargetAuto the effective value: managed overrides user)
+// and reports whether it differs from s. raw is the `defaults read` text; current is its comparable form.
+func compareIn(s Setting, scope Target) (raw, current string, differs bool) {
+	switch scope {
+	case TargetManaged:
+		raw, _ = ReadManaged(s.Key)
+	case TargetUser:
+		raw, _ = Read(s.Key)
+	default:
+		raw, _ = ReadManaged(s.Key)
+		if raw == "" {
+			raw, _ = Read(s.Key)
+		}
+	}
+	current = readValueStr(raw, s.Type)
+	return raw, current, current != settingValueStr(s)
+}
+
 // ReadCurrent returns the effective current value for key (managed overrides user) and infers a type.
 // Used for export. The value is normalized to the format we use in Setting (bool, int, or string).
 func ReadCurrent(key string) (Setting, bool) {
@@ -536,26 +576,62 @@ func ReadCurrent(key string) (Setting, bool) {
 	if !ok {
 		return Setting{}, false
 	}
+	return SettingFromDefaults(key, raw), true
+}
+
+// SettingFromDefaults converts a raw `defaults read` value into a Setting, inferring its type
+// (list/dict from old-style plist text, then bool, integer, string).
+func SettingFromDefaults(key, raw string) Setting {
 	raw = strings.TrimSpace(raw)
 	s := Setting{Key: key}
+	if strings.HasPrefix(raw, "(") || strings.HasPrefix(raw, "{") {
+		if v, err := ParseDefaultsValue(raw); err == nil {
+			switch v.(type) {
+			case []interface{}:
+				s.Type = TypeList
+			default:
+				s.Type = TypeDict
+			}
+			s.Value = v
+			return s
+		}
+	}
 	switch strings.ToLower(raw) {
 	case "1", "true", "yes":
 		s.Type = TypeBool
 		s.Value = true
-		return s, true
+		return s
 	case "0", "false", "no":
 		s.Type = TypeBool
 		s.Value = false
-		return s, true
+		return s
 	}
 	if n, err := parseInt(raw); err == nil {
 		s.Type = TypeInteger
 		s.Value = n
-		return s, true
+		return s
 	}
 	s.Type = TypeString
 	s.Value = raw
-	return s, true
+	return s
+}
+
+// ReadPlistFile returns the top-level keys of a plist file (e.g. a backup) as Settings with their plist types.
+func ReadPlistFile(path string) (map[string]Setting, error) {
+	if !IsMacOS() {
+		return nil, fmt.Errorf("cowardly only supports macOS")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultsTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "plutil", "-convert", "xml1", "-o", "-", path).Output()
+	if err != nil || ctx.Err() != nil {
+		return nil, fmt.Errorf("read %s: %v", path, err)
+	}
+	settings, err := ParsePlistXML(out)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return settings, nil
 }
 
 func parseInt(s string) (int, error) {
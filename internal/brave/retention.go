@@ -0,0 +1,116 @@
+package brave
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cowardly/cowardly/internal/lock"
+	"github.com/cowardly/cowardly/internal/paths"
+)
+
+// Retention is the backup retention policy (backups: in cowardly.yaml). A backup is kept if any rule
+// keeps it. Labelled backups, and backups without a manifest (taken by older versions), are never pruned
+// and do not count towards the rules.
+type Retention struct {
+	KeepLast   int // the newest KeepLast backups
+	KeepDaily  int // the newest backup of each day, for the last KeepDaily days
+	KeepWeekly int // the newest backup of each week, for the last KeepWeekly weeks
+}
+
+// DefaultRetention is the policy when cowardly.yaml has no backups section.
+var DefaultRetention = Retention{KeepLast: 10, KeepDaily: 7, KeepWeekly: 4}
+
+// Enabled reports whether r prunes anything; a policy that keeps nothing is treated as "keep everything".
+func (r Retention) Enabled() bool {
+	return r.KeepLast > 0 || r.KeepDaily > 0 || r.KeepWeekly > 0
+}
+
+// String describes r, e.g. "last 10, daily for 7 days, weekly for 4 weeks".
+func (r Retention) String() string {
+	if !r.Enabled() {
+		return "keep everything"
+	}
+	var parts []string
+	if r.KeepLast > 0 {
+		parts = append(parts, fmt.Sprintf("last %d", r.KeepLast))
+	}
+	if r.KeepDaily > 0 {
+		parts = append(parts, fmt.Sprintf("daily for %d days", r.KeepDaily))
+	}
+	if r.KeepWeekly > 0 {
+		parts = append(parts, fmt.Sprintf("weekly for %d weeks", r.KeepWeekly))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Time returns when the backup was taken, from its name (local time).
+func (b Backup) Time() time.Time {
+	t, _ := parseBackupTime(strings.TrimSuffix(b.Name(), legacyBackupSuffix))
+	return t
+}
+
+// ExpiredBackups returns the backups of the current channel that r does not keep, newest first.
+func ExpiredBackups(p paths.Paths, r Retention) ([]Backup, error) {
+	backups, err := ListBackups(p)
+	if err != nil {
+		return nil, err
+	}
+	return planPrune(backups, r, time.Now()), nil
+}
+
+// PruneBackups deletes the ExpiredBackups and returns them (l is the cowardly lock, held by the caller).
+// On error it returns the backups deleted so far.
+func PruneBackups(l *lock.Lock, p paths.Paths, r Retention) ([]Backup, error) {
+	if err := l.Check(); err != nil {
+		return nil, err
+	}
+	prune, err := ExpiredBackups(p, r)
+	if err != nil {
+		return nil, err
+	}
+	for i, b := range prune {
+		if err := DeleteBackup(b.Path); err != nil {
+			return prune[:i], err
+		}
+	}
+	return prune, nil
+}
+
+// planPrune returns the backups (newest first) that r does not keep at now.
+func planPrune(backups []Backup, r Retention, now time.Time) []Backup {
+	if !r.Enabled() {
+		return nil
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	dailyFrom := today.AddDate(0, 0, 1-r.KeepDaily)
+	weeklyFrom := today.AddDate(0, 0, -7*r.KeepWeekly)
+	days := map[string]bool{}
+	weeks := map[string]bool{}
+	last := 0
+	var prune []Backup
+	for _, b := range backups {
+		if b.Info == nil || b.Info.Label != "" {
+			continue
+		}
+		t := b.Time()
+		keep := false
+		if last < r.KeepLast {
+			last++
+			keep = true
+		}
+		if day := t.Format("2006-01-02"); r.KeepDaily > 0 && !t.Before(dailyFrom) && !days[day] {
+			days[day] = true
+			keep = true
+		}
+		year, w := t.ISOWeek()
+		if week := fmt.Sprintf("%d-W%02d", year, w); r.KeepWeekly > 0 && !t.Before(weeklyFrom) && !weeks[week] {
+			weeks[week] = true
+			keep = true
+		}
+		if !keep {
+			prune = append(prune, b)
+		}
+	}
+	return prune
+}
@@ -0,0 +1,48 @@
+package brave
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPlanPrune(t *testing.T) {
+	now := time.Date(2026, 3, 20, 12, 0, 0, 0, time.Local)
+	backup := func(name, label string) Backup {
+		return Backup{Path: "/b/" + name, Info: &BackupInfo{Label: label}}
+	}
+	backups := []Backup{ // newest first
+		backup("2026-03-20T11-00-00", ""),
+		backup("2026-03-20T10-00-00", ""),
+		backup("2026-03-19T10-00-00", ""),
+		backup("2026-03-19T09-00-00", ""),
+		backup("2026-03-10T10-00-00", "before trip"),
+		backup("2026-03-09T10-00-00", ""),
+		backup("2026-03-08T10-00-00", ""),
+		{Path: "/b/2026-01-05T10-00-00-user.plist"}, // legacy, no manifest: never pruned
+	}
+	names := func(bs []Backup) []string {
+		var out []string
+		for _, b := range bs {
+			out = append(out, b.Name())
+		}
+		return out
+	}
+	tests := []struct {
+		name string
+		r    Retention
+		want []string
+	}{
+		{"keep everything", Retention{}, nil},
+		{"last 2", Retention{KeepLast: 2}, []string{"2026-03-19T10-00-00", "2026-03-19T09-00-00", "2026-03-09T10-00-00", "2026-03-08T10-00-00"}},
+		{"daily for 2 days", Retention{KeepDaily: 2}, []string{"2026-03-20T10-00-00", "2026-03-19T09-00-00", "2026-03-09T10-00-00", "2026-03-08T10-00-00"}},
+		// 2026-03-08 is a Sunday: the 9th starts a new ISO week.
+		{"weekly for 4 weeks", Retention{KeepWeekly: 4}, []string{"2026-03-20T10-00-00", "2026-03-19T10-00-00", "2026-03-19T09-00-00"}},
+		{"last 1 and daily for 2 days", Retention{KeepLast: 1, KeepDaily: 2}, []string{"2026-03-20T10-00-00", "2026-03-19T09-00-00", "2026-03-09T10-00-00", "2026-03-08T10-00-00"}},
+	}
+	for _, tt := range tests {
+		if got := names(planPrune(backups, tt.r, now)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: planPrune() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package brave
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Target is where ApplySettings writes: the managed plist (enforced), user preferences, or managed with a
+// fallback to user preferences when the admin dialog is cancelled.
+type Target string
+
+const (
+	TargetAuto    Target = "auto"
+	TargetManaged Target = "managed"
+	TargetUser    Target = "user"
+)
+
+// target is the target set with UseTarget; "" means none was chosen (TargetAuto).
+var target Target
+
+// ParseTarget returns the target named s (managed, user or auto).
+func ParseTarget(s string) (Target, error) {
+	for _, t := range []Target{TargetAuto, TargetManaged, TargetUser} {
+		if strings.EqualFold(s, string(t)) {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("unknown target %q (use managed, user or auto)", s)
+}
+
+// UseTarget sets the target of ApplySettings (e.g. at startup from a --target flag).
+func UseTarget(t Target) {
+	target = t
+}
+
+// ChosenTarget returns the target set with UseTarget, or "" if none was chosen.
+func ChosenTarget() Target {
+	return target
+}
+
+// ResolveTarget returns the target for re-applying a saved desired state: the one chosen with UseTarget,
+// else saved (where the state was last applied), else TargetAuto.
+func ResolveTarget(saved Target) Target {
+	switch {
+	case target != "":
+		return target
+	case saved != "":
+		return saved
+	}
+	return TargetAuto
+}
+
+// AchievedTarget returns the target an apply reached, from the managed result of ApplySettings.
+func AchievedTarget(managed bool) Target {
+	if managed {
+		return TargetManaged
+	}
+	return TargetUser
+}
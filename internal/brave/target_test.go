@@ -0,0 +1,27 @@
+package brave
+
+import "testing"
+
+func TestResolveTarget(t *testing.T) {
+	defer UseTarget("")
+	if got, err := ParseTarget("Managed"); err != nil || got != TargetManaged {
+		t.Errorf("ParseTarget(Managed) = %q, %v", got, err)
+	}
+	if _, err := ParseTarget("system"); err == nil {
+		t.Error("ParseTarget(system) = nil error")
+	}
+	tests := []struct {
+		chosen, saved, want Target
+	}{
+		{"", "", TargetAuto},
+		{"", TargetUser, TargetUser},
+		{TargetManaged, TargetUser, TargetManaged},
+		{TargetAuto, TargetManaged, TargetAuto},
+	}
+	for _, tt := range tests {
+		UseTarget(tt.chosen)
+		if got := ResolveTarget(tt.saved); got != tt.want {
+			t.Errorf("ResolveTarget(%q) with %q chosen = %q, want %q", tt.saved, tt.chosen, got, tt.want)
+		}
+	}
+}
@@ -1,16 +1,89 @@
 package brave
 
-// Variant is the Brave channel (stable or beta).
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Variant is the Brave channel (stable, beta, dev or nightly).
 type Variant string
 
 const (
-	VariantStable Variant = "stable"
-	VariantBeta   Variant = "beta"
+	VariantStable  Variant = "stable"
+	VariantBeta    Variant = "beta"
+	VariantDev     Variant = "dev"
+	VariantNightly Variant = "nightly"
 )
 
+// channel describes one Brave channel on macOS.
+type channel struct {
+	variant Variant
+	domain  string // defaults domain, managed plist name and the app's CFBundleIdentifier
+	app     string // application and process name; the bundle is <app>.app
+}
+
+// channels is the registry of known channels, stable first. A new channel only needs a row here.
+var channels = []channel{
+	{VariantStable, "com.brave.Browser", "Brave Browser"},
+	{VariantBeta, "com.brave.Browser.beta", "Brave Browser Beta"},
+	{VariantDev, "com.brave.Browser.dev", "Brave Browser Dev"},
+	{VariantNightly, "com.brave.Browser.nightly", "Brave Browser Nightly"},
+}
+
 // currentVariant is the selected Brave channel. Default is stable.
 var currentVariant = VariantStable
 
+// appOverride is the app bundle set with UseApp for the channel appOverrideVariant; "" means the
+// standard locations.
+var (
+	appOverride        string
+	appOverrideVariant Variant
+)
+
+func (v Variant) channel() channel {
+	for _, c := range channels {
+		if c.variant == v {
+			return c
+		}
+	}
+	return channels[0]
+}
+
+// Variants returns every channel, stable first.
+func Variants() []Variant {
+	out := make([]Variant, len(channels))
+	for i, c := range channels {
+		out[i] = c.variant
+	}
+	return out
+}
+
+// VariantForDomain returns the channel whose defaults domain is domain (see Domain).
+func VariantForDomain(domain string) (Variant, bool) {
+	for _, c := range channels {
+		if c.domain == domain {
+			return c.variant, true
+		}
+	}
+	return "", false
+}
+
+// ParseVariant returns the channel named s (e.g. "stable", "nightly").
+func ParseVariant(s string) (Variant, error) {
+	var names []string
+	for _, v := range Variants() {
+		if strings.EqualFold(s, string(v)) {
+			return v, nil
+		}
+		names = append(names, string(v))
+	}
+	return "", fmt.Errorf("unknown channel %q (use %s)", s, strings.Join(names, ", "))
+}
+
 // UseBeta sets whether to target Brave Beta instead of Brave stable.
 // Call this before any other brave package functions (e.g. at startup from a --beta flag).
 func UseBeta(beta bool) {
@@ -21,45 +94,128 @@ func UseBeta(beta bool) {
 	}
 }
 
+// UseVariant selects the channel the package reads and writes. --channel=all calls it once per channel.
+func UseVariant(v Variant) {
+	currentVariant = v
+}
+
+// UseApp targets the app bundle at path (e.g. a copy in ~/Applications or a custom build). The channel is
+// taken from the bundle's CFBundleIdentifier and selected; it is returned.
+func UseApp(path string) (Variant, error) {
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		return "", fmt.Errorf("%s is not an app bundle", path)
+	}
+	id, err := bundleID(path)
+	if err != nil {
+		return "", err
+	}
+	for _, c := range channels {
+		if c.domain == id {
+			abs, err := filepath.Abs(path)
+			if err != nil {
+				return "", err
+			}
+			appOverride, appOverrideVariant = abs, c.variant
+			currentVariant = c.variant
+			return c.variant, nil
+		}
+	}
+	return "", fmt.Errorf("%s: bundle identifier %q is not a known Brave channel", path, id)
+}
+
+// AppOverride returns the app bundle set with UseApp, or "".
+func AppOverride() string {
+	return appOverride
+}
+
+// bundleID returns CFBundleIdentifier from the Info.plist of the app bundle at path.
+func bundleID(path string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultsTimeout)
+	defer cancel()
+	infoPlist := filepath.Join(path, "Contents", "Info.plist")
+	out, err := exec.CommandContext(ctx, "plutil", "-extract", "CFBundleIdentifier", "raw", "-o", "-", infoPlist).Output()
+	if err != nil {
+		return "", fmt.Errorf("read CFBundleIdentifier from %s: %w", infoPlist, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// CurrentVariant returns the selected channel.
+func CurrentVariant() Variant {
+	return currentVariant
+}
+
+// InstalledVariants returns the channels with an installed app, stable first.
+func InstalledVariants() []Variant {
+	var out []Variant
+	for _, v := range Variants() {
+		if v.installedPath() != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// AppName returns the application name of v (e.g. "Brave Browser Nightly").
+func (v Variant) AppName() string {
+	return v.channel().app
+}
+
+// AppPaths returns where the app of v is looked for: /Applications, then ~/Applications.
+func (v Variant) AppPaths() []string {
+	bundle := v.AppName() + ".app"
+	out := []string{filepath.Join("/Applications", bundle)}
+	if home, err := os.UserHomeDir(); err == nil {
+		out = append(out, filepath.Join(home, "Applications", bundle))
+	}
+	return out
+}
+
+// installedPath returns the app path of v: the UseApp bundle for its channel, else the first of
+// AppPaths that exists, or "".
+func (v Variant) installedPath() string {
+	if appOverride != "" && v == appOverrideVariant {
+		return appOverride
+	}
+	for _, p := range v.AppPaths() {
+		if info, err := os.Stat(p); err == nil && info.IsDir() {
+			return p
+		}
+	}
+	return ""
+}
+
+// AppPath returns the path to the application of v, or its /Applications path if it is not installed.
+func (v Variant) AppPath() string {
+	if p := v.installedPath(); p != "" {
+		return p
+	}
+	return v.AppPaths()[0]
+}
+
 // IsBeta returns true if the current variant is Brave Beta.
 func IsBeta() bool {
 	return currentVariant == VariantBeta
 }
 
-// Domain returns the macOS defaults domain for the current variant.
-// Stable: com.brave.Browser, Beta: com.brave.Browser.beta.
+// Domain returns the macOS defaults domain for the current variant (e.g. com.brave.Browser.beta).
 func Domain() string {
-	if currentVariant == VariantBeta {
-		return "com.brave.Browser.beta"
-	}
-	return "com.brave.Browser"
+	return currentVariant.channel().domain
 }
 
-// ManagedPreferencesPath returns the system path for mandatory policies (without .plist).
-// Stable: /Library/Managed Preferences/com.brave.Browser
-// Beta: /Library/Managed Preferences/com.brave.Browser.beta
+// ManagedPreferencesPath returns the system path for mandatory policies (without .plist),
+// e.g. /Library/Managed Preferences/com.brave.Browser.
 func ManagedPreferencesPath() string {
-	if currentVariant == VariantBeta {
-		return "/Library/Managed Preferences/com.brave.Browser.beta"
-	}
-	return "/Library/Managed Preferences/com.brave.Browser"
+	return "/Library/Managed Preferences/" + Domain()
 }
 
 // BraveAppPath returns the path to the Brave application for the current variant.
-// Stable: /Applications/Brave Browser.app
-// Beta: /Applications/Brave Browser Beta.app
 func BraveAppPath() string {
-	if currentVariant == VariantBeta {
-		return "/Applications/Brave Browser Beta.app"
-	}
-	return "/Applications/Brave Browser.app"
+	return currentVariant.AppPath()
 }
 
-// braveProcessName returns the process name for pgrep.
-// Stable: Brave Browser, Beta: Brave Browser Beta
+// braveProcessName returns the process name for pgrep (the app name, e.g. Brave Browser Beta).
 func braveProcessName() string {
-	if currentVariant == VariantBeta {
-		return "Brave Browser Beta"
-	}
-	return "Brave Browser"
+	return currentVariant.AppName()
 }
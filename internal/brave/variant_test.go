@@ -0,0 +1,42 @@
+package brave
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChannelRegistry(t *testing.T) {
+	defer UseVariant(VariantStable)
+	tests := []struct {
+		name    string
+		domain  string
+		process string
+	}{
+		{"stable", "com.brave.Browser", "Brave Browser"},
+		{"Beta", "com.brave.Browser.beta", "Brave Browser Beta"},
+		{"dev", "com.brave.Browser.dev", "Brave Browser Dev"},
+		{"nightly", "com.brave.Browser.nightly", "Brave Browser Nightly"},
+	}
+	for _, tt := range tests {
+		v, err := ParseVariant(tt.name)
+		if err != nil {
+			t.Fatalf("ParseVariant(%q): %v", tt.name, err)
+		}
+		UseVariant(v)
+		if Domain() != tt.domain || braveProcessName() != tt.process {
+			t.Errorf("%s: Domain() = %q, process = %q", tt.name, Domain(), braveProcessName())
+		}
+		if got, ok := VariantForDomain(tt.domain); !ok || got != v {
+			t.Errorf("%s: VariantForDomain(%q) = %q, %v", tt.name, tt.domain, got, ok)
+		}
+		if ManagedPreferencesPath() != "/Library/Managed Preferences/"+tt.domain {
+			t.Errorf("%s: ManagedPreferencesPath() = %q", tt.name, ManagedPreferencesPath())
+		}
+		if paths := v.AppPaths(); paths[0] != "/Applications/"+tt.process+".app" || !strings.HasSuffix(paths[len(paths)-1], "Applications/"+tt.process+".app") {
+			t.Errorf("%s: AppPaths() = %q", tt.name, paths)
+		}
+	}
+	if _, err := ParseVariant("canary"); err == nil || !strings.Contains(err.Error(), "stable, beta, dev, nightly") {
+		t.Errorf("ParseVariant(canary) error = %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+// Package catalog loads the embedded YAML catalogs whose entries have an id (search providers, DoH
+// resolvers) and expands the ${name} variables in their templates.
+package catalog
+
+import (
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Catalog is a YAML file in FS whose entries are listed under one top-level key. It is read and
+// checked on first use and cached.
+type Catalog[T any] struct {
+	FS    fs.FS
+	Path  string           // file in FS, e.g. "dns/resolvers.yaml"
+	Key   string           // top-level key holding the entries, e.g. "resolvers"
+	Name  string           // the catalog in errors, e.g. "DoH catalog"
+	Entry string           // an entry in errors, e.g. "DoH resolver"
+	ID    func(e T) string // the entry's id
+	Check func(e T) error  // checks the entry's required fields; nil accepts every entry
+	list  []T              // cached by Entries
+}
+
+// Entries returns the entries in file order. Ids must be unique.
+func (c *Catalog[T]) Entries() ([]T, error) {
+	if c.list != nil {
+		return c.list, nil
+	}
+	data, err := fs.ReadFile(c.FS, c.Path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", c.Name, err)
+	}
+	var f map[string][]T
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", c.Name, err)
+	}
+	list := f[c.Key]
+	seen := make(map[string]bool)
+	for i, e := range list {
+		id := c.ID(e)
+		if c.Check != nil {
+			if err := c.Check(e); err != nil {
+				if id == "" {
+					return nil, fmt.Errorf("%s %d: %w", c.Entry, i, err)
+				}
+				return nil, fmt.Errorf("%s %q: %w", c.Entry, id, err)
+			}
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("%s %q: duplicate id", c.Entry, id)
+		}
+		seen[id] = true
+	}
+	c.list = list
+	return c.list, nil
+}
+
+// Find returns the entry with the given id, or nil if there is none (or the catalog does not load).
+func (c *Catalog[T]) Find(id string) *T {
+	list, err := c.Entries()
+	if err != nil {
+		return nil
+	}
+	for i := range list {
+		if c.ID(list[i]) == id {
+			return &list[i]
+		}
+	}
+	return nil
+}
+
+// IDs returns the entry ids, sorted.
+func (c *Catalog[T]) IDs() []string {
+	list, _ := c.Entries()
+	ids := make([]string, len(list))
+	for i, e := range list {
+		ids[i] = c.ID(e)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// variableRegex matches variables like ${profile} in catalog templates.
+var variableRegex = regexp.MustCompile(`\$\{([a-z_]+)\}`)
+
+// Expand replaces each ${name} in s with vars[name], trimmed of spaces. It returns the result and the
+// names that have no value, in order of appearance; those are left in place.
+func Expand(s string, vars map[string]string) (string, []string) {
+	var missing []string
+	out := variableRegex.ReplaceAllStringFunc(s, func(m string) string {
+		name := variableRegex.FindStringSubmatch(m)[1]
+		v := strings.TrimSpace(vars[name])
+		if v == "" {
+			missing = append(missing, name)
+			return m
+		}
+		return v
+	})
+	return out, missing
+}
@@ -0,0 +1,66 @@
+package catalog
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+type entry struct {
+	ID   string `yaml:"id"`
+	Name string `yaml:"name"`
+}
+
+func testCatalog(data string) *Catalog[entry] {
+	return &Catalog[entry]{
+		FS:    fstest.MapFS{"c.yaml": {Data: []byte(data)}},
+		Path:  "c.yaml",
+		Key:   "entries",
+		Name:  "test catalog",
+		Entry: "entry",
+		ID:    func(e entry) string { return e.ID },
+		Check: func(e entry) error {
+			if e.ID == "" || e.Name == "" {
+				return fmt.Errorf("id and name are required")
+			}
+			return nil
+		},
+	}
+}
+
+func TestEntries(t *testing.T) {
+	c := testCatalog("entries:\n  - {id: b, name: B}\n  - {id: a, name: A}\n")
+	list, err := c.Entries()
+	if err != nil || len(list) != 2 || list[0].ID != "b" {
+		t.Fatalf("Entries() = %v, %v", list, err)
+	}
+	if e := c.Find("a"); e == nil || e.Name != "A" {
+		t.Errorf("Find(a) = %v", e)
+	}
+	if e := c.Find("z"); e != nil {
+		t.Errorf("Find(z) = %v, want nil", e)
+	}
+	if got := c.IDs(); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("IDs() = %v", got)
+	}
+
+	for data, want := range map[string]string{
+		"entries:\n  - {id: a, name: A}\n  - {id: a, name: B}\n": `entry "a": duplicate id`,
+		"entries:\n  - {name: A}\n":                              "entry 0: id and name are required",
+		"entries:\n  - {id: a}\n":                                `entry "a": id and name are required`,
+		"entries: [":                                             "parse test catalog",
+	} {
+		if _, err := testCatalog(data).Entries(); err == nil || !strings.HasPrefix(err.Error(), want) {
+			t.Errorf("Entries() of %q: err = %v, want %s", data, err, want)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	out, missing := Expand("https://${host}/${path}/x", map[string]string{"host": " example.org "})
+	if out != "https://example.org/${path}/x" || !reflect.DeepEqual(missing, []string{"path"}) {
+		t.Errorf("Expand() = %q, %v", out, missing)
+	}
+}
@@ -0,0 +1,267 @@
+// Package compare builds a key-by-key comparison of two sets of settings: built-in presets,
+// Privacy Guides, the current state of Brave, or a backup plist.
+package compare
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/cowardly/cowardly/internal/brave"
+	"github.com/cowardly/cowardly/internal/config"
+	"github.com/cowardly/cowardly/internal/paths"
+	"github.com/cowardly/cowardly/internal/presets"
+)
+
+// Current is the source name for the current effective state (managed overrides user).
+const Current = "current"
+
+// Source is one side of a comparison.
+type Source struct {
+	Name     string
+	Settings []brave.Setting                        // preset sources
+	lookup   func(key string) (brave.Setting, bool) // state sources (current, backup); nil for presets
+}
+
+// IsState returns true if s reads Brave's state (current or backup) rather than a preset.
+func (s Source) IsState() bool {
+	return s.lookup != nil
+}
+
+// Load resolves spec: a preset id, "privacy-guides" or "privacy-guides:<base>", "current",
+// or a backup (file name from --backups, "backup:<name>", or a path to a .plist) in the backup dir of p.
+func Load(p paths.Paths, spec string) (Source, error) {
+	spec = strings.TrimSpace(spec)
+	switch {
+	case spec == "":
+		return Source{}, fmt.Errorf("empty source")
+	case spec == Current:
+		return Source{Name: "current", lookup: brave.ReadCurrent}, nil
+	case spec == "privacy-guides" || strings.HasPrefix(spec, "privacy-guides:"):
+		base := strings.TrimPrefix(strings.TrimPrefix(spec, "privacy-guides"), ":")
+		if base == "" {
+			base = presets.PrivacyGuidesBasePresetID
+		}
+		settings, err := presets.PrivacyGuidesMerged(base)
+		if err != nil {
+			return Source{}, err
+		}
+		return Source{Name: spec, Settings: settings}, nil
+	case strings.HasPrefix(spec, "backup:") || strings.HasSuffix(spec, ".plist"):
+		return LoadBackup(p, strings.TrimPrefix(spec, "backup:"))
+	}
+	if preset := presets.FindPreset(spec); preset != nil {
+		return Source{Name: preset.ID, Settings: preset.Settings}, nil
+	}
+	return Source{}, fmt.Errorf("unknown source %q (use a preset id, privacy-guides, current, or a backup)", spec)
+}
+
+// LoadBackup reads a backup by name (in the backup dir of p) or path, as Brave would see it (managed
+// overrides user).
+func LoadBackup(p paths.Paths, name string) (Source, error) {
+	path := brave.ResolveBackup(p, name)
+	if path == "" {
+		return Source{}, fmt.Errorf("backup %q not found (use --backups to list them)", name)
+	}
+	settings, err := brave.ReadBackup(path)
+	if err != nil {
+		return Source{}, err
+	}
+	return Source{
+		Name: filepath.Base(path),
+		lookup: func(key string) (brave.Setting, bool) {
+			s, ok := settings[key]
+			return s, ok
+		},
+	}, nil
+}
+
+// Row is one key in a comparison. A or B is nil when the key is not set on that side.
+type Row struct {
+	Key   string
+	Label string // from config.CustomSettings, if any
+	A, B  *brave.Setting
+}
+
+// Result groups the keys of a comparison.
+type Result struct {
+	A, B      string
+	OnlyA     []Row
+	OnlyB     []Row
+	Different []Row
+	Same      int
+}
+
+// Compare compares a and b. Preset sources contribute their own keys; state sources are read
+// for the keys of the other side plus every key the presets and Custom know about.
+func Compare(a, b Source) Result {
+	keys := make(map[string]bool)
+	for _, s := range a.Settings {
+		keys[s.Key] = true
+	}
+	for _, s := range b.Settings {
+		keys[s.Key] = true
+	}
+	if a.IsState() || b.IsState() {
+		for _, k := range knownKeys() {
+			keys[k] = true
+		}
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	labels := customLabels()
+	r := Result{A: a.Name, B: b.Name}
+	for _, k := range sorted {
+		row := Row{Key: k, Label: labels[k], A: a.get(k), B: b.get(k)}
+		switch {
+		case row.A == nil && row.B == nil:
+		case row.B == nil:
+			r.OnlyA = append(r.OnlyA, row)
+		case row.A == nil:
+			r.OnlyB = append(r.OnlyB, row)
+		case brave.ValueString(*row.A) != brave.ValueString(*row.B):
+			r.Different = append(r.Different, row)
+		default:
+			r.Same++
+		}
+	}
+	return r
+}
+
+// get returns the setting for key from s, or nil.
+func (s Source) get(key string) *brave.Setting {
+	if s.lookup != nil {
+		if v, ok := s.lookup(key); ok {
+			return &v
+		}
+		return nil
+	}
+	for i := range s.Settings {
+		if s.Settings[i].Key == key {
+			return &s.Settings[i]
+		}
+	}
+	return nil
+}
+
+// customLabels maps the keys of the Custom settings to their labels.
+func customLabels() map[string]string {
+	labels := make(map[string]string)
+	for _, cs := range config.CustomSettings() {
+		labels[cs.Key] = cs.Label
+	}
+	return labels
+}
+
+// knownKeys returns the keys of all built-in presets and Custom settings.
+func knownKeys() []string {
+	var keys []string
+	for _, cs := range config.CustomSettings() {
+		keys = append(keys, cs.Key)
+	}
+	plist, _ := presets.AllWithError()
+	for _, p := range plist {
+		for _, s := range p.Settings {
+			keys = append(keys, s.Key)
+		}
+	}
+	return keys
+}
+
+// Format renders r as three tables: keys only in A, only in B, and set in both with different values.
+func (r Result) Format() string {
+	var b strings.Builder
+	section := func(title string, rows []Row, cols func(Row) string) {
+		b.WriteString(fmt.Sprintf("%s (%d)\n", title, len(rows)))
+		if len(rows) == 0 {
+			b.WriteString("  -\n\n")
+			return
+		}
+		tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+		for _, row := range rows {
+			fmt.Fprintf(tw, "  %s\t%s\t%s\n", row.Key, row.Label, cols(row))
+		}
+		_ = tw.Flush() // writes to a strings.Builder, which does not fail
+		b.WriteString("\n")
+	}
+	section("Only in "+r.A, r.OnlyA, func(row Row) string { return FormatValue(*row.A) })
+	section("Only in "+r.B, r.OnlyB, func(row Row) string { return FormatValue(*row.B) })
+	section(fmt.Sprintf("Different (%s -> %s)", r.A, r.B), r.Different, func(row Row) string {
+		return FormatValue(*row.A) + "\t-> " + FormatValue(*row.B)
+	})
+	b.WriteString(fmt.Sprintf("Same value in both: %d key(s)", r.Same))
+	return b.String()
+}
+
+// FormatValue returns a display form of s's value: true/false for bools, named levels for integers.
+func FormatValue(s brave.Setting) string {
+	switch s.Type {
+	case brave.TypeBool:
+		if v, ok := s.Value.(bool); ok && v {
+			return "true"
+		}
+		return "false"
+	case brave.TypeString:
+		return fmt.Sprintf("%q", s.Value)
+	}
+	return presets.ValueNames().Label(s.Key, brave.ValueString(s))
+}
+
+// BackupDiff is what restoring a backup would change, plist by plist.
+type BackupDiff struct {
+	Name    string
+	Changes []brave.BackupChange
+}
+
+// DiffBackup compares the backup name (file name from --backups in the backup dir of p, or a path) with
+// the current user and managed preferences.
+func DiffBackup(p paths.Paths, name string) (BackupDiff, error) {
+	path := brave.ResolveBackup(p, name)
+	if path == "" {
+		return BackupDiff{}, fmt.Errorf("backup %q not found (use --backups to list them)", name)
+	}
+	changes, err := brave.DiffBackup(path)
+	if err != nil {
+		return BackupDiff{}, err
+	}
+	return BackupDiff{Name: filepath.Base(path), Changes: changes}, nil
+}
+
+// Format lists the changes per plist as --diff does ("Key: current -> backup"), with Custom labels where known.
+func (d BackupDiff) Format() string {
+	if len(d.Changes) == 0 {
+		return fmt.Sprintf("Restoring %s changes nothing: it matches the current preferences.", d.Name)
+	}
+	labels := customLabels()
+	names := presets.ValueNames()
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Restoring %s would change:\n", d.Name))
+	for _, scope := range []brave.Target{brave.TargetUser, brave.TargetManaged} {
+		var lines []string
+		for _, c := range d.Changes {
+			if c.Scope != scope {
+				continue
+			}
+			key := c.Key
+			if l := labels[c.Key]; l != "" {
+				key += " (" + l + ")"
+			}
+			lines = append(lines, fmt.Sprintf("  %s: %s", key, c.Values(names)))
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		title := "User preferences"
+		if scope == brave.TargetManaged {
+			title = "Managed plist (admin dialog)"
+		}
+		b.WriteString(fmt.Sprintf("\n%s (%d)\n%s\n", title, len(lines), strings.Join(lines, "\n")))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,113 @@
+package compare
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cowardly/cowardly/internal/brave"
+	"github.com/cowardly/cowardly/internal/paths"
+)
+
+func TestCompare(t *testing.T) {
+	a := Source{Name: "a", Settings: []brave.Setting{
+		{Key: "MetricsReportingEnabled", Value: false, Type: brave.TypeBool},
+		{Key: "BraveRewardsDisabled", Value: true, Type: brave.TypeBool},
+		{Key: "DefaultBraveFingerprintingV2Setting", Value: 3, Type: brave.TypeInteger},
+		{Key: "OnlyA", Value: "x", Type: brave.TypeString},
+	}}
+	b := Source{Name: "b", Settings: []brave.Setting{
+		{Key: "MetricsReportingEnabled", Value: false, Type: brave.TypeBool},
+		{Key: "BraveRewardsDisabled", Value: false, Type: brave.TypeBool},
+		{Key: "DefaultBraveFingerprintingV2Setting", Value: 2, Type: brave.TypeInteger},
+		{Key: "OnlyB", Value: []interface{}{"a"}, Type: brave.TypeList},
+	}}
+	r := Compare(a, b)
+	if len(r.OnlyA) != 1 || r.OnlyA[0].Key != "OnlyA" {
+		t.Errorf("OnlyA = %+v", r.OnlyA)
+	}
+	if len(r.OnlyB) != 1 || r.OnlyB[0].Key != "OnlyB" {
+		t.Errorf("OnlyB = %+v", r.OnlyB)
+	}
+	if len(r.Different) != 2 || r.Same != 1 {
+		t.Errorf("Different = %+v, Same = %d", r.Different, r.Same)
+	}
+	for _, row := range r.Different {
+		if row.Key == "BraveRewardsDisabled" && row.Label == "" {
+			t.Error("expected a label from config.CustomSettings for BraveRewardsDisabled")
+		}
+	}
+	out := r.Format()
+	for _, want := range []string{"Only in a (1)", "Only in b (1)", "Different (a -> b) (2)", "true", "-> false", "Same value in both: 1"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Format() missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestCompareStateSource(t *testing.T) {
+	state := map[string]brave.Setting{
+		"MetricsReportingEnabled": brave.SettingFromDefaults("MetricsReportingEnabled", "0"),
+		"BraveRewardsDisabled":    brave.SettingFromDefaults("BraveRewardsDisabled", "0"),
+	}
+	cur := Source{Name: "current", lookup: func(k string) (brave.Setting, bool) {
+		s, ok := state[k]
+		return s, ok
+	}}
+	p := Source{Name: "p", Settings: []brave.Setting{
+		{Key: "MetricsReportingEnabled", Value: false, Type: brave.TypeBool},
+		{Key: "BraveRewardsDisabled", Value: true, Type: brave.TypeBool},
+	}}
+	r := Compare(p, cur)
+	if len(r.Different) != 1 || r.Different[0].Key != "BraveRewardsDisabled" || r.Same != 1 {
+		t.Errorf("unexpected result: %+v", r)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	p := paths.Paths{State: t.TempDir()}
+	src, err := Load(p, "quick")
+	if err != nil || len(src.Settings) == 0 || src.IsState() {
+		t.Errorf("Load(quick) = %+v, %v", src, err)
+	}
+	if _, err := Load(p, "privacy-guides:quick"); err != nil {
+		t.Errorf("Load(privacy-guides:quick): %v", err)
+	}
+	if src, err := Load(p, Current); err != nil || !src.IsState() {
+		t.Errorf("Load(current) = %+v, %v", src, err)
+	}
+	if _, err := Load(p, "no-such-preset"); err == nil {
+		t.Error("expected error for unknown source")
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	cases := map[string]brave.Setting{
+		"true":         {Key: "X", Value: true, Type: brave.TypeBool},
+		`"ask"`:        {Key: "X", Value: "ask", Type: brave.TypeString},
+		"3 (standard)": {Key: "DefaultBraveFingerprintingV2Setting", Value: 3, Type: brave.TypeInteger},
+	}
+	for want, s := range cases {
+		if got := FormatValue(s); got != want {
+			t.Errorf("FormatValue(%+v) = %q, want %q", s, got, want)
+		}
+	}
+}
+
+func TestBackupDiffFormat(t *testing.T) {
+	on := brave.Setting{Key: "BraveRewardsDisabled", Value: true, Type: brave.TypeBool}
+	off := brave.Setting{Key: "BraveRewardsDisabled", Value: false, Type: brave.TypeBool}
+	tor := brave.Setting{Key: "TorDisabled", Value: true, Type: brave.TypeBool}
+	d := BackupDiff{Name: "2026-03-20T11-00-00", Changes: []brave.BackupChange{
+		{Scope: brave.TargetUser, Key: on.Key, Current: &off, Backup: &on},
+		{Scope: brave.TargetManaged, Key: tor.Key, Current: &tor},
+	}}
+	out := d.Format()
+	for _, want := range []string{"Restoring 2026-03-20T11-00-00 would change:", "User preferences (1)", "  BraveRewardsDisabled (", "): 0 -> 1", "Managed plist (admin dialog) (1)", "TorDisabled", "1 -> (not set)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Format() missing %q:\n%s", want, out)
+		}
+	}
+	if out := (BackupDiff{Name: "b"}).Format(); !strings.Contains(out, "changes nothing") {
+		t.Errorf("Format() without changes = %q", out)
+	}
+}
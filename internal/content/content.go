@@ -0,0 +1,284 @@
+// Package content compiles per-site content settings (cookies, JavaScript, pop-ups, notifications,
+// images) from the `content:` YAML section into Chromium's Default*Setting and *ForUrls policies.
+package content
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/cowardly/cowardly/internal/brave"
+)
+
+// Action is what a rule does for the sites it lists.
+type Action string
+
+const (
+	ActionAllow       Action = "allow"
+	ActionBlock       Action = "block"
+	ActionSessionOnly Action = "session_only" // cookies only: cleared when the browser closes
+	ActionAsk         Action = "ask"          // defaults only (e.g. notifications)
+)
+
+// Type describes one content setting and the policies it compiles to.
+type Type struct {
+	Name       string            // YAML key, e.g. "cookies"
+	Label      string            // shown in the TUI
+	DefaultKey string            // Default*Setting policy
+	Defaults   map[Action]int    // allowed values for `default`
+	Lists      map[Action]string // per-URL list policies
+}
+
+// Actions returns the list actions supported by t, in display order.
+func (t Type) Actions() []Action {
+	var out []Action
+	for _, a := range []Action{ActionAllow, ActionBlock, ActionSessionOnly} {
+		if _, ok := t.Lists[a]; ok {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// Types lists the supported content settings, in display and write order.
+var Types = []Type{
+	{
+		Name: "cookies", Label: "Cookies", DefaultKey: "DefaultCookiesSetting",
+		Defaults: map[Action]int{ActionAllow: 1, ActionBlock: 2, ActionSessionOnly: 4},
+		Lists: map[Action]string{
+			ActionAllow:       "CookiesAllowedForUrls",
+			ActionBlock:       "CookiesBlockedForUrls",
+			ActionSessionOnly: "CookiesSessionOnlyForUrls",
+		},
+	},
+	{
+		Name: "javascript", Label: "JavaScript", DefaultKey: "DefaultJavaScriptSetting",
+		Defaults: map[Action]int{ActionAllow: 1, ActionBlock: 2},
+		Lists: map[Action]string{
+			ActionAllow: "JavaScriptAllowedForUrls",
+			ActionBlock: "JavaScriptBlockedForUrls",
+		},
+	},
+	{
+		Name: "popups", Label: "Pop-ups and redirects", DefaultKey: "DefaultPopupsSetting",
+		Defaults: map[Action]int{ActionAllow: 1, ActionBlock: 2},
+		Lists: map[Action]string{
+			ActionAllow: "PopupsAllowedForUrls",
+			ActionBlock: "PopupsBlockedForUrls",
+		},
+	},
+	{
+		Name: "notifications", Label: "Notifications", DefaultKey: "DefaultNotificationsSetting",
+		Defaults: map[Action]int{ActionAllow: 1, ActionBlock: 2, ActionAsk: 3},
+		Lists: map[Action]string{
+			ActionAllow: "NotificationsAllowedForUrls",
+			ActionBlock: "NotificationsBlockedForUrls",
+		},
+	},
+	{
+		Name: "images", Label: "Images", DefaultKey: "DefaultImagesSetting",
+		Defaults: map[Action]int{ActionAllow: 1, ActionBlock: 2},
+		Lists: map[Action]string{
+			ActionAllow: "ImagesAllowedForUrls",
+			ActionBlock: "ImagesBlockedForUrls",
+		},
+	},
+}
+
+// FindType returns the content type with the given YAML name, or nil.
+func FindType(name string) *Type {
+	for i := range Types {
+		if Types[i].Name == name {
+			return &Types[i]
+		}
+	}
+	return nil
+}
+
+// Keys returns all policy keys this package can write.
+func Keys() []string {
+	var keys []string
+	for _, t := range Types {
+		keys = append(keys, t.DefaultKey)
+		for _, a := range t.Actions() {
+			keys = append(keys, t.Lists[a])
+		}
+	}
+	return keys
+}
+
+// Rules is the YAML block for one content type.
+type Rules struct {
+	Default     Action   `yaml:"default,omitempty"`
+	Allow       []string `yaml:"allow,omitempty"`
+	Block       []string `yaml:"block,omitempty"`
+	SessionOnly []string `yaml:"session_only,omitempty"`
+}
+
+// List returns the patterns for action a.
+func (r Rules) List(a Action) []string {
+	switch a {
+	case ActionAllow:
+		return r.Allow
+	case ActionBlock:
+		return r.Block
+	case ActionSessionOnly:
+		return r.SessionOnly
+	}
+	return nil
+}
+
+// SetList replaces the patterns for action a.
+func (r *Rules) SetList(a Action, patterns []string) {
+	switch a {
+	case ActionAllow:
+		r.Allow = patterns
+	case ActionBlock:
+		r.Block = patterns
+	case ActionSessionOnly:
+		r.SessionOnly = patterns
+	}
+}
+
+// IsEmpty returns true if r sets nothing.
+func (r Rules) IsEmpty() bool {
+	return r.Default == "" && len(r.Allow) == 0 && len(r.Block) == 0 && len(r.SessionOnly) == 0
+}
+
+// Config is the `content:` section: content type name -> rules.
+type Config map[string]Rules
+
+// IsEmpty returns true if no content type has rules.
+func (c Config) IsEmpty() bool {
+	for _, r := range c {
+		if !r.IsEmpty() {
+			return false
+		}
+	}
+	return true
+}
+
+// Clone returns a copy of c whose rules can be edited without changing c.
+func (c Config) Clone() Config {
+	out := make(Config, len(c))
+	for name, r := range c {
+		out[name] = Rules{
+			Default:     r.Default,
+			Allow:       append([]string(nil), r.Allow...),
+			Block:       append([]string(nil), r.Block...),
+			SessionOnly: append([]string(nil), r.SessionOnly...),
+		}
+	}
+	return out
+}
+
+// Validate checks type names, defaults, supported actions and URL patterns.
+// A pattern may not appear in two lists of the same type.
+func (c Config) Validate() error {
+	names := make([]string, 0, len(c))
+	for name := range c {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		r := c[name]
+		t := FindType(name)
+		if t == nil {
+			known := make([]string, len(Types))
+			for i, kt := range Types {
+				known[i] = kt.Name
+			}
+			return fmt.Errorf("content: unknown type %q (known: %s)", name, strings.Join(known, ", "))
+		}
+		if r.Default != "" {
+			if _, ok := t.Defaults[r.Default]; !ok {
+				return fmt.Errorf("content.%s: default %q is not supported", name, r.Default)
+			}
+		}
+		seen := make(map[string]Action)
+		for _, a := range []Action{ActionAllow, ActionBlock, ActionSessionOnly} {
+			list := r.List(a)
+			if len(list) == 0 {
+				continue
+			}
+			if _, ok := t.Lists[a]; !ok {
+				return fmt.Errorf("content.%s: %s is not supported", name, a)
+			}
+			for _, p := range list {
+				if err := ValidatePattern(p); err != nil {
+					return fmt.Errorf("content.%s.%s: %w", name, a, err)
+				}
+				if prev, dup := seen[p]; dup {
+					return fmt.Errorf("content.%s: %q is in both %s and %s", name, p, prev, a)
+				}
+				seen[p] = a
+			}
+		}
+	}
+	return nil
+}
+
+// Settings compiles c into policies, in Types order. An invalid config yields no settings.
+func (c Config) Settings() []brave.Setting {
+	if c.IsEmpty() || c.Validate() != nil {
+		return nil
+	}
+	var out []brave.Setting
+	for _, t := range Types {
+		r, ok := c[t.Name]
+		if !ok {
+			continue
+		}
+		if r.Default != "" {
+			out = append(out, brave.Setting{Key: t.DefaultKey, Value: t.Defaults[r.Default], Type: brave.TypeInteger})
+		}
+		for _, a := range t.Actions() {
+			list := r.List(a)
+			if len(list) == 0 {
+				continue
+			}
+			l := make([]interface{}, len(list))
+			for i, p := range list {
+				l[i] = p
+			}
+			out = append(out, brave.Setting{Key: t.Lists[a], Value: l, Type: brave.TypeList})
+		}
+	}
+	return out
+}
+
+// ValidatePattern checks a Chromium content settings pattern such as "https://sso.example.com",
+// "[*.]example.com", "example.com:8443" or "*".
+func ValidatePattern(p string) error {
+	if p == "" || strings.ContainsAny(p, " \t,") {
+		return fmt.Errorf("invalid site pattern %q", p)
+	}
+	if p == "*" {
+		return nil
+	}
+	rest := p
+	if i := strings.Index(rest, "://"); i >= 0 {
+		switch rest[:i] {
+		case "http", "https", "*":
+		case "file":
+			return nil
+		default:
+			return fmt.Errorf("site pattern %q: unsupported scheme %q", p, rest[:i])
+		}
+		rest = rest[i+3:]
+	}
+	rest = strings.TrimPrefix(rest, "[*.]")
+	hostPort := rest
+	if i := strings.IndexAny(rest, "/"); i >= 0 {
+		hostPort = rest[:i]
+	}
+	if strings.Contains(hostPort, "*") && hostPort != "*" {
+		return fmt.Errorf("site pattern %q: use [*.]domain for subdomains", p)
+	}
+	u, err := url.Parse("https://" + hostPort)
+	if err != nil || u.Hostname() == "" {
+		return fmt.Errorf("invalid site pattern %q", p)
+	}
+	return nil
+}
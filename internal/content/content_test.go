@@ -0,0 +1,77 @@
+package content
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestSettings(t *testing.T) {
+	var c Config
+	src := `
+cookies:
+  default: block
+  allow: ["[*.]sso.example.com", "https://login.example.com"]
+  session_only: [news.example.com]
+javascript:
+  block: ["ads.example.net"]
+`
+	if err := yaml.Unmarshal([]byte(src), &c); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	got := make(map[string]interface{})
+	var order []string
+	for _, s := range c.Settings() {
+		got[s.Key] = s.Value
+		order = append(order, s.Key)
+	}
+	want := []string{"DefaultCookiesSetting", "CookiesAllowedForUrls", "CookiesSessionOnlyForUrls", "JavaScriptBlockedForUrls"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("keys = %v, want %v", order, want)
+	}
+	if got["DefaultCookiesSetting"] != 2 {
+		t.Errorf("DefaultCookiesSetting = %v, want 2", got["DefaultCookiesSetting"])
+	}
+	if l := got["CookiesAllowedForUrls"].([]interface{}); len(l) != 2 || l[0] != "[*.]sso.example.com" {
+		t.Errorf("CookiesAllowedForUrls = %v", l)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	invalid := []Config{
+		{"location": {Allow: []string{"example.com"}}},
+		{"javascript": {SessionOnly: []string{"example.com"}}},
+		{"javascript": {Default: ActionSessionOnly}},
+		{"cookies": {Allow: []string{"example.com"}, Block: []string{"example.com"}}},
+		{"cookies": {Allow: []string{"exa mple.com"}}},
+		{"cookies": {Allow: []string{"ftp://example.com"}}},
+		{"cookies": {Allow: []string{"*.example.com"}}},
+	}
+	for _, c := range invalid {
+		if err := c.Validate(); err == nil {
+			t.Errorf("Validate(%v): expected error", c)
+		}
+		if s := c.Settings(); s != nil {
+			t.Errorf("Settings(%v) = %v, want nil", c, s)
+		}
+	}
+	valid := Config{
+		"notifications": {Default: ActionAsk, Allow: []string{"https://chat.example.com:8443"}},
+		"popups":        {Allow: []string{"*"}},
+		"images":        {Block: []string{"http://[*.]example.org/path"}},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+}
+
+func TestKeys(t *testing.T) {
+	keys := Keys()
+	if len(keys) != 16 {
+		t.Errorf("len(Keys) = %d, want 16: %v", len(keys), keys)
+	}
+}
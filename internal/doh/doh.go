@@ -0,0 +1,239 @@
+// Package doh provides the embedded DNS-over-HTTPS resolver catalog and expands a resolver
+// selection into the DnsOverHttpsMode and DnsOverHttpsTemplates policy settings.
+package doh
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/cowardly/cowardly/configs"
+	"github.com/cowardly/cowardly/internal/brave"
+	"github.com/cowardly/cowardly/internal/catalog"
+)
+
+// Policy keys written for DNS over HTTPS.
+const (
+	KeyMode      = "DnsOverHttpsMode"
+	KeyTemplates = "DnsOverHttpsTemplates"
+)
+
+// Keys lists all policy keys managed by this package, in write order.
+var Keys = []string{KeyMode, KeyTemplates}
+
+// Mode is a DnsOverHttpsMode value.
+type Mode string
+
+const (
+	ModeOff       Mode = "off"
+	ModeAutomatic Mode = "automatic"
+	ModeSecure    Mode = "secure"
+)
+
+// ParseMode parses a DnsOverHttpsMode value.
+func ParseMode(s string) (Mode, error) {
+	switch m := Mode(strings.ToLower(strings.TrimSpace(s))); m {
+	case ModeOff, ModeAutomatic, ModeSecure:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown DoH mode %q (use off, automatic or secure)", s)
+}
+
+// expressionRegex matches the URI template expressions Chromium accepts in a DoH template.
+var expressionRegex = regexp.MustCompile(`\{[?&]?dns\}`)
+
+// Variable is a value the user must supply for a resolver (e.g. a NextDNS profile ID).
+type Variable struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Pattern     string `yaml:"pattern"` // optional regexp the value must match
+}
+
+// Resolver is one entry in the resolver catalog.
+type Resolver struct {
+	ID          string     `yaml:"id"`
+	Name        string     `yaml:"name"`
+	Description string     `yaml:"description"`
+	Templates   string     `yaml:"templates"`
+	Variables   []Variable `yaml:"variables"`
+}
+
+// NeedsVariables returns true if the resolver cannot be used without user-supplied values.
+func (r Resolver) NeedsVariables() bool {
+	return len(r.Variables) > 0
+}
+
+// resolvers is the embedded resolver catalog, configs/dns/resolvers.yaml.
+var resolvers = &catalog.Catalog[Resolver]{
+	FS:    configs.DNSFS,
+	Path:  "dns/resolvers.yaml",
+	Key:   "resolvers",
+	Name:  "DoH catalog",
+	Entry: "DoH resolver",
+	ID:    func(r Resolver) string { return r.ID },
+	Check: func(r Resolver) error {
+		if r.ID == "" || r.Name == "" || r.Templates == "" {
+			return fmt.Errorf("id, name and templates are required")
+		}
+		for _, v := range r.Variables {
+			if v.Pattern != "" {
+				if _, err := regexp.Compile(v.Pattern); err != nil {
+					return fmt.Errorf("variable %q: %w", v.Name, err)
+				}
+			}
+		}
+		return nil
+	},
+}
+
+// Catalog returns the embedded resolvers in file order.
+func Catalog() ([]Resolver, error) {
+	return resolvers.Entries()
+}
+
+// Find returns the resolver with the given id, or nil if not in the catalog.
+func Find(id string) *Resolver {
+	return resolvers.Find(id)
+}
+
+// IDs returns the catalog resolver ids, sorted.
+func IDs() []string {
+	return resolvers.IDs()
+}
+
+// Selection is the saved DoH choice: a mode and, unless the mode is off, an optional resolver.
+type Selection struct {
+	Mode     Mode              `yaml:"mode"`
+	Resolver string            `yaml:"resolver,omitempty"`
+	Vars     map[string]string `yaml:"vars,omitempty"`
+}
+
+// IsEmpty returns true if nothing is selected.
+func (s Selection) IsEmpty() bool {
+	return s.Mode == "" && s.Resolver == ""
+}
+
+// Templates expands the selected resolver into a validated DnsOverHttpsTemplates value.
+// Returns "" when no resolver is selected or the mode is off.
+func (s Selection) Templates() (string, error) {
+	if _, err := ParseMode(string(s.Mode)); err != nil {
+		return "", err
+	}
+	if s.Mode == ModeOff || s.Resolver == "" {
+		if s.Mode == ModeSecure {
+			return "", fmt.Errorf("secure mode needs a resolver (known: %s)", strings.Join(IDs(), ", "))
+		}
+		return "", nil
+	}
+	r := Find(s.Resolver)
+	if r == nil {
+		return "", fmt.Errorf("unknown DoH resolver %q (known: %s)", s.Resolver, strings.Join(IDs(), ", "))
+	}
+	for name := range s.Vars {
+		if r.variable(name) == nil {
+			return "", fmt.Errorf("DoH resolver %q: unknown variable %q", r.ID, name)
+		}
+	}
+	templates, err := r.expand(s.Vars)
+	if err != nil {
+		return "", err
+	}
+	if err := ValidateTemplates(templates); err != nil {
+		return "", fmt.Errorf("DoH resolver %q: %w", r.ID, err)
+	}
+	return templates, nil
+}
+
+// Validate checks the mode, resolver and variables.
+func (s Selection) Validate() error {
+	_, err := s.Templates()
+	return err
+}
+
+// Settings returns DnsOverHttpsMode and, when a resolver is selected, DnsOverHttpsTemplates.
+// An invalid selection yields no settings; Validate before saving one.
+func (s Selection) Settings() []brave.Setting {
+	if s.IsEmpty() {
+		return nil
+	}
+	templates, err := s.Templates()
+	if err != nil {
+		return nil
+	}
+	settings := []brave.Setting{{Key: KeyMode, Value: string(s.Mode), Type: brave.TypeString}}
+	if templates != "" {
+		settings = append(settings, brave.Setting{Key: KeyTemplates, Value: templates, Type: brave.TypeString})
+	}
+	return settings
+}
+
+// Describe returns a short label such as "NextDNS (secure)".
+func (s Selection) Describe() string {
+	if s.Mode == ModeOff || s.Resolver == "" {
+		return string(s.Mode)
+	}
+	name := s.Resolver
+	if r := Find(s.Resolver); r != nil {
+		name = r.Name
+	}
+	return fmt.Sprintf("%s (%s)", name, s.Mode)
+}
+
+func (r Resolver) variable(name string) *Variable {
+	for i := range r.Variables {
+		if r.Variables[i].Name == name {
+			return &r.Variables[i]
+		}
+	}
+	return nil
+}
+
+// expand replaces ${name} in the resolver templates with vars[name].
+// Missing values and values that do not match the variable pattern are an error.
+func (r Resolver) expand(vars map[string]string) (string, error) {
+	out, missing := catalog.Expand(r.Templates, vars)
+	var errs []string
+	for _, name := range missing {
+		errs = append(errs, "missing variable "+name)
+	}
+	for _, def := range r.Variables {
+		v := strings.TrimSpace(vars[def.Name])
+		if v != "" && def.Pattern != "" && !regexp.MustCompile(def.Pattern).MatchString(v) {
+			errs = append(errs, fmt.Sprintf("invalid %s %q", def.Name, v))
+		}
+	}
+	if len(errs) > 0 {
+		return "", fmt.Errorf("DoH resolver %q: %s", r.ID, strings.Join(errs, ", "))
+	}
+	return out, nil
+}
+
+// ValidateTemplates checks a DnsOverHttpsTemplates value: one or more space-separated https
+// URI templates whose only expression is {?dns} (or {dns}).
+func ValidateTemplates(templates string) error {
+	fields := strings.Fields(templates)
+	if len(fields) == 0 {
+		return fmt.Errorf("DoH template is empty")
+	}
+	for _, t := range fields {
+		rest := expressionRegex.ReplaceAllString(t, "")
+		if strings.ContainsAny(rest, "{}") {
+			return fmt.Errorf("DoH template %q: only the {?dns} variable is allowed", t)
+		}
+		u, err := url.Parse(rest)
+		if err != nil {
+			return fmt.Errorf("DoH template %q: %w", t, err)
+		}
+		if u.Scheme != "https" {
+			return fmt.Errorf("DoH template %q must use https", t)
+		}
+		if u.Host == "" || u.Hostname() == "" {
+			return fmt.Errorf("DoH template %q has no host", t)
+		}
+		if u.User != nil {
+			return fmt.Errorf("DoH template %q must not contain credentials", t)
+		}
+	}
+	return nil
+}
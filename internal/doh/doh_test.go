@@ -0,0 +1,102 @@
+package doh
+
+import "testing"
+
+func TestCatalog(t *testing.T) {
+	list, err := Catalog()
+	if err != nil {
+		t.Fatalf("Catalog: %v", err)
+	}
+	for _, id := range []string{"quad9", "mullvad", "cloudflare", "nextdns", "custom"} {
+		if Find(id) == nil {
+			t.Errorf("expected resolver %q in catalog", id)
+		}
+	}
+	for _, r := range list {
+		if r.NeedsVariables() {
+			continue
+		}
+		if err := ValidateTemplates(r.Templates); err != nil {
+			t.Errorf("resolver %q: %v", r.ID, err)
+		}
+	}
+}
+
+func TestValidateTemplates(t *testing.T) {
+	valid := []string{
+		"https://dns.quad9.net/dns-query",
+		"https://doh.example.com/dns-query{?dns}",
+		"https://a.example/dns-query https://b.example/dns-query{?dns}",
+	}
+	for _, v := range valid {
+		if err := ValidateTemplates(v); err != nil {
+			t.Errorf("ValidateTemplates(%q): %v", v, err)
+		}
+	}
+	invalid := []string{
+		"",
+		"http://doh.example.com/dns-query",
+		"https:///dns-query",
+		"https://doh.example.com/{name}",
+		"https://doh.example.com/dns-query{?dns",
+		"https://user:pw@doh.example.com/dns-query",
+		"doh.example.com/dns-query",
+	}
+	for _, v := range invalid {
+		if err := ValidateTemplates(v); err == nil {
+			t.Errorf("ValidateTemplates(%q): expected error", v)
+		}
+	}
+}
+
+func TestSelectionSettings(t *testing.T) {
+	s := Selection{Mode: ModeSecure, Resolver: "nextdns", Vars: map[string]string{"profile": "abc123"}}
+	if err := s.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	byKey := make(map[string]interface{})
+	for _, st := range s.Settings() {
+		byKey[st.Key] = st.Value
+	}
+	if byKey[KeyMode] != "secure" {
+		t.Errorf("%s = %v, want secure", KeyMode, byKey[KeyMode])
+	}
+	if byKey[KeyTemplates] != "https://dns.nextdns.io/abc123" {
+		t.Errorf("%s = %v", KeyTemplates, byKey[KeyTemplates])
+	}
+
+	off := Selection{Mode: ModeOff, Resolver: "quad9"}
+	if got := off.Settings(); len(got) != 1 || got[0].Value != "off" {
+		t.Errorf("off settings = %v, want only mode", got)
+	}
+}
+
+func TestSelectionValidate(t *testing.T) {
+	invalid := []Selection{
+		{Mode: ModeSecure},
+		{Mode: "strict", Resolver: "quad9"},
+		{Mode: ModeSecure, Resolver: "unknown"},
+		{Mode: ModeSecure, Resolver: "nextdns"},
+		{Mode: ModeSecure, Resolver: "nextdns", Vars: map[string]string{"profile": "abc/../x"}},
+		{Mode: ModeSecure, Resolver: "quad9", Vars: map[string]string{"profile": "x"}},
+		{Mode: ModeSecure, Resolver: "custom", Vars: map[string]string{"template": "http://doh.example.com"}},
+	}
+	for _, s := range invalid {
+		if err := s.Validate(); err == nil {
+			t.Errorf("Validate(%+v): expected error", s)
+		}
+		if got := s.Settings(); got != nil {
+			t.Errorf("Settings(%+v) = %v, want nil", s, got)
+		}
+	}
+	ok := []Selection{
+		{Mode: ModeAutomatic},
+		{Mode: ModeAutomatic, Resolver: "mullvad"},
+		{Mode: ModeSecure, Resolver: "custom", Vars: map[string]string{"template": "https://doh.example.com/dns-query{?dns}"}},
+	}
+	for _, s := range ok {
+		if err := s.Validate(); err != nil {
+			t.Errorf("Validate(%+v): %v", s, err)
+		}
+	}
+}
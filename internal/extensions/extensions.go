@@ -0,0 +1,253 @@
+// Package extensions manages extension install policies (force-install, block, allow)
+// and resolves extension names via the embedded catalog in configs/extensions/catalog.yaml.
+package extensions
+
+import (
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cowardly/cowardly/configs"
+	"github.com/cowardly/cowardly/internal/brave"
+	"gopkg.in/yaml.v3"
+)
+
+// Policy keys written for extension management.
+const (
+	KeyForcelist = "ExtensionInstallForcelist"
+	KeyBlocklist = "ExtensionInstallBlocklist"
+	KeyAllowlist = "ExtensionInstallAllowlist"
+	KeySettings  = "ExtensionSettings"
+)
+
+// Keys lists all policy keys managed by this package.
+var Keys = []string{KeyForcelist, KeyBlocklist, KeyAllowlist, KeySettings}
+
+// UpdateURL is the Chrome Web Store update URL used for force-installed extensions.
+const UpdateURL = "https://clients2.google.com/service/update2/crx"
+
+// Wildcard matches all extensions (e.g. `block *` blocks everything not allowed or forced).
+const Wildcard = "*"
+
+// idRegex matches Chrome Web Store extension IDs (32 characters a-p).
+var idRegex = regexp.MustCompile(`^[a-p]{32}$`)
+
+// Mode is how an extension is managed.
+type Mode string
+
+const (
+	ModeNone  Mode = ""
+	ModeForce Mode = "force"
+	ModeBlock Mode = "block"
+	ModeAllow Mode = "allow"
+)
+
+// ParseMode returns the Mode for s (force, block, allow).
+func ParseMode(s string) (Mode, error) {
+	switch Mode(strings.ToLower(s)) {
+	case ModeForce:
+		return ModeForce, nil
+	case ModeBlock:
+		return ModeBlock, nil
+	case ModeAllow:
+		return ModeAllow, nil
+	}
+	return ModeNone, fmt.Errorf("unknown mode %q (use force, block or allow)", s)
+}
+
+// Extension is one entry in the extension catalog.
+type Extension struct {
+	ID      string   `yaml:"id"`
+	Name    string   `yaml:"name"`
+	Aliases []string `yaml:"aliases"`
+}
+
+// catalogFile is the on-disk shape of configs/extensions/catalog.yaml.
+type catalogFile struct {
+	Extensions []Extension `yaml:"extensions"`
+}
+
+var cachedCatalog []Extension
+
+// Catalog returns the embedded extension catalog in file order.
+func Catalog() ([]Extension, error) {
+	if cachedCatalog != nil {
+		return cachedCatalog, nil
+	}
+	data, err := fs.ReadFile(configs.ExtensionsFS, "extensions/catalog.yaml")
+	if err != nil {
+		return nil, fmt.Errorf("read extension catalog: %w", err)
+	}
+	var f catalogFile
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse extension catalog: %w", err)
+	}
+	for i, e := range f.Extensions {
+		if !idRegex.MatchString(e.ID) {
+			return nil, fmt.Errorf("extension %d %q: invalid id %q", i, e.Name, e.ID)
+		}
+	}
+	cachedCatalog = f.Extensions
+	return cachedCatalog, nil
+}
+
+// Resolve returns the extension ID for an ID, catalog name or alias (case-insensitive).
+// The wildcard "*" is returned as-is.
+func Resolve(idOrName string) (string, error) {
+	s := strings.TrimSpace(idOrName)
+	if s == Wildcard {
+		return Wildcard, nil
+	}
+	if idRegex.MatchString(s) {
+		return s, nil
+	}
+	list, err := Catalog()
+	if err != nil {
+		return "", err
+	}
+	for _, e := range list {
+		if strings.EqualFold(e.Name, s) {
+			return e.ID, nil
+		}
+		for _, a := range e.Aliases {
+			if strings.EqualFold(a, s) {
+				return e.ID, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("unknown extension %q (use a 32-character extension ID or a catalog name; see `cowardly extensions catalog`)", s)
+}
+
+// NameFor returns the catalog name for id, or "" if it is not in the catalog.
+func NameFor(id string) string {
+	if id == Wildcard {
+		return "All extensions"
+	}
+	list, _ := Catalog()
+	for _, e := range list {
+		if e.ID == id {
+			return e.Name
+		}
+	}
+	return ""
+}
+
+// Policy is the desired extension state saved in userconfig.
+type Policy struct {
+	Force []string `yaml:"force,omitempty"`
+	Block []string `yaml:"block,omitempty"`
+	Allow []string `yaml:"allow,omitempty"`
+}
+
+// IsEmpty returns true if no extension is managed.
+func (p Policy) IsEmpty() bool {
+	return len(p.Force) == 0 && len(p.Block) == 0 && len(p.Allow) == 0
+}
+
+// ModeOf returns how id is currently managed.
+func (p Policy) ModeOf(id string) Mode {
+	switch {
+	case contains(p.Force, id):
+		return ModeForce
+	case contains(p.Block, id):
+		return ModeBlock
+	case contains(p.Allow, id):
+		return ModeAllow
+	}
+	return ModeNone
+}
+
+// Set manages id with mode, removing it from any other list. ModeNone removes it.
+// The wildcard can only be blocked or allowed.
+func (p *Policy) Set(id string, mode Mode) error {
+	if id == Wildcard && mode == ModeForce {
+		return fmt.Errorf("cannot force-install %q", Wildcard)
+	}
+	p.Remove(id)
+	switch mode {
+	case ModeForce:
+		p.Force = append(p.Force, id)
+	case ModeBlock:
+		p.Block = append(p.Block, id)
+	case ModeAllow:
+		p.Allow = append(p.Allow, id)
+	}
+	return nil
+}
+
+// Remove stops managing id. Returns true if it was present.
+func (p *Policy) Remove(id string) bool {
+	var removed bool
+	for _, l := range []*[]string{&p.Force, &p.Block, &p.Allow} {
+		out := (*l)[:0]
+		for _, x := range *l {
+			if x == id {
+				removed = true
+				continue
+			}
+			out = append(out, x)
+		}
+		*l = out
+	}
+	return removed
+}
+
+// IDs returns every managed id, sorted.
+func (p Policy) IDs() []string {
+	var ids []string
+	ids = append(ids, p.Force...)
+	ids = append(ids, p.Block...)
+	ids = append(ids, p.Allow...)
+	sort.Strings(ids)
+	return ids
+}
+
+// Settings returns the policy settings for p. Empty lists are omitted.
+// ExtensionSettings mirrors the three lists so newer Brave versions see the same intent.
+func (p Policy) Settings() []brave.Setting {
+	var out []brave.Setting
+	extSettings := map[string]interface{}{}
+	if len(p.Force) > 0 {
+		l := make([]interface{}, len(p.Force))
+		for i, id := range p.Force {
+			l[i] = id + ";" + UpdateURL
+			extSettings[id] = map[string]interface{}{"installation_mode": "force_installed", "update_url": UpdateURL}
+		}
+		out = append(out, brave.Setting{Key: KeyForcelist, Value: l, Type: brave.TypeList})
+	}
+	if len(p.Block) > 0 {
+		out = append(out, brave.Setting{Key: KeyBlocklist, Value: toList(p.Block), Type: brave.TypeList})
+		for _, id := range p.Block {
+			extSettings[id] = map[string]interface{}{"installation_mode": "blocked"}
+		}
+	}
+	if len(p.Allow) > 0 {
+		out = append(out, brave.Setting{Key: KeyAllowlist, Value: toList(p.Allow), Type: brave.TypeList})
+		for _, id := range p.Allow {
+			extSettings[id] = map[string]interface{}{"installation_mode": "allowed"}
+		}
+	}
+	if len(extSettings) > 0 {
+		out = append(out, brave.Setting{Key: KeySettings, Value: extSettings, Type: brave.TypeDict})
+	}
+	return out
+}
+
+func toList(ids []string) []interface{} {
+	out := make([]interface{}, len(ids))
+	for i, id := range ids {
+		out[i] = id
+	}
+	return out
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
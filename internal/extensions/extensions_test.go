@@ -0,0 +1,70 @@
+package extensions
+
+import (
+	"testing"
+
+	"github.com/cowardly/cowardly/internal/brave"
+)
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"cjpalhdlnbpafiamejdnhcphjbkeiagm", "cjpalhdlnbpafiamejdnhcphjbkeiagm", false},
+		{"uBlock Origin", "cjpalhdlnbpafiamejdnhcphjbkeiagm", false},
+		{"bitwarden", "nngceckbapebfimnlniiiahkandclblb", false},
+		{"*", "*", false},
+		{"not-an-extension", "", true},
+		{"zzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzz", "", true},
+	}
+	for _, tt := range tests {
+		got, err := Resolve(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("Resolve(%q) = %q, %v; want %q, err %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestPolicySetRemove(t *testing.T) {
+	var p Policy
+	id := "cjpalhdlnbpafiamejdnhcphjbkeiagm"
+	if err := p.Set(id, ModeForce); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Set(id, ModeBlock); err != nil {
+		t.Fatal(err)
+	}
+	if p.ModeOf(id) != ModeBlock || len(p.Force) != 0 {
+		t.Errorf("expected id moved to block list, got %+v", p)
+	}
+	if err := p.Set(Wildcard, ModeForce); err == nil {
+		t.Error("expected error force-installing wildcard")
+	}
+	if !p.Remove(id) || !p.IsEmpty() {
+		t.Errorf("expected empty policy after remove, got %+v", p)
+	}
+}
+
+func TestPolicySettings(t *testing.T) {
+	p := Policy{
+		Force: []string{"nngceckbapebfimnlniiiahkandclblb"},
+		Block: []string{Wildcard},
+	}
+	byKey := make(map[string]brave.Setting)
+	for _, s := range p.Settings() {
+		byKey[s.Key] = s
+	}
+	if _, ok := byKey[KeyAllowlist]; ok {
+		t.Error("empty allow list should be omitted")
+	}
+	force := byKey[KeyForcelist].Value.([]interface{})
+	if len(force) != 1 || force[0] != "nngceckbapebfimnlniiiahkandclblb;"+UpdateURL {
+		t.Errorf("unexpected forcelist %v", force)
+	}
+	ext := byKey[KeySettings].Value.(map[string]interface{})
+	if ext[Wildcard].(map[string]interface{})["installation_mode"] != "blocked" {
+		t.Errorf("unexpected ExtensionSettings %v", ext)
+	}
+}
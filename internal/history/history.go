@@ -0,0 +1,215 @@
+// Package history keeps an append-only journal (history.jsonl in the state dir) of what each apply,
+// reset, restore and reapply changed: the before and after value of every key, in the managed and the
+// user plist. Entries can be undone key by key.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/cowardly/cowardly/internal/brave"
+	"github.com/cowardly/cowardly/internal/paths"
+	"github.com/cowardly/cowardly/internal/userconfig"
+)
+
+// FileName is the name of the journal in the state directory.
+const FileName = "history.jsonl"
+
+// Scope is the plist a change was made in.
+type Scope string
+
+const (
+	Managed Scope = "managed" // /Library/Managed Preferences (enforced)
+	User    Scope = "user"    // ~/Library/Preferences
+)
+
+// Actions recorded in the journal.
+const (
+	ActionApply   = "apply"
+	ActionReapply = "reapply"
+	ActionReset   = "reset"
+	ActionRestore = "restore"
+	ActionUndo    = "undo"
+)
+
+// Value is a journaled plist value.
+type Value struct {
+	Value interface{}     `json:"value"`
+	Type  brave.ValueType `json:"type"`
+}
+
+// Change is one key whose value changed. Before or After is nil when the key was unset.
+type Change struct {
+	Scope  Scope  `json:"scope"`
+	Key    string `json:"key"`
+	Before *Value `json:"before,omitempty"`
+	After  *Value `json:"after,omitempty"`
+}
+
+// Entry is one journal line.
+type Entry struct {
+	Time    time.Time `json:"time"`
+	Action  string    `json:"action"`
+	Source  string    `json:"source,omitempty"`  // preset id, file, backup, profile or undone entry
+	Profile string    `json:"profile,omitempty"` // active profile in cowardly.yaml
+	Brave   string    `json:"brave,omitempty"`   // Brave domain, e.g. com.brave.Browser.beta
+	Changes []Change  `json:"changes"`
+}
+
+// Snapshot is the content of the managed and user plists.
+type Snapshot map[Scope]map[string]brave.Setting
+
+// Take reads both plists. A missing plist is empty. Returns nil if either cannot be read.
+func Take() Snapshot {
+	userPath, err := brave.UserPreferencesPath()
+	if err != nil {
+		return nil
+	}
+	snap := Snapshot{}
+	for scope, path := range map[Scope]string{Managed: brave.ManagedPreferencesPath() + ".plist", User: userPath} {
+		snap[scope] = map[string]brave.Setting{}
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		settings, err := brave.ReadPlistFile(path)
+		if err != nil {
+			return nil
+		}
+		snap[scope] = settings
+	}
+	return snap
+}
+
+// Diff returns the keys that differ between before and after, managed scope first, then by key.
+func Diff(before, after Snapshot) []Change {
+	var out []Change
+	for _, scope := range []Scope{Managed, User} {
+		b, a := before[scope], after[scope]
+		keys := make(map[string]bool)
+		for k := range b {
+			keys[k] = true
+		}
+		for k := range a {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			bs, bok := b[k]
+			as, aok := a[k]
+			if bok && aok && sameValue(bs, as) {
+				continue
+			}
+			c := Change{Scope: scope, Key: k}
+			if bok {
+				c.Before = &Value{Value: bs.Value, Type: bs.Type}
+			}
+			if aok {
+				c.After = &Value{Value: as.Value, Type: as.Type}
+			}
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func sameValue(a, b brave.Setting) bool {
+	return a.Type == b.Type && brave.ValueString(a) == brave.ValueString(b)
+}
+
+// Path returns the path of the journal in the state dir of p.
+func Path(p paths.Paths) string {
+	return filepath.Join(p.State, FileName)
+}
+
+// Record appends an entry with the changes between before (from Take, taken before the operation)
+// and the plists now, in the journal of p. Does nothing if before is nil.
+func Record(p paths.Paths, action, source string, before Snapshot) error {
+	if before == nil {
+		return nil
+	}
+	after := Take()
+	if after == nil {
+		return fmt.Errorf("read plists after %s", action)
+	}
+	profile, _ := userconfig.New(p).ActiveProfile()
+	return Append(p, Entry{
+		Time:    time.Now(),
+		Action:  action,
+		Source:  source,
+		Profile: profile,
+		Brave:   brave.Domain(),
+		Changes: Diff(before, after),
+	})
+}
+
+// Append writes e as a line at the end of the journal of p.
+func Append(p paths.Paths, e Entry) error {
+	path := Path(p)
+	if e.Changes == nil {
+		e.Changes = []Change{}
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("marshal history entry: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create state dir: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("open history: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("write history: %w", err)
+	}
+	return f.Close()
+}
+
+// List returns all entries of the journal of p, oldest first. Entry n in `cowardly history` is
+// List(p)[n-1].
+func List(p paths.Paths) ([]Entry, error) {
+	f, err := os.Open(Path(p))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("open history: %w", err)
+	}
+	defer f.Close()
+	var out []Entry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("history line %d: %w", line, err)
+		}
+		out = append(out, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read history: %w", err)
+	}
+	return out, nil
+}
+
+// Setting returns v as a Setting for key, converting JSON numbers back to integers.
+func (v *Value) Setting(key string) brave.Setting {
+	s := brave.Setting{Key: key, Value: v.Value, Type: v.Type}
+	if f, ok := v.Value.(float64); ok && v.Type == brave.TypeInteger {
+		s.Value = int(f)
+	}
+	return s
+}
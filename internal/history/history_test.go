@@ -0,0 +1,90 @@
+package history
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cowardly/cowardly/internal/brave"
+	"github.com/cowardly/cowardly/internal/paths"
+)
+
+func TestDiff(t *testing.T) {
+	before := Snapshot{
+		Managed: {
+			"BraveRewardsDisabled": {Key: "BraveRewardsDisabled", Value: false, Type: brave.TypeBool},
+			"TorDisabled":          {Key: "TorDisabled", Value: true, Type: brave.TypeBool},
+		},
+		User: {
+			"DnsOverHttpsMode": {Key: "DnsOverHttpsMode", Value: "automatic", Type: brave.TypeString},
+		},
+	}
+	after := Snapshot{
+		Managed: {
+			"BraveRewardsDisabled":       {Key: "BraveRewardsDisabled", Value: true, Type: brave.TypeBool},
+			"TorDisabled":                {Key: "TorDisabled", Value: true, Type: brave.TypeBool},
+			"DefaultBraveAdblockSetting": {Key: "DefaultBraveAdblockSetting", Value: 2, Type: brave.TypeInteger},
+		},
+		User: {},
+	}
+	got := Diff(before, after)
+	if len(got) != 3 {
+		t.Fatalf("Diff() = %+v, want 3 changes", got)
+	}
+	if got[0].Key != "BraveRewardsDisabled" || got[0].Before.Value != false || got[0].After.Value != true {
+		t.Errorf("change 0 = %+v", got[0])
+	}
+	if got[1].Key != "DefaultBraveAdblockSetting" || got[1].Before != nil || got[1].After == nil {
+		t.Errorf("change 1 = %+v", got[1])
+	}
+	if got[2].Scope != User || got[2].Key != "DnsOverHttpsMode" || got[2].After != nil {
+		t.Errorf("change 2 = %+v", got[2])
+	}
+}
+
+func TestAppendList(t *testing.T) {
+	p := paths.Paths{State: t.TempDir()}
+	e := Entry{
+		Time:   time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Action: ActionApply,
+		Source: "quick",
+		Changes: []Change{{
+			Scope: Managed, Key: "DefaultBraveAdblockSetting",
+			After: &Value{Value: 2, Type: brave.TypeInteger},
+		}},
+	}
+	if err := Append(p, e); err != nil {
+		t.Fatal(err)
+	}
+	if err := Append(p, Entry{Time: e.Time, Action: ActionReset}); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := List(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Source != "quick" || entries[1].Action != ActionReset {
+		t.Fatalf("List() = %+v", entries)
+	}
+	s := entries[0].Changes[0].After.Setting("DefaultBraveAdblockSetting")
+	if n, ok := s.Value.(int); !ok || n != 2 {
+		t.Errorf("Setting() value = %#v, want int 2", s.Value)
+	}
+}
+
+func TestUndoEmpty(t *testing.T) {
+	if _, _, err := Undo(paths.Paths{State: t.TempDir()}, 0); err == nil {
+		t.Error("Undo() on empty history = nil, want error")
+	}
+}
+
+func TestUndoOtherChannel(t *testing.T) {
+	p := paths.Paths{State: t.TempDir()}
+	e := Entry{Action: ActionApply, Brave: "com.brave.Browser.nightly", Changes: []Change{{Scope: User, Key: "TorDisabled"}}}
+	if err := Append(p, e); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Undo(p, 1); err == nil || !strings.Contains(err.Error(), "--channel=nightly") {
+		t.Errorf("Undo() of a nightly entry = %v, want a --channel=nightly hint", err)
+	}
+}
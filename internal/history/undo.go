@@ -0,0 +1,118 @@
+package history
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/cowardly/cowardly/internal/brave"
+	"github.com/cowardly/cowardly/internal/lock"
+	"github.com/cowardly/cowardly/internal/paths"
+)
+
+// Undo reverts the key changes of entry n (1-based, as numbered by `cowardly history`; 0 = the latest)
+// in both plists, and journals the undo. Keys whose value changed again since the entry are reverted
+// too and returned as drifted, so the caller can warn about them. It holds the cowardly lock in p.State.
+func Undo(p paths.Paths, n int) (e Entry, drifted []Change, err error) {
+	l, err := lock.Acquire(p.State)
+	if err != nil {
+		return Entry{}, nil, err
+	}
+	defer l.Release()
+	entries, err := List(p)
+	if err != nil {
+		return Entry{}, nil, err
+	}
+	if len(entries) == 0 {
+		return Entry{}, nil, fmt.Errorf("history is empty")
+	}
+	if n == 0 {
+		n = len(entries)
+	}
+	if n < 1 || n > len(entries) {
+		return Entry{}, nil, fmt.Errorf("no history entry #%d (1-%d)", n, len(entries))
+	}
+	e = entries[n-1]
+	if e.Brave != "" && e.Brave != brave.Domain() {
+		if v, ok := brave.VariantForDomain(e.Brave); ok {
+			return e, nil, fmt.Errorf("entry #%d changed %s; use --channel=%s to match", n, e.Brave, v)
+		}
+		return e, nil, fmt.Errorf("entry #%d changed %s, which is not a known Brave channel", n, e.Brave)
+	}
+	if len(e.Changes) == 0 {
+		return e, nil, nil
+	}
+	before := Take()
+	if before == nil {
+		return e, nil, fmt.Errorf("cannot read the Brave plists")
+	}
+	for _, c := range e.Changes {
+		cur, ok := before[c.Scope][c.Key]
+		if c.After == nil && !ok {
+			continue
+		}
+		if c.After != nil && ok && sameValue(cur, c.After.Setting(c.Key)) {
+			continue
+		}
+		drifted = append(drifted, c)
+	}
+	if err := revertManaged(before[Managed], e.Changes); err != nil {
+		return e, drifted, err
+	}
+	for _, c := range e.Changes {
+		if c.Scope != User {
+			continue
+		}
+		if c.Before == nil {
+			err = brave.Delete(c.Key)
+		} else {
+			err = brave.Write(c.Before.Setting(c.Key))
+		}
+		if err != nil {
+			return e, drifted, fmt.Errorf("%s: %w", c.Key, err)
+		}
+	}
+	if err := Record(p, ActionUndo, fmt.Sprintf("#%d", n), before); err != nil {
+		return e, drifted, err
+	}
+	return e, drifted, nil
+}
+
+// revertManaged rewrites the managed plist (current) with the managed-scope changes reverted.
+// The managed plist is replaced as a whole, so this asks for admin privileges once.
+func revertManaged(current map[string]brave.Setting, changes []Change) error {
+	next := make(map[string]brave.Setting, len(current))
+	for k, s := range current {
+		next[k] = s
+	}
+	touched := false
+	for _, c := range changes {
+		if c.Scope != Managed {
+			continue
+		}
+		touched = true
+		if c.Before == nil {
+			delete(next, c.Key)
+		} else {
+			next[c.Key] = c.Before.Setting(c.Key)
+		}
+	}
+	if !touched {
+		return nil
+	}
+	if len(next) == 0 {
+		if !brave.ManagedPlistExists() {
+			return nil
+		}
+		return brave.RemoveManagedPlist()
+	}
+	keys := make([]string, 0, len(next))
+	for k := range next {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	settings := make([]brave.Setting, len(keys))
+	for i, k := range keys {
+		settings[i] = next[k]
+	}
+	return brave.WriteAllToManaged(settings)
+}
@@ -0,0 +1,132 @@
+// Package lock serializes cowardly processes, and goroutines within one, that change Brave or the config
+// file (the TUI, a CLI call, the login hook, a watch loop) with an advisory lock on cowardly.lock in the
+// state dir. The file holds the pid of the process that has the lock, for the "another cowardly is
+// running" error.
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// FileName is the name of the lock file in the state directory.
+const FileName = "cowardly.lock"
+
+// BusyError is returned by Acquire when another process holds the lock and waiting is off.
+type BusyError struct {
+	PID int // 0 if the holder's pid is unknown
+}
+
+func (e *BusyError) Error() string {
+	if e.PID > 0 {
+		return fmt.Sprintf("another cowardly is running (pid %d); retry when it is done or pass --wait", e.PID)
+	}
+	return "another cowardly is running; retry when it is done or pass --wait"
+}
+
+// wait is set by UseWait.
+var wait bool
+
+// UseWait makes Acquire block until the lock is free instead of failing with a BusyError (--wait).
+func UseWait(w bool) {
+	wait = w
+}
+
+// Lock is the held cowardly lock. It is not reentrant (a second Acquire in the same process waits for
+// Release): a change made of several steps (backup, apply, journal, config write) takes it once and
+// passes it to the functions that need it held.
+type Lock struct {
+	dir  string
+	mu   sync.Mutex
+	file *os.File // nil once released
+}
+
+// Acquire takes the lock in stateDir (paths.Paths.State). Each call opens its own file description, so
+// goroutines of one process are serialized like processes; one of this process waits even without
+// UseWait, as it only has to finish its own change.
+func Acquire(stateDir string) (*Lock, error) {
+	path := filepath.Join(stateDir, FileName)
+	if err := os.MkdirAll(stateDir, 0755); err != nil {
+		return nil, fmt.Errorf("create state dir: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("open lock: %w", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		if !errors.Is(err, syscall.EWOULDBLOCK) {
+			_ = f.Close()
+			return nil, fmt.Errorf("lock %s: %w", path, err)
+		}
+		pid := holder(f)
+		self := pid == os.Getpid()
+		if !wait && !self {
+			_ = f.Close()
+			return nil, &BusyError{PID: pid}
+		}
+		switch {
+		case self:
+		case pid > 0:
+			fmt.Fprintf(os.Stderr, "Another cowardly is running (pid %d); waiting for it to finish...\n", pid)
+		default:
+			fmt.Fprintln(os.Stderr, "Another cowardly is running; waiting for it to finish...")
+		}
+		if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+			_ = f.Close()
+			return nil, fmt.Errorf("lock %s: %w", path, err)
+		}
+	}
+	if err := f.Truncate(0); err == nil {
+		_, _ = f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	}
+	return &Lock{dir: stateDir, file: f}, nil
+}
+
+// Dir returns the state dir of the lock file.
+func (l *Lock) Dir() string {
+	return l.dir
+}
+
+// Check returns an error unless l is held (not nil and not released). Functions that take a held lock
+// call it first.
+func (l *Lock) Check() error {
+	if l == nil {
+		return fmt.Errorf("cowardly lock not held")
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return fmt.Errorf("cowardly lock in %s already released", l.dir)
+	}
+	return nil
+}
+
+// Release unlocks l. Calling it again does nothing.
+func (l *Lock) Release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return
+	}
+	_ = l.file.Truncate(0)
+	_ = syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN)
+	_ = l.file.Close()
+	l.file = nil
+}
+
+// holder returns the pid written in the lock file, or 0.
+func holder(f *os.File) int {
+	buf := make([]byte, 32)
+	n, _ := f.ReadAt(buf, 0)
+	pid, err := strconv.Atoi(strings.TrimSpace(string(buf[:n])))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
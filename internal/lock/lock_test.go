@@ -0,0 +1,83 @@
+package lock
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestAcquire(t *testing.T) {
+	dir := t.TempDir()
+
+	l, err := Acquire(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Check(); err != nil {
+		t.Fatalf("Check() on a held lock = %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, FileName))
+	if string(data) != strconv.Itoa(os.Getpid())+"\n" {
+		t.Errorf("lock file = %q, want our pid", data)
+	}
+
+	// Another goroutine waits for the release instead of sharing the lock.
+	acquired := make(chan *Lock)
+	go func() {
+		other, err := Acquire(dir)
+		if err != nil {
+			t.Error(err)
+		}
+		acquired <- other
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("second Acquire() in the process did not wait for the first")
+	case <-time.After(100 * time.Millisecond):
+	}
+	l.Release()
+	l.Release() // a second call is a no-op
+	if err := l.Check(); err == nil {
+		t.Error("Check() after Release() = nil, want error")
+	}
+	other := <-acquired
+	other.Release()
+
+	var none *Lock
+	if err := none.Check(); err == nil {
+		t.Error("Check() on a nil lock = nil, want error")
+	}
+}
+
+func TestAcquireBusy(t *testing.T) {
+	dir := t.TempDir()
+
+	// Another open file description stands in for another process.
+	path := filepath.Join(dir, FileName)
+	other, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	if err := syscall.Flock(int(other.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.WriteString("4242\n"); err != nil {
+		t.Fatal(err)
+	}
+	_, err = Acquire(dir)
+	var busy *BusyError
+	if !errors.As(err, &busy) || busy.PID != 4242 {
+		t.Fatalf("Acquire() = %v, want BusyError for pid 4242", err)
+	}
+	_ = syscall.Flock(int(other.Fd()), syscall.LOCK_UN)
+	l, err := Acquire(dir)
+	if err != nil {
+		t.Fatalf("Acquire() after unlock = %v", err)
+	}
+	l.Release()
+}
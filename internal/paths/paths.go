@@ -0,0 +1,114 @@
+// Package paths resolves where cowardly keeps its config file and its state (backups, history, logs).
+// main resolves them once at startup from flags and the environment and passes them to the packages
+// that read or write files (userconfig, brave, history, lock, watch); there is no fallback.
+package paths
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Environment variables consulted by Resolve.
+const (
+	EnvConfig    = "COWARDLY_CONFIG" // path of the config file
+	EnvXDGConfig = "XDG_CONFIG_HOME"
+	EnvXDGState  = "XDG_STATE_HOME"
+)
+
+// ConfigFileName is the name of the config file in the config directory.
+const ConfigFileName = "cowardly.yaml"
+
+// Paths are the resolved locations. Both are absolute.
+type Paths struct {
+	Config string // config file (cowardly.yaml)
+	State  string // state directory: backups, history journal, Launch Agent logs
+}
+
+// ConfigDir returns the directory of the config file.
+func (p Paths) ConfigDir() string {
+	return filepath.Dir(p.Config)
+}
+
+// BackupDir returns the directory of the user plist backups.
+func (p Paths) BackupDir() string {
+	return filepath.Join(p.State, "backups")
+}
+
+// Log returns the path of a log file in the state directory.
+func (p Paths) Log(name string) string {
+	return filepath.Join(p.State, name)
+}
+
+// Options are the global path flags. Empty fields fall back to the environment, then the defaults.
+type Options struct {
+	Config   string // --config=<file>
+	StateDir string // --state-dir=<dir>
+}
+
+// ParseFlags removes --config=<file> and --state-dir=<dir> (one or two leading dashes) from args.
+func ParseFlags(args []string) (Options, []string, error) {
+	var opts Options
+	var rest []string
+	for _, a := range args {
+		flag := strings.TrimLeft(a, "-")
+		var dst *string
+		var val string
+		switch {
+		case strings.HasPrefix(a, "-") && strings.HasPrefix(flag, "config="):
+			dst, val = &opts.Config, strings.TrimPrefix(flag, "config=")
+		case strings.HasPrefix(a, "-") && strings.HasPrefix(flag, "state-dir="):
+			dst, val = &opts.StateDir, strings.TrimPrefix(flag, "state-dir=")
+		default:
+			rest = append(rest, a)
+			continue
+		}
+		if val == "" {
+			return opts, nil, fmt.Errorf("%s: path is empty", a)
+		}
+		*dst = val
+	}
+	return opts, rest, nil
+}
+
+// Resolve returns the paths for opts. In order of precedence:
+//
+//	config: --config, $COWARDLY_CONFIG, $XDG_CONFIG_HOME/cowardly/cowardly.yaml, ~/.config/cowardly/cowardly.yaml
+//	state:  --state-dir, $XDG_STATE_HOME/cowardly, ~/Library/Application Support/cowardly
+//
+// Nothing is created; writers create directories as needed.
+func Resolve(opts Options, getenv func(string) string, home string) (Paths, error) {
+	abs := func(p string) (string, error) {
+		if strings.HasPrefix(p, "~/") {
+			p = filepath.Join(home, p[2:])
+		}
+		return filepath.Abs(p)
+	}
+	var p Paths
+	var err error
+	switch {
+	case opts.Config != "":
+		p.Config, err = abs(opts.Config)
+	case getenv(EnvConfig) != "":
+		p.Config, err = abs(getenv(EnvConfig))
+	case getenv(EnvXDGConfig) != "":
+		p.Config, err = abs(filepath.Join(getenv(EnvXDGConfig), "cowardly", ConfigFileName))
+	default:
+		p.Config = filepath.Join(home, ".config", "cowardly", ConfigFileName)
+	}
+	if err != nil {
+		return Paths{}, fmt.Errorf("config path: %w", err)
+	}
+	switch {
+	case opts.StateDir != "":
+		p.State, err = abs(opts.StateDir)
+	case getenv(EnvXDGState) != "":
+		p.State, err = abs(filepath.Join(getenv(EnvXDGState), "cowardly"))
+	default:
+		p.State = filepath.Join(home, "Library", "Application Support", "cowardly")
+	}
+	if err != nil {
+		return Paths{}, fmt.Errorf("state dir: %w", err)
+	}
+	return p, nil
+}
@@ -0,0 +1,56 @@
+package paths
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	home := "/Users/me"
+	tests := []struct {
+		name string
+		opts Options
+		env  map[string]string
+		want Paths
+	}{
+		{"defaults", Options{}, nil, Paths{
+			Config: "/Users/me/.config/cowardly/cowardly.yaml",
+			State:  "/Users/me/Library/Application Support/cowardly",
+		}},
+		{"xdg", Options{}, map[string]string{EnvXDGConfig: "/xdg/config", EnvXDGState: "/xdg/state"}, Paths{
+			Config: "/xdg/config/cowardly/cowardly.yaml",
+			State:  "/xdg/state/cowardly",
+		}},
+		{"env config over xdg", Options{}, map[string]string{EnvConfig: "~/work.yaml", EnvXDGConfig: "/xdg/config"}, Paths{
+			Config: "/Users/me/work.yaml",
+			State:  "/Users/me/Library/Application Support/cowardly",
+		}},
+		{"flags over env", Options{Config: "/tmp/a.yaml", StateDir: "/tmp/state"}, map[string]string{EnvConfig: "/b.yaml", EnvXDGState: "/xdg/state"}, Paths{
+			Config: "/tmp/a.yaml",
+			State:  "/tmp/state",
+		}},
+	}
+	for _, tt := range tests {
+		got, err := Resolve(tt.opts, func(k string) string { return tt.env[k] }, home)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: Resolve() = %+v, %v; want %+v", tt.name, got, err, tt.want)
+		}
+	}
+	if got := (Paths{State: "/s"}).BackupDir(); got != filepath.Join("/s", "backups") {
+		t.Errorf("BackupDir() = %q", got)
+	}
+}
+
+func TestParseFlags(t *testing.T) {
+	opts, rest, err := ParseFlags([]string{"--config=/tmp/a.yaml", "--beta", "-state-dir=/tmp/s", "profile", "list"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts != (Options{Config: "/tmp/a.yaml", StateDir: "/tmp/s"}) || !reflect.DeepEqual(rest, []string{"--beta", "profile", "list"}) {
+		t.Errorf("ParseFlags() = %+v, %q", opts, rest)
+	}
+	if _, _, err := ParseFlags([]string{"--config="}); err == nil {
+		t.Error("ParseFlags(--config=) = nil error")
+	}
+}
@@ -13,6 +13,11 @@ import (
 
 	"github.com/cowardly/cowardly/configs"
 	"github.com/cowardly/cowardly/internal/brave"
+	"github.com/cowardly/cowardly/internal/content"
+	"github.com/cowardly/cowardly/internal/proxy"
+	"github.com/cowardly/cowardly/internal/search"
+	"github.com/cowardly/cowardly/internal/shields"
+	"github.com/cowardly/cowardly/internal/startup"
 	"gopkg.in/yaml.v3"
 )
 
@@ -21,10 +26,109 @@ var policyKeyRegex = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*$`)
 
 // presetFile is the on-disk shape of a preset YAML file.
 type presetFile struct {
-	ID          string       `yaml:"id"`
-	Name        string       `yaml:"name"`
-	Description string       `yaml:"description"`
-	Settings    []settingRow `yaml:"settings"`
+	ID             string             `yaml:"id"`
+	Name           string             `yaml:"name"`
+	Description    string             `yaml:"description"`
+	SearchProvider *searchProviderRef `yaml:"search_provider,omitempty"`
+	Proxy          *proxy.Config      `yaml:"proxy,omitempty"`
+	Content        content.Config     `yaml:"content,omitempty"`
+	Shields        *shields.Config    `yaml:"shields,omitempty"`
+	Startup        *startup.Config    `yaml:"startup,omitempty"`
+	Settings       []settingRow       `yaml:"settings"`
+}
+
+// searchProviderRef is the `search_provider` field in preset or settings YAML.
+// Either a catalog id (`search_provider: duckduckgo`) or a mapping with id and
+// provider variables (`search_provider: {id: searxng, url: https://searx.example.org}`).
+type searchProviderRef struct {
+	ID   string
+	Vars map[string]string
+}
+
+// UnmarshalYAML accepts the scalar and mapping forms of search_provider.
+func (r *searchProviderRef) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		r.ID = node.Value
+		return nil
+	}
+	var m map[string]string
+	if err := node.Decode(&m); err != nil {
+		return fmt.Errorf("search_provider: expected an id or a mapping: %w", err)
+	}
+	r.ID = m["id"]
+	delete(m, "id")
+	r.Vars = m
+	return nil
+}
+
+// withSearchProvider expands ref (if any) and overlays the resulting DefaultSearchProvider* keys on settings.
+func withSearchProvider(settings []brave.Setting, ref *searchProviderRef) ([]brave.Setting, error) {
+	if ref == nil {
+		return settings, nil
+	}
+	if ref.ID == "" {
+		return nil, fmt.Errorf("search_provider: id is empty")
+	}
+	searchSettings, err := search.Settings(ref.ID, ref.Vars)
+	if err != nil {
+		return nil, err
+	}
+	return MergeSettingsWithSupplement(settings, searchSettings), nil
+}
+
+// withProxy validates cfg (if any) and overlays the resulting proxy policies on settings.
+func withProxy(settings []brave.Setting, cfg *proxy.Config) ([]brave.Setting, error) {
+	if cfg == nil {
+		return settings, nil
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("proxy: %w", err)
+	}
+	return MergeSettingsWithSupplement(settings, cfg.Settings()), nil
+}
+
+// withContent validates the content section (if any) and overlays the compiled policies on settings.
+func withContent(settings []brave.Setting, c content.Config) ([]brave.Setting, error) {
+	if len(c) == 0 {
+		return settings, nil
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return MergeSettingsWithSupplement(settings, c.Settings()), nil
+}
+
+// withShields converts the named Shields levels (if any) and overlays the integer policies on settings.
+func withShields(settings []brave.Setting, cfg *shields.Config) ([]brave.Setting, error) {
+	if cfg == nil {
+		return settings, nil
+	}
+	shieldsSettings, err := cfg.Settings()
+	if err != nil {
+		return nil, err
+	}
+	return MergeSettingsWithSupplement(settings, shieldsSettings), nil
+}
+
+// withStartup validates the startup section (if any) and overlays the startup and homepage policies on settings.
+func withStartup(settings []brave.Setting, cfg *startup.Config) ([]brave.Setting, error) {
+	if cfg == nil {
+		return settings, nil
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return MergeSettingsWithSupplement(settings, cfg.Settings()), nil
+}
+
+// ValueNames returns the names of the integer policy values a preset can set by name (Shields levels,
+// on_startup), for labelling raw values in diffs and status output.
+func ValueNames() brave.ValueNames {
+	out := shields.ValueNames()
+	for k, v := range startup.ValueNames() {
+		out[k] = v
+	}
+	return out
 }
 
 // SettingRow is one key/value/type row as in preset or config YAML. Exported for use by userconfig.
@@ -110,6 +214,26 @@ func LoadFromFS(fsys fs.FS, dir string) ([]Preset, error) {
 		if err != nil {
 			return nil, fmt.Errorf("%q: %w", path, err)
 		}
+		settings, err = withSearchProvider(settings, pf.SearchProvider)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", path, err)
+		}
+		settings, err = withProxy(settings, pf.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", path, err)
+		}
+		settings, err = withContent(settings, pf.Content)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", path, err)
+		}
+		settings, err = withShields(settings, pf.Shields)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", path, err)
+		}
+		settings, err = withStartup(settings, pf.Startup)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", path, err)
+		}
 		out = append(out, Preset{
 			ID:          pf.ID,
 			Name:        pf.Name,
@@ -125,24 +249,53 @@ func ConvertSettingRows(rows []SettingRow) ([]brave.Setting, error) {
 	return convertSettings(rows)
 }
 
+// ConvertSettingRow is ConvertSettingRows for one row; errors are not prefixed with the row index and key.
+func ConvertSettingRow(r SettingRow) (brave.Setting, error) {
+	if r.Key == "" {
+		return brave.Setting{}, fmt.Errorf("key is empty")
+	}
+	return convertSetting(r)
+}
+
 func convertSettings(rows []settingRow) ([]brave.Setting, error) {
 	out := make([]brave.Setting, 0, len(rows))
 	for i, r := range rows {
 		if r.Key == "" {
 			return nil, fmt.Errorf("setting %d: key is empty", i)
 		}
-		if !policyKeyRegex.MatchString(r.Key) {
-			return nil, fmt.Errorf("setting %d %q: key must match [A-Za-z][A-Za-z0-9]* (Chromium policy name)", i, r.Key)
-		}
-		val, vt, err := normalizeValue(r.Value, r.Type)
+		s, err := convertSetting(r)
 		if err != nil {
 			return nil, fmt.Errorf("setting %d %q: %w", i, r.Key, err)
 		}
-		out = append(out, brave.Setting{Key: r.Key, Value: val, Type: vt})
+		out = append(out, s)
 	}
 	return out, nil
 }
 
+// convertSetting checks the key of r and converts its value to the policy type.
+func convertSetting(r settingRow) (brave.Setting, error) {
+	if !policyKeyRegex.MatchString(r.Key) {
+		return brave.Setting{}, fmt.Errorf("key must match [A-Za-z][A-Za-z0-9]* (Chromium policy name)")
+	}
+	var val interface{}
+	var vt brave.ValueType
+	var err error
+	if p := shields.Find(r.Key); p != nil {
+		// Shields policies accept a level name ("standard") or one of its integers.
+		vt = brave.TypeInteger
+		val, err = p.Resolve(r.Value)
+	} else if r.Key == startup.KeyRestoreOnStartup {
+		vt = brave.TypeInteger
+		val, err = startup.ResolveOnStartup(r.Value)
+	} else {
+		val, vt, err = normalizeValue(r.Value, r.Type)
+	}
+	if err != nil {
+		return brave.Setting{}, err
+	}
+	return brave.Setting{Key: r.Key, Value: val, Type: vt}, nil
+}
+
 func normalizeValue(raw interface{}, typeStr string) (interface{}, brave.ValueType, error) {
 	switch strings.ToLower(typeStr) {
 	case "bool", "boolean":
@@ -154,6 +307,12 @@ func normalizeValue(raw interface{}, typeStr string) (interface{}, brave.ValueTy
 	case "string":
 		s, err := toString(raw)
 		return s, brave.TypeString, err
+	case "list", "array":
+		l, err := toList(raw)
+		return l, brave.TypeList, err
+	case "dict", "dictionary":
+		d, err := toDict(raw)
+		return d, brave.TypeDict, err
 	default:
 		return nil, "", fmt.Errorf("unknown type %q", typeStr)
 	}
@@ -197,9 +356,40 @@ func toString(v interface{}) (string, error) {
 	return "", fmt.Errorf("cannot convert %T to string", v)
 }
 
+func toList(v interface{}) ([]interface{}, error) {
+	switch l := v.(type) {
+	case []interface{}:
+		return l, nil
+	case []string:
+		out := make([]interface{}, len(l))
+		for i, e := range l {
+			out[i] = e
+		}
+		return out, nil
+	case nil:
+		return []interface{}{}, nil
+	}
+	return nil, fmt.Errorf("cannot convert %T to list", v)
+}
+
+func toDict(v interface{}) (map[string]interface{}, error) {
+	switch d := v.(type) {
+	case map[string]interface{}:
+		return d, nil
+	case nil:
+		return map[string]interface{}{}, nil
+	}
+	return nil, fmt.Errorf("cannot convert %T to dict", v)
+}
+
 // settingsFile is the on-disk shape for YAML that contains only a settings list (export/import).
 type settingsFile struct {
-	Settings []settingRow `yaml:"settings"`
+	SearchProvider *searchProviderRef `yaml:"search_provider,omitempty"`
+	Proxy          *proxy.Config      `yaml:"proxy,omitempty"`
+	Content        content.Config     `yaml:"content,omitempty"`
+	Shields        *shields.Config    `yaml:"shields,omitempty"`
+	Startup        *startup.Config    `yaml:"startup,omitempty"`
+	Settings       []settingRow       `yaml:"settings"`
 }
 
 // LoadSettingsFromFile reads a YAML file (with a "settings" list) and returns brave settings.
@@ -212,7 +402,27 @@ func LoadSettingsFromFile(path string) ([]brave.Setting, error) {
 	if err := yaml.Unmarshal(data, &f); err != nil {
 		return nil, fmt.Errorf("parse YAML: %w", err)
 	}
-	return convertSettings(f.Settings)
+	settings, err := convertSettings(f.Settings)
+	if err != nil {
+		return nil, err
+	}
+	settings, err = withSearchProvider(settings, f.SearchProvider)
+	if err != nil {
+		return nil, err
+	}
+	settings, err = withProxy(settings, f.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	settings, err = withContent(settings, f.Content)
+	if err != nil {
+		return nil, err
+	}
+	settings, err = withShields(settings, f.Shields)
+	if err != nil {
+		return nil, err
+	}
+	return withStartup(settings, f.Startup)
 }
 
 // PrivacyGuidesURL is the source URL for the Privacy Guides Brave recommendations.
@@ -231,7 +441,11 @@ func LoadPrivacyGuides() ([]brave.Setting, error) {
 	if err := yaml.Unmarshal(data, &f); err != nil {
 		return nil, fmt.Errorf("parse Privacy Guides YAML: %w", err)
 	}
-	return convertSettings(f.Settings)
+	settings, err := convertSettings(f.Settings)
+	if err != nil {
+		return nil, err
+	}
+	return withShields(settings, f.Shields)
 }
 
 // PrivacyGuidesMerged returns base preset + Privacy Guides supplement.
@@ -303,3 +517,23 @@ func WriteSettingsToFile(path string, settings []brave.Setting) error {
 	}
 	return os.WriteFile(path, data, 0600)
 }
+
+// WriteStartupPreset writes a preset YAML file with only a startup section.
+// The file can be applied with --apply-file or copied into configs/presets.
+func WriteStartupPreset(path, id, name string, cfg startup.Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	f := presetFile{
+		ID:          id,
+		Name:        name,
+		Description: "Startup pages, homepage and Home button",
+		Startup:     &cfg,
+		Settings:    []settingRow{},
+	}
+	data, err := yaml.Marshal(&f)
+	if err != nil {
+		return fmt.Errorf("marshal YAML: %w", err)
+	}
+	return os.WriteFile(path, data, 0600)
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/cowardly/cowardly/internal/brave"
+	"github.com/cowardly/cowardly/internal/startup"
 )
 
 func TestConvertSettingsKeyValidation(t *testing.T) {
@@ -20,6 +21,14 @@ func TestConvertSettingsKeyValidation(t *testing.T) {
 		{"invalid key space", []settingRow{{Key: "Brave Rewards", Value: true, Type: "bool"}}, true},
 		{"invalid key digit first", []settingRow{{Key: "1Key", Value: true, Type: "bool"}}, true},
 		{"valid key with digits", []settingRow{{Key: "Key2", Value: 1, Type: "integer"}}, false},
+		{"shields level name", []settingRow{{Key: "DefaultBraveFingerprintingV2Setting", Value: "standard", Type: "integer"}}, false},
+		{"shields valid number", []settingRow{{Key: "DefaultBraveHttpsUpgradeSetting", Value: 2, Type: "integer"}}, false},
+		{"shields invalid number", []settingRow{{Key: "DefaultBraveAdblockSetting", Value: 7, Type: "integer"}}, true},
+		{"shields unknown name", []settingRow{{Key: "DefaultBraveAdblockSetting", Value: "aggressive", Type: "integer"}}, true},
+		{"startup name", []settingRow{{Key: "RestoreOnStartup", Value: "restore_session", Type: "integer"}}, false},
+		{"startup restore session and urls", []settingRow{{Key: "RestoreOnStartup", Value: 6, Type: "integer"}}, false},
+		{"startup restore session and urls name", []settingRow{{Key: "RestoreOnStartup", Value: "restore_session_and_urls", Type: "integer"}}, false},
+		{"startup invalid number", []settingRow{{Key: "RestoreOnStartup", Value: 2, Type: "integer"}}, true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -105,6 +114,18 @@ func TestLoadPrivacyGuides(t *testing.T) {
 			t.Errorf("expected key %q in Privacy Guides supplement", k)
 		}
 	}
+	// The shields: section must keep compiling to the integers Privacy Guides recommends.
+	want := map[string]int{
+		"DefaultBraveAdblockSetting":           2,
+		"DefaultBraveHttpsUpgradeSetting":      2,
+		"DefaultBraveFingerprintingV2Setting":  3,
+		"DefaultBraveRemember1PStorageSetting": 2,
+	}
+	for _, s := range settings {
+		if n, ok := want[s.Key]; ok && s.Value != n {
+			t.Errorf("%s = %v, want %d", s.Key, s.Value, n)
+		}
+	}
 }
 
 func TestPrivacyGuidesMerged(t *testing.T) {
@@ -168,3 +189,138 @@ func TestLoadFromFS_EmptyDir(t *testing.T) {
 		t.Errorf("expected 0 presets, got %d", len(list))
 	}
 }
+
+func TestLoadFromFS_SearchProvider(t *testing.T) {
+	dir := t.TempDir()
+	presetsDir := path.Join(dir, "presets")
+	if err := os.Mkdir(presetsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	yaml := `id: degoogle
+name: Degoogle
+description: Test preset
+search_provider: duckduckgo
+settings:
+  - key: BraveRewardsDisabled
+    value: true
+    type: bool
+`
+	if err := os.WriteFile(path.Join(presetsDir, "00-test.yaml"), []byte(yaml), 0600); err != nil {
+		t.Fatal(err)
+	}
+	yaml2 := `id: selfhosted
+name: Self-hosted
+description: Test preset
+search_provider:
+  id: searxng
+  url: https://searx.example.org
+settings: []
+`
+	if err := os.WriteFile(path.Join(presetsDir, "01-test.yaml"), []byte(yaml2), 0600); err != nil {
+		t.Fatal(err)
+	}
+	list, err := LoadFromFS(os.DirFS(dir), "presets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := make(map[string]interface{})
+	for _, s := range list[0].Settings {
+		keys[s.Key] = s.Value
+	}
+	if keys["BraveRewardsDisabled"] != true || keys["DefaultSearchProviderName"] != "DuckDuckGo" {
+		t.Errorf("unexpected settings: %+v", list[0].Settings)
+	}
+	found := false
+	for _, s := range list[1].Settings {
+		if s.Key == "DefaultSearchProviderSearchURL" && s.Value == "https://searx.example.org/search?q={searchTerms}" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected expanded SearXNG search URL, got %+v", list[1].Settings)
+	}
+}
+
+func TestLoadFromFS_SearchProviderUnknown(t *testing.T) {
+	dir := t.TempDir()
+	presetsDir := path.Join(dir, "presets")
+	if err := os.Mkdir(presetsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path.Join(presetsDir, "bad.yaml"), []byte("id: x\nsearch_provider: altavista\nsettings: []\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFromFS(os.DirFS(dir), "presets"); err == nil {
+		t.Error("expected error for unknown search provider")
+	}
+}
+
+func TestLoadFromFS_Proxy(t *testing.T) {
+	dir := t.TempDir()
+	presetsDir := path.Join(dir, "presets")
+	if err := os.Mkdir(presetsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	yaml := `id: corp
+name: Corp
+description: Test preset
+proxy:
+  mode: fixed_servers
+  server: proxy.corp.example:3128
+  bypass: [localhost, "*.corp.example"]
+settings: []
+`
+	if err := os.WriteFile(path.Join(presetsDir, "00-test.yaml"), []byte(yaml), 0600); err != nil {
+		t.Fatal(err)
+	}
+	list, err := LoadFromFS(os.DirFS(dir), "presets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := make(map[string]interface{})
+	for _, s := range list[0].Settings {
+		keys[s.Key] = s.Value
+	}
+	if keys["ProxyMode"] != "fixed_servers" || keys["ProxyServer"] != "proxy.corp.example:3128" || keys["ProxyBypassList"] != "localhost,*.corp.example" {
+		t.Errorf("unexpected settings: %+v", list[0].Settings)
+	}
+
+	bad := "id: x\nproxy:\n  mode: direct\n  server: proxy:3128\nsettings: []\n"
+	if err := os.WriteFile(path.Join(presetsDir, "00-test.yaml"), []byte(bad), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFromFS(os.DirFS(dir), "presets"); err == nil {
+		t.Error("expected error for server with mode direct")
+	}
+}
+
+func TestWriteStartupPreset(t *testing.T) {
+	dir := t.TempDir()
+	presetsDir := path.Join(dir, "presets")
+	if err := os.Mkdir(presetsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	show := true
+	cfg := startup.Config{OnStartup: startup.RestoreSession, Homepage: "https://intranet.example", ShowHomeButton: &show}
+	file := path.Join(presetsDir, "startup.yaml")
+	if err := WriteStartupPreset(file, "startup", "Startup", cfg); err != nil {
+		t.Fatal(err)
+	}
+	list, err := LoadFromFS(os.DirFS(dir), "presets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := make(map[string]interface{})
+	for _, s := range list[0].Settings {
+		keys[s.Key] = s.Value
+	}
+	if keys["RestoreOnStartup"] != 1 || keys["HomepageLocation"] != "https://intranet.example" || keys["ShowHomeButton"] != true {
+		t.Errorf("unexpected settings: %+v", list[0].Settings)
+	}
+	if _, err := LoadSettingsFromFile(file); err != nil {
+		t.Errorf("LoadSettingsFromFile: %v", err)
+	}
+	if err := WriteStartupPreset(file, "startup", "Startup", startup.Config{OnStartup: startup.OpenURLs}); err == nil {
+		t.Error("expected error for urls without URLs")
+	}
+}
@@ -0,0 +1,284 @@
+// Package proxy validates a proxy configuration and expands it into the ProxyMode, ProxyServer,
+// ProxyPacUrl and ProxyBypassList policies (or the ProxySettings dictionary), and renders the
+// policies currently set as readable text.
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/cowardly/cowardly/internal/brave"
+)
+
+// Policy keys for proxy configuration.
+const (
+	KeyMode       = "ProxyMode"
+	KeyServer     = "ProxyServer"
+	KeyPacURL     = "ProxyPacUrl"
+	KeyBypassList = "ProxyBypassList"
+	KeySettings   = "ProxySettings"
+)
+
+// Keys lists all policy keys managed by this package.
+var Keys = []string{KeyMode, KeyServer, KeyPacURL, KeyBypassList, KeySettings}
+
+// Mode is a ProxyMode value.
+type Mode string
+
+const (
+	ModeDirect       Mode = "direct"
+	ModeAutoDetect   Mode = "auto_detect"
+	ModePacScript    Mode = "pac_script"
+	ModeFixedServers Mode = "fixed_servers"
+	ModeSystem       Mode = "system"
+)
+
+// Modes lists the valid modes.
+var Modes = []Mode{ModeDirect, ModeAutoDetect, ModePacScript, ModeFixedServers, ModeSystem}
+
+// ParseMode parses a ProxyMode value; "-" may be used instead of "_" (e.g. "fixed-servers").
+func ParseMode(s string) (Mode, error) {
+	m := Mode(strings.ReplaceAll(strings.ToLower(strings.TrimSpace(s)), "-", "_"))
+	for _, known := range Modes {
+		if m == known {
+			return m, nil
+		}
+	}
+	names := make([]string, len(Modes))
+	for i, known := range Modes {
+		names[i] = string(known)
+	}
+	return "", fmt.Errorf("unknown proxy mode %q (use %s)", s, strings.Join(names, ", "))
+}
+
+// serverSchemes are the schemes allowed in ProxyServer entries.
+var serverSchemes = map[string]bool{"http": true, "https": true, "socks": true, "socks4": true, "socks5": true, "quic": true}
+
+// ruleSchemes are the URL schemes a per-scheme ProxyServer rule can apply to (e.g. "https=proxy:443").
+var ruleSchemes = map[string]bool{"http": true, "https": true, "ftp": true, "socks": true}
+
+// Config is a proxy configuration as written in preset YAML or cowardly.yaml.
+type Config struct {
+	Mode   Mode     `yaml:"mode"`
+	Server string   `yaml:"server,omitempty"`
+	PacURL string   `yaml:"pac_url,omitempty"`
+	Bypass []string `yaml:"bypass,omitempty"`
+	Dict   bool     `yaml:"dict,omitempty"` // write the ProxySettings dictionary instead of the individual keys
+}
+
+// IsEmpty returns true if no mode is set.
+func (c Config) IsEmpty() bool {
+	return c.Mode == ""
+}
+
+// Validate checks the mode and that only the fields that mode uses are set.
+func (c Config) Validate() error {
+	if _, err := ParseMode(string(c.Mode)); err != nil {
+		return err
+	}
+	switch c.Mode {
+	case ModeFixedServers:
+		if c.Server == "" {
+			return fmt.Errorf("proxy mode %s needs a server", c.Mode)
+		}
+		if c.PacURL != "" {
+			return fmt.Errorf("proxy mode %s does not use pac_url", c.Mode)
+		}
+		if err := ValidateServer(c.Server); err != nil {
+			return err
+		}
+		for _, b := range c.Bypass {
+			if b = strings.TrimSpace(b); b == "" || strings.ContainsAny(b, ", \t") {
+				return fmt.Errorf("proxy bypass entry %q must be a single host, domain or CIDR", b)
+			}
+		}
+	case ModePacScript:
+		if c.PacURL == "" {
+			return fmt.Errorf("proxy mode %s needs pac_url", c.Mode)
+		}
+		if c.Server != "" || len(c.Bypass) > 0 {
+			return fmt.Errorf("proxy mode %s does not use server or bypass", c.Mode)
+		}
+		if err := ValidatePacURL(c.PacURL); err != nil {
+			return err
+		}
+	default:
+		if c.Server != "" || c.PacURL != "" || len(c.Bypass) > 0 {
+			return fmt.Errorf("proxy mode %s does not use server, pac_url or bypass", c.Mode)
+		}
+	}
+	return nil
+}
+
+// Settings returns the proxy policies for c: the individual keys, or ProxySettings when Dict is set.
+// An invalid config yields no settings; Validate before saving one.
+func (c Config) Settings() []brave.Setting {
+	if c.IsEmpty() || c.Validate() != nil {
+		return nil
+	}
+	if c.Dict {
+		d := map[string]interface{}{KeyMode: string(c.Mode)}
+		if c.Server != "" {
+			d[KeyServer] = c.Server
+		}
+		if c.PacURL != "" {
+			d[KeyPacURL] = c.PacURL
+		}
+		if len(c.Bypass) > 0 {
+			d[KeyBypassList] = strings.Join(c.Bypass, ",")
+		}
+		return []brave.Setting{{Key: KeySettings, Value: d, Type: brave.TypeDict}}
+	}
+	settings := []brave.Setting{{Key: KeyMode, Value: string(c.Mode), Type: brave.TypeString}}
+	if c.Server != "" {
+		settings = append(settings, brave.Setting{Key: KeyServer, Value: c.Server, Type: brave.TypeString})
+	}
+	if c.PacURL != "" {
+		settings = append(settings, brave.Setting{Key: KeyPacURL, Value: c.PacURL, Type: brave.TypeString})
+	}
+	if len(c.Bypass) > 0 {
+		settings = append(settings, brave.Setting{Key: KeyBypassList, Value: strings.Join(c.Bypass, ","), Type: brave.TypeString})
+	}
+	return settings
+}
+
+// Describe returns a one-line, human-readable summary of c.
+func (c Config) Describe() string {
+	switch c.Mode {
+	case ModeDirect:
+		return "never use a proxy (direct)"
+	case ModeSystem:
+		return "use the macOS system proxy settings"
+	case ModeAutoDetect:
+		return "auto-detect proxy (WPAD)"
+	case ModePacScript:
+		return "PAC script " + c.PacURL
+	case ModeFixedServers:
+		s := "fixed proxy " + c.Server
+		if len(c.Bypass) > 0 {
+			s += " (bypass: " + strings.Join(c.Bypass, ", ") + ")"
+		}
+		return s
+	case "":
+		return "not set"
+	}
+	return "unknown mode " + string(c.Mode)
+}
+
+// FromPolicy builds a Config from policy values read with read (e.g. brave.ReadManaged).
+// ProxySettings takes precedence over the individual keys, as in Chromium.
+// ok is false if no proxy policy is set.
+func FromPolicy(read func(key string) (string, bool)) (c Config, ok bool, err error) {
+	if raw, set := read(KeySettings); set {
+		v, err := brave.ParseDefaultsValue(raw)
+		if err != nil {
+			return Config{}, true, fmt.Errorf("%s: %w", KeySettings, err)
+		}
+		d, isDict := v.(map[string]interface{})
+		if !isDict {
+			return Config{}, true, fmt.Errorf("%s is not a dictionary", KeySettings)
+		}
+		str := func(k string) string { s, _ := d[k].(string); return s }
+		return Config{
+			Mode:   Mode(str(KeyMode)),
+			Server: str(KeyServer),
+			PacURL: str(KeyPacURL),
+			Bypass: splitBypass(str(KeyBypassList)),
+			Dict:   true,
+		}, true, nil
+	}
+	mode, modeSet := read(KeyMode)
+	server, serverSet := read(KeyServer)
+	pac, pacSet := read(KeyPacURL)
+	bypass, _ := read(KeyBypassList)
+	if !modeSet && !serverSet && !pacSet {
+		return Config{}, false, nil
+	}
+	c = Config{Mode: Mode(mode), Server: server, PacURL: pac, Bypass: splitBypass(bypass)}
+	if !modeSet {
+		// Chromium infers the mode from the other keys when ProxyMode is unset.
+		if serverSet {
+			c.Mode = ModeFixedServers
+		} else {
+			c.Mode = ModePacScript
+		}
+	}
+	return c, true, nil
+}
+
+func splitBypass(s string) []string {
+	var out []string
+	for _, b := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ';' }) {
+		if b = strings.TrimSpace(b); b != "" {
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
+// ValidateServer checks a ProxyServer value: "host:port", "scheme://host:port", or
+// semicolon-separated per-scheme rules such as "http=proxy:3128;https=proxy:3129".
+func ValidateServer(server string) error {
+	rules := strings.Split(server, ";")
+	for _, rule := range rules {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			return fmt.Errorf("proxy server %q: empty entry", server)
+		}
+		if i := strings.Index(rule, "="); i >= 0 && !strings.Contains(rule[:i], "/") {
+			if scheme := strings.ToLower(rule[:i]); !ruleSchemes[scheme] {
+				return fmt.Errorf("proxy server %q: unknown URL scheme %q in rule", server, rule[:i])
+			}
+			rule = rule[i+1:]
+		}
+		if err := validateHostPort(rule); err != nil {
+			return fmt.Errorf("proxy server %q: %w", server, err)
+		}
+	}
+	return nil
+}
+
+// validateHostPort checks "[scheme://]host[:port]".
+func validateHostPort(s string) error {
+	if i := strings.Index(s, "://"); i >= 0 {
+		if scheme := strings.ToLower(s[:i]); !serverSchemes[scheme] {
+			return fmt.Errorf("unsupported proxy scheme %q", s[:i])
+		}
+		s = s[i+3:]
+	}
+	host, port := s, ""
+	if h, p, err := net.SplitHostPort(s); err == nil {
+		host, port = h, p
+	}
+	if host == "" || strings.ContainsAny(host, "/ @?#") {
+		return fmt.Errorf("invalid proxy host %q", s)
+	}
+	if port != "" {
+		n, err := strconv.Atoi(port)
+		if err != nil || n < 1 || n > 65535 {
+			return fmt.Errorf("invalid proxy port %q", port)
+		}
+	}
+	return nil
+}
+
+// ValidatePacURL checks that u is an absolute http, https, file or data URL.
+func ValidatePacURL(u string) error {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return fmt.Errorf("PAC URL %q: %w", u, err)
+	}
+	switch parsed.Scheme {
+	case "http", "https":
+		if parsed.Host == "" {
+			return fmt.Errorf("PAC URL %q has no host", u)
+		}
+	case "file", "data":
+	default:
+		return fmt.Errorf("PAC URL %q must use http, https, file or data", u)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package proxy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cowardly/cowardly/internal/brave"
+)
+
+func TestValidate(t *testing.T) {
+	valid := []Config{
+		{Mode: ModeDirect},
+		{Mode: ModeSystem},
+		{Mode: ModeAutoDetect},
+		{Mode: ModePacScript, PacURL: "https://wpad.corp.example/proxy.pac"},
+		{Mode: ModeFixedServers, Server: "proxy.corp.example:3128"},
+		{Mode: ModeFixedServers, Server: "socks5://10.0.0.1:1080", Bypass: []string{"localhost", "*.corp.example", "10.0.0.0/8"}},
+		{Mode: ModeFixedServers, Server: "http=proxy:3128;https=proxy:3129"},
+		{Mode: ModeFixedServers, Server: "[::1]:8080"},
+	}
+	for _, c := range valid {
+		if err := c.Validate(); err != nil {
+			t.Errorf("Validate(%+v): %v", c, err)
+		}
+	}
+	invalid := []Config{
+		{},
+		{Mode: "manual"},
+		{Mode: ModeDirect, Server: "proxy:3128"},
+		{Mode: ModeSystem, Bypass: []string{"localhost"}},
+		{Mode: ModePacScript},
+		{Mode: ModePacScript, PacURL: "ftp://wpad/proxy.pac"},
+		{Mode: ModePacScript, PacURL: "https://wpad/proxy.pac", Server: "proxy:3128"},
+		{Mode: ModeFixedServers},
+		{Mode: ModeFixedServers, Server: "proxy:99999"},
+		{Mode: ModeFixedServers, Server: "gopher://proxy:70"},
+		{Mode: ModeFixedServers, Server: "smtp=proxy:25"},
+		{Mode: ModeFixedServers, Server: "proxy:3128", PacURL: "https://wpad/proxy.pac"},
+		{Mode: ModeFixedServers, Server: "proxy:3128", Bypass: []string{"a.example, b.example"}},
+	}
+	for _, c := range invalid {
+		if err := c.Validate(); err == nil {
+			t.Errorf("Validate(%+v): expected error", c)
+		}
+	}
+}
+
+func TestSettings(t *testing.T) {
+	c := Config{Mode: ModeFixedServers, Server: "proxy:3128", Bypass: []string{"localhost", "*.corp"}}
+	got := make(map[string]interface{})
+	for _, s := range c.Settings() {
+		got[s.Key] = s.Value
+	}
+	if got[KeyMode] != "fixed_servers" || got[KeyServer] != "proxy:3128" || got[KeyBypassList] != "localhost,*.corp" {
+		t.Errorf("Settings = %v", got)
+	}
+	if _, ok := got[KeyPacURL]; ok {
+		t.Errorf("unexpected %s", KeyPacURL)
+	}
+
+	c.Dict = true
+	settings := c.Settings()
+	if len(settings) != 1 || settings[0].Key != KeySettings || settings[0].Type != brave.TypeDict {
+		t.Fatalf("dict Settings = %+v", settings)
+	}
+	d := settings[0].Value.(map[string]interface{})
+	if d[KeyMode] != "fixed_servers" || d[KeyServer] != "proxy:3128" {
+		t.Errorf("ProxySettings = %v", d)
+	}
+
+	if got := (Config{Mode: ModeDirect, Server: "x:1"}).Settings(); got != nil {
+		t.Errorf("invalid config Settings = %v, want nil", got)
+	}
+}
+
+func TestFromPolicy(t *testing.T) {
+	read := func(values map[string]string) func(string) (string, bool) {
+		return func(k string) (string, bool) {
+			v, ok := values[k]
+			return v, ok
+		}
+	}
+	c, ok, err := FromPolicy(read(map[string]string{
+		KeySettings: `{ ProxyBypassList = "localhost,*.corp"; ProxyMode = "fixed_servers"; ProxyServer = "proxy:3128"; }`,
+		KeyMode:     "direct",
+	}))
+	if err != nil || !ok {
+		t.Fatalf("FromPolicy: ok=%v err=%v", ok, err)
+	}
+	if c.Mode != ModeFixedServers || c.Server != "proxy:3128" || len(c.Bypass) != 2 || !c.Dict {
+		t.Errorf("FromPolicy = %+v", c)
+	}
+	if d := c.Describe(); !strings.Contains(d, "proxy:3128") || !strings.Contains(d, "*.corp") {
+		t.Errorf("Describe = %q", d)
+	}
+
+	c, ok, _ = FromPolicy(read(map[string]string{KeyPacURL: "https://wpad/proxy.pac"}))
+	if !ok || c.Mode != ModePacScript {
+		t.Errorf("inferred mode = %+v, ok=%v", c, ok)
+	}
+
+	if _, ok, _ := FromPolicy(read(nil)); ok {
+		t.Error("expected ok=false when nothing is set")
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	if m, err := ParseMode("Fixed-Servers"); err != nil || m != ModeFixedServers {
+		t.Errorf("ParseMode = %q, %v", m, err)
+	}
+	if _, err := ParseMode("manual"); err == nil {
+		t.Error("expected error")
+	}
+}
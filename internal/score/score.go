@@ -0,0 +1,173 @@
+// Package score rates how locked-down Brave is: each Custom setting that has its debloat value counts
+// toward its category, weighted by category and key impact.
+package score
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cowardly/cowardly/internal/brave"
+	"github.com/cowardly/cowardly/internal/config"
+)
+
+// CategoryWeights is the weight of one setting in each category (config.CategoryOrder names).
+var CategoryWeights = map[string]int{
+	"Telemetry & Privacy": 3,
+	"Privacy & Security":  3,
+	"Brave Features":      2,
+	"Performance & Bloat": 1,
+}
+
+// KeyWeights multiplies the weight of individual keys. 0 leaves a key out of the score: those are
+// restrictions (parental controls, admin lockdown) rather than privacy or debloat settings.
+var KeyWeights = map[string]int{
+	"MetricsReportingEnabled":                 2,
+	"UrlKeyedAnonymizedDataCollectionEnabled": 2,
+	"WebRtcIPHandling":                        2,
+	"BlockThirdPartyCookies":                  2,
+	"ForceGoogleSafeSearch":                   0,
+	"IncognitoModeAvailability":               0,
+	"PrintingEnabled":                         0,
+	"DeveloperToolsDisabled":                  0,
+}
+
+// impact returns the points cs is worth.
+func impact(cs config.CustomSetting) int {
+	w := CategoryWeights[cs.Category]
+	if kw, ok := KeyWeights[cs.Key]; ok {
+		w *= kw
+	}
+	return w
+}
+
+// Category is the score of one category.
+type Category struct {
+	Name   string
+	Points int // points of settings at their debloat value
+	Max    int
+}
+
+// Percent returns the category score from 0 to 100.
+func (c Category) Percent() int {
+	if c.Max == 0 {
+		return 100
+	}
+	return c.Points * 100 / c.Max
+}
+
+// Missing is a setting that does not have its debloat value.
+type Missing struct {
+	Key      string
+	Label    string
+	Category string
+	Impact   int
+	Current  string // display value, or "(not set)"
+	Want     string
+}
+
+// Result is a full score.
+type Result struct {
+	Categories []Category // in config.CategoryOrder
+	Missing    []Missing  // highest impact first
+}
+
+// Total returns the overall score from 0 to 100.
+func (r Result) Total() int {
+	points, max := 0, 0
+	for _, c := range r.Categories {
+		points += c.Points
+		max += c.Max
+	}
+	if max == 0 {
+		return 100
+	}
+	return points * 100 / max
+}
+
+// Compute scores the state returned by read (e.g. brave.ReadCurrent).
+func Compute(read func(key string) (brave.Setting, bool)) Result {
+	byCat := make(map[string]*Category)
+	var r Result
+	for _, name := range config.CategoryOrder {
+		r.Categories = append(r.Categories, Category{Name: name})
+	}
+	for i := range r.Categories {
+		byCat[r.Categories[i].Name] = &r.Categories[i]
+	}
+	for _, cs := range config.CustomSettings() {
+		w := impact(cs)
+		c := byCat[cs.Category]
+		if w == 0 || c == nil {
+			continue
+		}
+		c.Max += w
+		want := brave.Setting{Key: cs.Key, Value: cs.Value, Type: cs.Type}
+		cur, ok := read(cs.Key)
+		if ok && brave.ValueString(cur) == brave.ValueString(want) {
+			c.Points += w
+			continue
+		}
+		m := Missing{Key: cs.Key, Label: cs.Label, Category: cs.Category, Impact: w, Current: "(not set)", Want: formatValue(want)}
+		if ok {
+			m.Current = formatValue(cur)
+		}
+		r.Missing = append(r.Missing, m)
+	}
+	sort.SliceStable(r.Missing, func(i, j int) bool {
+		return r.Missing[i].Impact > r.Missing[j].Impact
+	})
+	return r
+}
+
+// Current scores the effective state of Brave (managed overrides user).
+func Current() Result {
+	return Compute(brave.ReadCurrent)
+}
+
+// Summary returns one line, e.g. "Score 72/100 — Telemetry & Privacy 100%, Privacy & Security 58%, ...".
+func (r Result) Summary() string {
+	parts := make([]string, len(r.Categories))
+	for i, c := range r.Categories {
+		parts[i] = fmt.Sprintf("%s %d%%", c.Name, c.Percent())
+	}
+	return fmt.Sprintf("Score %d/100 — %s", r.Total(), strings.Join(parts, ", "))
+}
+
+// Format renders the per-category scores and the top missing settings (all if top <= 0).
+func (r Result) Format(top int) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Score: %d/100\n\n", r.Total()))
+	for _, c := range r.Categories {
+		b.WriteString(fmt.Sprintf("  %-22s %3d%%  %s\n", c.Name, c.Percent(), bar(c.Percent())))
+	}
+	if len(r.Missing) == 0 {
+		b.WriteString("\nAll scored settings are locked down.")
+		return b.String()
+	}
+	missing := r.Missing
+	if top > 0 && len(missing) > top {
+		missing = missing[:top]
+	}
+	b.WriteString(fmt.Sprintf("\nHighest-impact missing settings (%d of %d):\n", len(missing), len(r.Missing)))
+	for _, m := range missing {
+		b.WriteString(fmt.Sprintf("  +%d  %s (%s): %s, want %s\n", m.Impact, m.Label, m.Key, m.Current, m.Want))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// bar returns a 20-character bar for a percentage.
+func bar(percent int) string {
+	n := percent / 5
+	return strings.Repeat("█", n) + strings.Repeat("░", 20-n)
+}
+
+func formatValue(s brave.Setting) string {
+	if s.Type == brave.TypeBool {
+		if v, ok := s.Value.(bool); ok && v {
+			return "true"
+		}
+		return "false"
+	}
+	return brave.ValueString(s)
+}
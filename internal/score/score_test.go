@@ -0,0 +1,68 @@
+package score
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cowardly/cowardly/internal/brave"
+	"github.com/cowardly/cowardly/internal/config"
+)
+
+func TestCompute(t *testing.T) {
+	empty := Compute(func(string) (brave.Setting, bool) { return brave.Setting{}, false })
+	if empty.Total() != 0 {
+		t.Errorf("empty state: Total = %d, want 0", empty.Total())
+	}
+	if len(empty.Categories) != len(config.CategoryOrder) {
+		t.Fatalf("got %d categories, want %d", len(empty.Categories), len(config.CategoryOrder))
+	}
+	for i := 1; i < len(empty.Missing); i++ {
+		if empty.Missing[i].Impact > empty.Missing[i-1].Impact {
+			t.Fatalf("Missing not sorted by impact: %+v", empty.Missing)
+		}
+	}
+	if empty.Missing[0].Key != "MetricsReportingEnabled" {
+		t.Errorf("top missing = %s, want MetricsReportingEnabled", empty.Missing[0].Key)
+	}
+	for _, m := range empty.Missing {
+		if KeyWeights[m.Key] == 0 && hasKeyWeight(m.Key) {
+			t.Errorf("%s has weight 0 but is listed as missing", m.Key)
+		}
+	}
+
+	// Every Custom setting at its debloat value, read back the way `defaults read` reports it.
+	want := make(map[string]brave.Setting)
+	for _, cs := range config.CustomSettings() {
+		raw := brave.ValueString(brave.Setting{Key: cs.Key, Value: cs.Value, Type: cs.Type})
+		want[cs.Key] = brave.SettingFromDefaults(cs.Key, raw)
+	}
+	full := Compute(func(k string) (brave.Setting, bool) {
+		s, ok := want[k]
+		return s, ok
+	})
+	if full.Total() != 100 || len(full.Missing) != 0 {
+		t.Errorf("locked-down state: Total = %d, missing = %+v", full.Total(), full.Missing)
+	}
+
+	// Telemetry only.
+	telemetry := Compute(func(k string) (brave.Setting, bool) {
+		for _, cs := range config.CustomSettings() {
+			if cs.Key == k && cs.Category == "Telemetry & Privacy" {
+				return want[k], true
+			}
+		}
+		return brave.Setting{}, false
+	})
+	if telemetry.Categories[0].Percent() != 100 || telemetry.Categories[1].Percent() != 0 {
+		t.Errorf("telemetry only: %+v", telemetry.Categories)
+	}
+	out := telemetry.Format(3)
+	if !strings.Contains(out, "Highest-impact missing settings (3 of") || !strings.Contains(telemetry.Summary(), "Telemetry & Privacy 100%") {
+		t.Errorf("unexpected output:\n%s\n%s", out, telemetry.Summary())
+	}
+}
+
+func hasKeyWeight(key string) bool {
+	_, ok := KeyWeights[key]
+	return ok
+}
@@ -0,0 +1,156 @@
+// Package search provides the embedded default search provider catalog and expands
+// a provider selection into DefaultSearchProvider* policy settings.
+package search
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/cowardly/cowardly/configs"
+	"github.com/cowardly/cowardly/internal/brave"
+	"github.com/cowardly/cowardly/internal/catalog"
+)
+
+// Policy keys written for a default search provider.
+const (
+	KeyEnabled    = "DefaultSearchProviderEnabled"
+	KeyName       = "DefaultSearchProviderName"
+	KeyKeyword    = "DefaultSearchProviderKeyword"
+	KeySearchURL  = "DefaultSearchProviderSearchURL"
+	KeySuggestURL = "DefaultSearchProviderSuggestURL"
+)
+
+// Keys lists all policy keys managed by this package, in write order.
+var Keys = []string{KeyEnabled, KeyName, KeyKeyword, KeySearchURL, KeySuggestURL}
+
+// searchTermsPlaceholder is the Chromium placeholder that must appear in the search URL.
+const searchTermsPlaceholder = "{searchTerms}"
+
+// Variable is a value the user must supply for a provider (e.g. a self-hosted base URL).
+type Variable struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+}
+
+// Provider is one entry in the search provider catalog.
+type Provider struct {
+	ID         string     `yaml:"id"`
+	Name       string     `yaml:"name"`
+	Keyword    string     `yaml:"keyword"`
+	SearchURL  string     `yaml:"search_url"`
+	SuggestURL string     `yaml:"suggest_url"`
+	Variables  []Variable `yaml:"variables"`
+}
+
+// NeedsVariables returns true if the provider cannot be used without user-supplied values.
+func (p Provider) NeedsVariables() bool {
+	return len(p.Variables) > 0
+}
+
+// providers is the embedded search provider catalog, configs/search/providers.yaml.
+var providers = &catalog.Catalog[Provider]{
+	FS:    configs.SearchFS,
+	Path:  "search/providers.yaml",
+	Key:   "providers",
+	Name:  "search catalog",
+	Entry: "search provider",
+	ID:    func(p Provider) string { return p.ID },
+	Check: func(p Provider) error {
+		if p.ID == "" || p.Name == "" || p.SearchURL == "" {
+			return fmt.Errorf("id, name and search_url are required")
+		}
+		return nil
+	},
+}
+
+// Catalog returns the embedded search providers in file order.
+func Catalog() ([]Provider, error) {
+	return providers.Entries()
+}
+
+// Find returns the provider with the given id, or nil if not in the catalog.
+func Find(id string) *Provider {
+	return providers.Find(id)
+}
+
+// Settings expands provider id into DefaultSearchProvider* settings.
+// vars supplies values for provider variables (e.g. {"url": "https://searx.example.org"}).
+func Settings(id string, vars map[string]string) ([]brave.Setting, error) {
+	p := Find(id)
+	if p == nil {
+		return nil, fmt.Errorf("unknown search provider %q (known: %s)", id, strings.Join(IDs(), ", "))
+	}
+	for name := range vars {
+		if !p.hasVariable(name) {
+			return nil, fmt.Errorf("search provider %q: unknown variable %q", id, name)
+		}
+	}
+	searchURL, err := p.expand(p.SearchURL, vars)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateSearchURL(searchURL); err != nil {
+		return nil, fmt.Errorf("search provider %q: %w", id, err)
+	}
+	settings := []brave.Setting{
+		{Key: KeyEnabled, Value: true, Type: brave.TypeBool},
+		{Key: KeyName, Value: p.Name, Type: brave.TypeString},
+		{Key: KeyKeyword, Value: p.Keyword, Type: brave.TypeString},
+		{Key: KeySearchURL, Value: searchURL, Type: brave.TypeString},
+	}
+	if p.SuggestURL != "" {
+		suggestURL, err := p.expand(p.SuggestURL, vars)
+		if err != nil {
+			return nil, err
+		}
+		settings = append(settings, brave.Setting{Key: KeySuggestURL, Value: suggestURL, Type: brave.TypeString})
+	}
+	return settings, nil
+}
+
+// IDs returns the catalog provider ids, sorted.
+func IDs() []string {
+	return providers.IDs()
+}
+
+func (p Provider) hasVariable(name string) bool {
+	for _, v := range p.Variables {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// expand replaces ${name} in s with vars[name]; missing variables are an error.
+// Trailing slashes are trimmed from values so "${url}/search" does not produce "//search".
+func (p Provider) expand(s string, vars map[string]string) (string, error) {
+	trimmed := make(map[string]string, len(vars))
+	for name, v := range vars {
+		trimmed[name] = strings.TrimRight(strings.TrimSpace(v), "/")
+	}
+	out, missing := catalog.Expand(s, trimmed)
+	if len(missing) > 0 {
+		return "", fmt.Errorf("search provider %q: missing variable(s): %s", p.ID, strings.Join(missing, ", "))
+	}
+	return out, nil
+}
+
+// validateSearchURL checks that u is an absolute http(s) URL containing {searchTerms}.
+func validateSearchURL(u string) error {
+	if !strings.Contains(u, searchTermsPlaceholder) {
+		return fmt.Errorf("search URL %q must contain %s", u, searchTermsPlaceholder)
+	}
+	parsed, err := url.Parse(strings.ReplaceAll(u, searchTermsPlaceholder, "x"))
+	if err != nil {
+		return fmt.Errorf("search URL %q: %w", u, err)
+	}
+	if parsed.Scheme != "https" && parsed.Scheme != "http" {
+		return fmt.Errorf("search URL %q must use http or https", u)
+	}
+	if parsed.Host == "" {
+		return fmt.Errorf("search URL %q has no host", u)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package search
+
+import "testing"
+
+func TestCatalog(t *testing.T) {
+	list, err := Catalog()
+	if err != nil {
+		t.Fatalf("Catalog: %v", err)
+	}
+	for _, id := range []string{"brave", "duckduckgo", "startpage", "qwant", "ecosia", "searxng"} {
+		if Find(id) == nil {
+			t.Errorf("expected provider %q in catalog", id)
+		}
+	}
+	for _, p := range list {
+		if p.NeedsVariables() {
+			continue
+		}
+		if err := validateSearchURL(p.SearchURL); err != nil {
+			t.Errorf("provider %q: %v", p.ID, err)
+		}
+	}
+}
+
+func TestSettings(t *testing.T) {
+	settings, err := Settings("duckduckgo", nil)
+	if err != nil {
+		t.Fatalf("Settings: %v", err)
+	}
+	byKey := make(map[string]interface{})
+	for _, s := range settings {
+		byKey[s.Key] = s.Value
+	}
+	if byKey[KeyEnabled] != true {
+		t.Errorf("%s = %v, want true", KeyEnabled, byKey[KeyEnabled])
+	}
+	if byKey[KeyName] != "DuckDuckGo" {
+		t.Errorf("%s = %v, want DuckDuckGo", KeyName, byKey[KeyName])
+	}
+	if _, ok := byKey[KeySuggestURL]; !ok {
+		t.Errorf("expected %s", KeySuggestURL)
+	}
+}
+
+func TestSettingsVariables(t *testing.T) {
+	if _, err := Settings("searxng", nil); err == nil {
+		t.Error("expected error for missing url variable")
+	}
+	if _, err := Settings("searxng", map[string]string{"url": "ftp://searx.example.org"}); err == nil {
+		t.Error("expected error for non-http url")
+	}
+	if _, err := Settings("duckduckgo", map[string]string{"url": "https://x"}); err == nil {
+		t.Error("expected error for unknown variable")
+	}
+	settings, err := Settings("searxng", map[string]string{"url": "https://searx.example.org/"})
+	if err != nil {
+		t.Fatalf("Settings: %v", err)
+	}
+	for _, s := range settings {
+		if s.Key == KeySearchURL && s.Value != "https://searx.example.org/search?q={searchTerms}" {
+			t.Errorf("search URL = %v", s.Value)
+		}
+	}
+}
+
+func TestSettingsUnknown(t *testing.T) {
+	if _, err := Settings("altavista", nil); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+}
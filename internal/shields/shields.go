@@ -0,0 +1,180 @@
+// Package shields maps human-readable Brave Shields levels (e.g. fingerprinting: standard) to the
+// integer DefaultBrave*Setting policies, validates those integers, and names them for display.
+package shields
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cowardly/cowardly/internal/brave"
+)
+
+// Shields policy keys.
+const (
+	KeyAdblock          = "DefaultBraveAdblockSetting"
+	KeyCosmetic         = "DefaultBraveCosmeticFilteringSetting"
+	KeyFingerprinting   = "DefaultBraveFingerprintingV2Setting"
+	KeyHTTPSUpgrade     = "DefaultBraveHttpsUpgradeSetting"
+	KeyForgetFirstParty = "DefaultBraveRemember1PStorageSetting"
+	KeyReferrers        = "DefaultBraveReferrersSetting"
+)
+
+// Value is a named integer value of a Shields policy.
+type Value struct {
+	Name string
+	Int  int
+}
+
+// Policy is one integer Shields policy and the names of its values.
+// Brave stores Shields as content settings: 1 = allow, 2 = block, 3 = ask (used for "standard").
+type Policy struct {
+	Key    string
+	Label  string
+	Values []Value
+}
+
+// Policies lists the Shields policies, in display order.
+var Policies = []Policy{
+	{KeyAdblock, "Trackers & ads blocking", []Value{{"allow", 1}, {"block", 2}}},
+	{KeyCosmetic, "Cosmetic filtering", []Value{{"allow", 1}, {"aggressive", 2}, {"standard", 3}}},
+	{KeyFingerprinting, "Fingerprinting protection", []Value{{"off", 1}, {"strict", 2}, {"standard", 3}}},
+	{KeyHTTPSUpgrade, "Upgrade connections to HTTPS", []Value{{"off", 1}, {"strict", 2}, {"standard", 3}}},
+	{KeyForgetFirstParty, "Forget me when I close this site", []Value{{"off", 1}, {"on", 2}}},
+	{KeyReferrers, "Cross-site referrers", []Value{{"allow", 1}, {"block", 2}}},
+}
+
+// ValueNames returns the level names of the Shields policies, for labelling their raw values.
+func ValueNames() brave.ValueNames {
+	out := make(brave.ValueNames, len(Policies))
+	for _, p := range Policies {
+		names := make(map[string]string, len(p.Values))
+		for _, v := range p.Values {
+			names[strconv.Itoa(v.Int)] = v.Name
+		}
+		out[p.Key] = names
+	}
+	return out
+}
+
+// Keys returns the Shields policy keys.
+func Keys() []string {
+	keys := make([]string, len(Policies))
+	for i, p := range Policies {
+		keys[i] = p.Key
+	}
+	return keys
+}
+
+// Find returns the Shields policy for key, or nil if key is not a Shields policy.
+func Find(key string) *Policy {
+	for i := range Policies {
+		if Policies[i].Key == key {
+			return &Policies[i]
+		}
+	}
+	return nil
+}
+
+// Name returns the name of integer value n, or "" if n is not valid for p.
+func (p Policy) Name(n int) string {
+	for _, v := range p.Values {
+		if v.Int == n {
+			return v.Name
+		}
+	}
+	return ""
+}
+
+// Resolve returns the integer for v, which may be a valid integer or a value name ("standard").
+func (p Policy) Resolve(v interface{}) (int, error) {
+	var n int
+	switch x := v.(type) {
+	case int:
+		n = x
+	case int64:
+		n = int(x)
+	case float64:
+		n = int(x)
+	case string:
+		s := strings.ToLower(strings.TrimSpace(x))
+		for _, val := range p.Values {
+			if val.Name == s {
+				return val.Int, nil
+			}
+		}
+		parsed, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, fmt.Errorf("%s: unknown level %q (use %s)", p.Key, x, p.names())
+		}
+		n = parsed
+	default:
+		return 0, fmt.Errorf("%s: cannot use %T as a Shields level", p.Key, v)
+	}
+	if p.Name(n) == "" {
+		return 0, fmt.Errorf("%s: invalid value %d (use %s)", p.Key, n, p.names())
+	}
+	return n, nil
+}
+
+// names returns "allow (1), block (2)" for error messages.
+func (p Policy) names() string {
+	parts := make([]string, len(p.Values))
+	for i, v := range p.Values {
+		parts[i] = fmt.Sprintf("%s (%d)", v.Name, v.Int)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Config is the `shields:` section of preset YAML.
+type Config struct {
+	Ads              string `yaml:"ads,omitempty"`                // allow | block | aggressive
+	Fingerprinting   string `yaml:"fingerprinting,omitempty"`     // off | standard | strict
+	HTTPS            string `yaml:"https,omitempty"`              // off | standard | strict
+	ForgetFirstParty string `yaml:"forget_first_party,omitempty"` // off | on
+	Referrers        string `yaml:"referrers,omitempty"`          // allow | block
+}
+
+// Settings converts c into the integer Shields policies.
+// ads: aggressive also sets aggressive cosmetic filtering; block keeps Brave's standard cosmetic filtering.
+func (c Config) Settings() ([]brave.Setting, error) {
+	var out []brave.Setting
+	add := func(key, level string) error {
+		if level == "" {
+			return nil
+		}
+		n, err := Find(key).Resolve(level)
+		if err != nil {
+			return err
+		}
+		out = append(out, brave.Setting{Key: key, Value: n, Type: brave.TypeInteger})
+		return nil
+	}
+	switch strings.ToLower(c.Ads) {
+	case "":
+	case "allow", "block":
+		if err := add(KeyAdblock, c.Ads); err != nil {
+			return nil, err
+		}
+	case "aggressive":
+		if err := add(KeyAdblock, "block"); err != nil {
+			return nil, err
+		}
+		if err := add(KeyCosmetic, "aggressive"); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("shields.ads: unknown level %q (use allow, block, aggressive)", c.Ads)
+	}
+	for _, f := range []struct{ key, level string }{
+		{KeyFingerprinting, c.Fingerprinting},
+		{KeyHTTPSUpgrade, c.HTTPS},
+		{KeyForgetFirstParty, c.ForgetFirstParty},
+		{KeyReferrers, c.Referrers},
+	} {
+		if err := add(f.key, f.level); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,69 @@
+package shields
+
+import (
+	"testing"
+
+	"github.com/cowardly/cowardly/internal/brave"
+)
+
+func TestConfigSettings(t *testing.T) {
+	c := Config{Ads: "aggressive", Fingerprinting: "strict", HTTPS: "standard", ForgetFirstParty: "on"}
+	settings, err := c.Settings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{
+		KeyAdblock:          2,
+		KeyCosmetic:         2,
+		KeyFingerprinting:   2,
+		KeyHTTPSUpgrade:     3,
+		KeyForgetFirstParty: 2,
+	}
+	if len(settings) != len(want) {
+		t.Fatalf("got %d settings, want %d: %+v", len(settings), len(want), settings)
+	}
+	for _, s := range settings {
+		if s.Type != brave.TypeInteger || s.Value != want[s.Key] {
+			t.Errorf("%s = %v (%s), want %d", s.Key, s.Value, s.Type, want[s.Key])
+		}
+	}
+
+	for _, bad := range []Config{{Ads: "standard"}, {Fingerprinting: "aggressive"}, {HTTPS: "5"}, {ForgetFirstParty: "yes"}} {
+		if _, err := bad.Settings(); err == nil {
+			t.Errorf("Settings(%+v): expected error", bad)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	p := Find(KeyFingerprinting)
+	if p == nil {
+		t.Fatal("fingerprinting policy not found")
+	}
+	for _, v := range []interface{}{"standard", "Standard", 3, "3", float64(3)} {
+		if n, err := p.Resolve(v); err != nil || n != 3 {
+			t.Errorf("Resolve(%v) = %d, %v; want 3", v, n, err)
+		}
+	}
+	for _, v := range []interface{}{0, 4, "block", true} {
+		if _, err := p.Resolve(v); err == nil {
+			t.Errorf("Resolve(%v): expected error", v)
+		}
+	}
+	if Find("DefaultCookiesSetting") != nil {
+		t.Error("DefaultCookiesSetting is not a Shields policy")
+	}
+}
+
+func TestValueNames(t *testing.T) {
+	names := ValueNames()
+	if got := names.Label(KeyFingerprinting, "3"); got != "3 (standard)" {
+		t.Errorf("Label = %q", got)
+	}
+	if got := names.Label(KeyFingerprinting, "9"); got != "9" {
+		t.Errorf("Label(invalid) = %q", got)
+	}
+	if got := names.Label("TorDisabled", "1"); got != "1" {
+		t.Errorf("Label(other key) = %q", got)
+	}
+}
@@ -0,0 +1,231 @@
+// Package startup compiles the `startup:` YAML section (what Brave opens on launch, the homepage and
+// the Home button) into RestoreOnStartup, RestoreOnStartupURLs, HomepageLocation, HomepageIsNewTabPage
+// and ShowHomeButton.
+package startup
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/cowardly/cowardly/internal/brave"
+)
+
+// Policy keys.
+const (
+	KeyRestoreOnStartup     = "RestoreOnStartup"
+	KeyRestoreOnStartupURLs = "RestoreOnStartupURLs"
+	KeyHomepageLocation     = "HomepageLocation"
+	KeyHomepageIsNewTabPage = "HomepageIsNewTabPage"
+	KeyShowHomeButton       = "ShowHomeButton"
+)
+
+// Keys lists the policies this package writes.
+var Keys = []string{KeyRestoreOnStartup, KeyRestoreOnStartupURLs, KeyHomepageLocation, KeyHomepageIsNewTabPage, KeyShowHomeButton}
+
+// OnStartup is a named RestoreOnStartup value.
+type OnStartup string
+
+const (
+	RestoreSession        OnStartup = "restore_session"          // 1: continue where you left off
+	OpenURLs              OnStartup = "urls"                     // 4: open RestoreOnStartupURLs
+	NewTabPage            OnStartup = "new_tab"                  // 5: open the New Tab page
+	RestoreSessionAndURLs OnStartup = "restore_session_and_urls" // 6: restore the session and open RestoreOnStartupURLs
+)
+
+// NeedsURLs returns true if o opens RestoreOnStartupURLs (urls, restore_session_and_urls).
+func (o OnStartup) NeedsURLs() bool {
+	return o == OpenURLs || o == RestoreSessionAndURLs
+}
+
+// onStartupValues maps each OnStartup to its RestoreOnStartup integer, in display order.
+var onStartupValues = []struct {
+	Name OnStartup
+	Int  int
+}{
+	{RestoreSession, 1},
+	{OpenURLs, 4},
+	{NewTabPage, 5},
+	{RestoreSessionAndURLs, 6},
+}
+
+// HomepageNewTab is the `homepage` value that makes the New Tab page the homepage.
+const HomepageNewTab = "new_tab"
+
+// ValueNames returns the on_startup names of the RestoreOnStartup values, for labelling raw values.
+func ValueNames() brave.ValueNames {
+	names := make(map[string]string, len(onStartupValues))
+	for _, v := range onStartupValues {
+		names[strconv.Itoa(v.Int)] = string(v.Name)
+	}
+	return brave.ValueNames{KeyRestoreOnStartup: names}
+}
+
+// ResolveOnStartup returns the RestoreOnStartup integer for v, a name ("restore_session") or a valid integer.
+func ResolveOnStartup(v interface{}) (int, error) {
+	var n int
+	switch x := v.(type) {
+	case int:
+		n = x
+	case int64:
+		n = int(x)
+	case float64:
+		n = int(x)
+	case string:
+		s := strings.ToLower(strings.TrimSpace(x))
+		for _, val := range onStartupValues {
+			if string(val.Name) == s {
+				return val.Int, nil
+			}
+		}
+		parsed, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, fmt.Errorf("%s: unknown value %q (use restore_session, urls, new_tab, restore_session_and_urls)", KeyRestoreOnStartup, x)
+		}
+		n = parsed
+	default:
+		return 0, fmt.Errorf("%s: cannot use %T", KeyRestoreOnStartup, v)
+	}
+	for _, val := range onStartupValues {
+		if val.Int == n {
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("%s: invalid value %d (use 1 = restore_session, 4 = urls, 5 = new_tab, 6 = restore_session_and_urls)", KeyRestoreOnStartup, n)
+}
+
+// Config is the `startup:` section of preset YAML.
+type Config struct {
+	OnStartup      OnStartup `yaml:"on_startup,omitempty"`
+	URLs           []string  `yaml:"urls,omitempty"`             // pages to open when on_startup is urls or restore_session_and_urls
+	Homepage       string    `yaml:"homepage,omitempty"`         // a URL, or new_tab
+	ShowHomeButton *bool     `yaml:"show_home_button,omitempty"` // nil = not managed
+}
+
+// IsEmpty returns true if c manages nothing.
+func (c Config) IsEmpty() bool {
+	return c.OnStartup == "" && len(c.URLs) == 0 && c.Homepage == "" && c.ShowHomeButton == nil
+}
+
+// Validate checks the startup mode, that urls is set exactly when on_startup opens URLs (urls,
+// restore_session_and_urls), and every URL.
+func (c Config) Validate() error {
+	if c.OnStartup != "" {
+		if _, err := ResolveOnStartup(string(c.OnStartup)); err != nil {
+			return fmt.Errorf("startup.on_startup: unknown value %q (use restore_session, urls, new_tab, restore_session_and_urls)", c.OnStartup)
+		}
+	}
+	if c.OnStartup.NeedsURLs() && len(c.URLs) == 0 {
+		return fmt.Errorf("startup: on_startup %s needs at least one URL in urls", c.OnStartup)
+	}
+	if len(c.URLs) > 0 && !c.OnStartup.NeedsURLs() {
+		return fmt.Errorf("startup: urls is only used with on_startup: urls or restore_session_and_urls")
+	}
+	for _, u := range c.URLs {
+		if err := ValidateURL(u); err != nil {
+			return fmt.Errorf("startup.urls: %w", err)
+		}
+	}
+	if c.Homepage != "" && c.Homepage != HomepageNewTab {
+		if err := ValidateURL(c.Homepage); err != nil {
+			return fmt.Errorf("startup.homepage: %w", err)
+		}
+	}
+	return nil
+}
+
+// Settings compiles c into policies. An invalid config yields no settings.
+func (c Config) Settings() []brave.Setting {
+	if c.IsEmpty() || c.Validate() != nil {
+		return nil
+	}
+	var out []brave.Setting
+	if c.OnStartup != "" {
+		n, _ := ResolveOnStartup(string(c.OnStartup))
+		out = append(out, brave.Setting{Key: KeyRestoreOnStartup, Value: n, Type: brave.TypeInteger})
+	}
+	if len(c.URLs) > 0 {
+		l := make([]interface{}, len(c.URLs))
+		for i, u := range c.URLs {
+			l[i] = u
+		}
+		out = append(out, brave.Setting{Key: KeyRestoreOnStartupURLs, Value: l, Type: brave.TypeList})
+	}
+	switch c.Homepage {
+	case "":
+	case HomepageNewTab:
+		out = append(out, brave.Setting{Key: KeyHomepageIsNewTabPage, Value: true, Type: brave.TypeBool})
+	default:
+		out = append(out,
+			brave.Setting{Key: KeyHomepageIsNewTabPage, Value: false, Type: brave.TypeBool},
+			brave.Setting{Key: KeyHomepageLocation, Value: c.Homepage, Type: brave.TypeString})
+	}
+	if c.ShowHomeButton != nil {
+		out = append(out, brave.Setting{Key: KeyShowHomeButton, Value: *c.ShowHomeButton, Type: brave.TypeBool})
+	}
+	return out
+}
+
+// Describe returns a one-line summary of c, e.g. "restore session; homepage https://intranet.example".
+func (c Config) Describe() string {
+	if c.IsEmpty() {
+		return "not managed"
+	}
+	var parts []string
+	switch c.OnStartup {
+	case RestoreSession:
+		parts = append(parts, "restore session")
+	case OpenURLs:
+		parts = append(parts, "open "+strings.Join(c.URLs, ", "))
+	case RestoreSessionAndURLs:
+		parts = append(parts, "restore session and open "+strings.Join(c.URLs, ", "))
+	case NewTabPage:
+		parts = append(parts, "open New Tab page")
+	}
+	switch c.Homepage {
+	case "":
+	case HomepageNewTab:
+		parts = append(parts, "homepage New Tab page")
+	default:
+		parts = append(parts, "homepage "+c.Homepage)
+	}
+	if c.ShowHomeButton != nil {
+		if *c.ShowHomeButton {
+			parts = append(parts, "Home button shown")
+		} else {
+			parts = append(parts, "Home button hidden")
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// ParseURLs splits a comma- or space-separated list of URLs and validates each one.
+func ParseURLs(s string) ([]string, error) {
+	var out []string
+	for _, f := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+		if err := ValidateURL(f); err != nil {
+			return nil, err
+		}
+		out = append(out, f)
+	}
+	return out, nil
+}
+
+// ValidateURL checks that u is an absolute http(s) URL with a host.
+func ValidateURL(u string) error {
+	if u == "" || strings.ContainsAny(u, " \t\n") {
+		return fmt.Errorf("invalid URL %q", u)
+	}
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return fmt.Errorf("invalid URL %q: %v", u, err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("URL %q: must start with http:// or https://", u)
+	}
+	if parsed.Hostname() == "" {
+		return fmt.Errorf("URL %q: missing host", u)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package startup
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestSettings(t *testing.T) {
+	var c Config
+	src := `
+on_startup: restore_session
+homepage: https://intranet.example/dashboard
+show_home_button: true
+`
+	if err := yaml.Unmarshal([]byte(src), &c); err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]interface{})
+	for _, s := range c.Settings() {
+		got[s.Key] = s.Value
+	}
+	want := map[string]interface{}{
+		KeyRestoreOnStartup:     1,
+		KeyHomepageIsNewTabPage: false,
+		KeyHomepageLocation:     "https://intranet.example/dashboard",
+		KeyShowHomeButton:       true,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %v, want %v", k, got[k], v)
+		}
+	}
+
+	c = Config{OnStartup: OpenURLs, URLs: []string{"https://a.example", "http://b.example:8080/x"}, Homepage: HomepageNewTab}
+	got = make(map[string]interface{})
+	for _, s := range c.Settings() {
+		got[s.Key] = s.Value
+	}
+	if got[KeyRestoreOnStartup] != 4 || got[KeyHomepageIsNewTabPage] != true || got[KeyHomepageLocation] != nil {
+		t.Errorf("unexpected settings: %v", got)
+	}
+	if l, ok := got[KeyRestoreOnStartupURLs].([]interface{}); !ok || len(l) != 2 {
+		t.Errorf("%s = %v", KeyRestoreOnStartupURLs, got[KeyRestoreOnStartupURLs])
+	}
+
+	c = Config{OnStartup: RestoreSessionAndURLs, URLs: []string{"https://dashboard.example"}}
+	got = make(map[string]interface{})
+	for _, s := range c.Settings() {
+		got[s.Key] = s.Value
+	}
+	if got[KeyRestoreOnStartup] != 6 || got[KeyRestoreOnStartupURLs] == nil {
+		t.Errorf("restore_session_and_urls settings: %v", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	invalid := []Config{
+		{OnStartup: "resume"},
+		{OnStartup: OpenURLs},
+		{OnStartup: RestoreSessionAndURLs},
+		{OnStartup: RestoreSession, URLs: []string{"https://a.example"}},
+		{OnStartup: OpenURLs, URLs: []string{"intranet.example"}},
+		{OnStartup: OpenURLs, URLs: []string{"javascript:alert(1)"}},
+		{Homepage: "ftp://files.example"},
+		{Homepage: "https://"},
+	}
+	for _, c := range invalid {
+		if err := c.Validate(); err == nil {
+			t.Errorf("Validate(%+v): expected error", c)
+		}
+		if s := c.Settings(); s != nil {
+			t.Errorf("Settings(%+v) = %v, want nil", c, s)
+		}
+	}
+}
+
+func TestResolveOnStartup(t *testing.T) {
+	for v, want := range map[interface{}]int{"restore_session": 1, "urls": 4, "New_Tab": 5, 5: 5, "4": 4} {
+		if n, err := ResolveOnStartup(v); err != nil || n != want {
+			t.Errorf("ResolveOnStartup(%v) = %d, %v; want %d", v, n, err, want)
+		}
+	}
+	for _, v := range []interface{}{0, 2, 3, "session", true} {
+		if _, err := ResolveOnStartup(v); err == nil {
+			t.Errorf("ResolveOnStartup(%v): expected error", v)
+		}
+	}
+	if got := ValueNames().Label(KeyRestoreOnStartup, "1"); got != "1 (restore_session)" {
+		t.Errorf("Label = %q", got)
+	}
+}
+
+func TestParseURLs(t *testing.T) {
+	urls, err := ParseURLs("https://a.example, https://b.example  https://c.example")
+	if err != nil || len(urls) != 3 {
+		t.Errorf("ParseURLs = %v, %v", urls, err)
+	}
+	if _, err := ParseURLs("https://a.example, b.example"); err == nil {
+		t.Error("expected error for URL without scheme")
+	}
+}
@@ -6,11 +6,21 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/cowardly/cowardly/internal/bookmarks"
 	"github.com/cowardly/cowardly/internal/brave"
+	"github.com/cowardly/cowardly/internal/compare"
 	"github.com/cowardly/cowardly/internal/config"
+	"github.com/cowardly/cowardly/internal/content"
+	"github.com/cowardly/cowardly/internal/doh"
+	"github.com/cowardly/cowardly/internal/extensions"
+	"github.com/cowardly/cowardly/internal/history"
+	"github.com/cowardly/cowardly/internal/lock"
 	"github.com/cowardly/cowardly/internal/presets"
+	"github.com/cowardly/cowardly/internal/score"
+	"github.com/cowardly/cowardly/internal/search"
 	"github.com/cowardly/cowardly/internal/userconfig"
 	"github.com/mattn/go-runewidth"
 )
@@ -19,15 +29,20 @@ type applyPresetMsg struct{ idx int }
 type applyPrivacyGuidesMsg struct{ basePresetID string }
 type privacyGuidesCheckBaseMsg struct{ basePresetID string }
 type applyCustomMsg struct{}
+type applyExtensionsMsg struct{}
+type applyBookmarksMsg struct{}
+type applyDNSMsg struct{ sel doh.Selection }
+type applyContentMsg struct{}
+type applyOverridesMsg struct{}
 type resetDoneMsg struct {
 	err            error
-	backupPath     string
+	backupMsg      string // from model.backup
 	hadManaged     bool
 	managedRemoved bool
 }
 type backupsListMsg struct {
-	paths []string
-	err   error
+	backups []brave.Backup
+	err     error
 }
 type backupDoneMsg struct {
 	err error
@@ -36,6 +51,11 @@ type backupDoneMsg struct {
 type settingsRevertedMsg struct {
 	reverted bool
 	preset   string
+	target   brave.Target // scope the desired state was last applied to
+	profile  string       // active profile in cowardly.yaml
+}
+type scoreMsg struct {
+	result score.Result
 }
 type reapplyDoneMsg struct {
 	managed bool
@@ -44,17 +64,23 @@ type reapplyDoneMsg struct {
 	preset  string
 }
 
+// loadScore scores the current state for the main screen.
+func loadScore() tea.Msg {
+	return scoreMsg{result: score.Current()}
+}
+
 func (m model) Init() tea.Cmd {
-	return func() tea.Msg {
-		desired, err := userconfig.Read()
-		if err != nil || desired == nil || len(desired.Settings) == 0 {
-			return settingsRevertedMsg{reverted: false}
+	return tea.Batch(loadScore, func() tea.Msg {
+		profile, _ := m.store.ActiveProfile()
+		desired, err := m.store.Read()
+		if err != nil || desired == nil || len(desired.Effective()) == 0 {
+			return settingsRevertedMsg{reverted: false, profile: profile}
 		}
-		if brave.Diff(desired.Settings) != "" {
-			return settingsRevertedMsg{reverted: true, preset: desired.Preset}
+		if brave.DiffIn(desired.Effective(), desired.Target, presets.ValueNames()) != "" {
+			return settingsRevertedMsg{reverted: true, preset: desired.Preset, target: desired.Target, profile: profile}
 		}
-		return settingsRevertedMsg{reverted: false}
-	}
+		return settingsRevertedMsg{reverted: false, profile: profile}
+	})
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -67,18 +93,33 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			switch msg.String() {
 			case "q", "ctrl+c":
 				return m, tea.Quit
+			case "c":
+				if len(m.channels) > 1 {
+					return m.nextChannel()
+				}
 			case "r", "R":
 				if m.settingsReverted {
 					return m, func() tea.Msg {
-						desired, err := userconfig.Read()
+						l, st, err := m.lockState()
 						if err != nil {
 							return reapplyDoneMsg{err: err}
 						}
-						if desired == nil || len(desired.Settings) == 0 {
-							return reapplyDoneMsg{err: fmt.Errorf("desired state not found in ~/.config/cowardly/cowardly.yaml")}
+						defer l.Release()
+						desired, err := st.Read()
+						if err != nil {
+							return reapplyDoneMsg{err: err}
+						}
+						if desired == nil || len(desired.Effective()) == 0 {
+							return reapplyDoneMsg{err: fmt.Errorf("desired state not found in cowardly.yaml")}
+						}
+						settings := desired.Effective()
+						before := history.Take()
+						managed, err := brave.ApplySettingsTo(l, settings, brave.ResolveTarget(desired.Target))
+						_ = history.Record(m.paths, history.ActionReapply, desired.Preset, before)
+						if err == nil {
+							_ = st.WriteTarget(managed)
 						}
-						managed, err := brave.ApplySettings(desired.Settings)
-						return reapplyDoneMsg{managed: managed, err: err, n: len(desired.Settings), preset: desired.Preset}
+						return reapplyDoneMsg{managed: managed, err: err, n: len(settings), preset: desired.Preset}
 					}
 				}
 			case "enter":
@@ -90,7 +131,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					return m, nil
 				case 1:
 					return m, func() tea.Msg {
-						base, _ := userconfig.PrivacyGuidesBaseFromConfig()
+						base, _ := m.store.PrivacyGuidesBaseFromConfig()
 						return privacyGuidesCheckBaseMsg{basePresetID: base}
 					}
 				case 2:
@@ -98,18 +139,55 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.customIdx = 0
 					return m, nil
 				case 3:
+					desired, _ := m.store.Read()
+					m.extPolicy = extensions.Policy{}
+					if desired != nil {
+						m.extPolicy = desired.Extensions
+					}
+					m.extIDs = extensionRows(m.extPolicy)
+					m.extIdx = 0
+					m.state = stateExtensions
+					return m, nil
+				case 4:
+					desired, _ := m.store.Read()
+					m.bmTree = bookmarks.Tree{TopLevelName: bookmarks.DefaultTopLevelName}
+					if desired != nil && !desired.Bookmarks.IsEmpty() {
+						m.bmTree = desired.Bookmarks
+					}
+					m.bmIdx = 0
+					m.bmErr = ""
+					m.state = stateBookmarks
+					return m, nil
+				case 5:
+					m.state = stateDNSPicker
+					m.dnsList.ResetSelected()
+					return m, nil
+				case 6:
+					desired, _ := m.store.Read()
+					m.contentCfg = make(content.Config)
+					if desired != nil {
+						m.contentCfg = desired.Content.Clone()
+					}
+					m.contentIdx = 0
+					m.contentErr = ""
+					m.contentDiff = ""
+					m.state = stateContent
+					return m, nil
+				case 7:
 					m.state = stateViewSettings
 					m.viewScroll = 0
 					return m, nil
-				case 4:
+				case 8:
+					return m.openCompare()
+				case 9:
 					m.state = stateResetConfirm
 					return m, nil
-				case 5:
+				case 10:
 					return m, func() tea.Msg {
-						paths, err := brave.ListBackups()
-						return backupsListMsg{paths: paths, err: err}
+						backups, err := brave.ListBackups(m.paths)
+						return backupsListMsg{backups: backups, err: err}
 					}
-				case 6:
+				case 11:
 					return m, tea.Quit
 				}
 			}
@@ -138,14 +216,14 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			switch msg.String() {
 			case "q", "esc":
 				m.state = stateMain
-				m.presetList.SetItems(presetListItems(false))
+				m.presetList.SetItems(presetListItems(nil))
 				m.privacyGuidesBasePresetID = ""
 				return m, nil
 			case "enter":
 				idx := m.presetList.Index()
 				if idx == 0 {
 					m.state = stateMain
-					m.presetList.SetItems(presetListItems(false))
+					m.presetList.SetItems(presetListItems(nil))
 					m.privacyGuidesBasePresetID = ""
 					return m, nil
 				}
@@ -212,14 +290,146 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.customToggles[i] = false
 				}
 				return m, nil
+			case "s":
+				m.state = stateSearchPicker
+				m.searchList.ResetSelected()
+				return m, nil
+			case "h":
+				return m.startStartupWizard()
+			}
+			return m, nil
+
+		case stateSearchPicker:
+			switch msg.String() {
+			case "q", "esc":
+				m.state = stateCustom
+				return m, nil
+			case "enter":
+				idx := m.searchList.Index()
+				if idx == 0 {
+					m.customSearchID = ""
+					m.customSearchVars = nil
+					m.state = stateCustom
+					return m, nil
+				}
+				providers, _ := search.Catalog()
+				if idx < 1 || idx > len(providers) {
+					return m, nil
+				}
+				p := providers[idx-1]
+				if p.NeedsVariables() {
+					m.searchPending = p.ID
+					m.searchErr = ""
+					m.searchInput.Reset()
+					m.searchInput.Placeholder = p.Variables[0].Description
+					m.searchInput.Focus()
+					m.state = stateSearchVar
+					return m, textinput.Blink
+				}
+				m.customSearchID = p.ID
+				m.customSearchVars = nil
+				m.state = stateCustom
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.searchList, cmd = m.searchList.Update(msg)
+			return m, cmd
+
+		case stateSearchVar:
+			switch msg.String() {
+			case "esc":
+				m.searchInput.Blur()
+				m.state = stateSearchPicker
+				return m, nil
+			case "enter":
+				p := search.Find(m.searchPending)
+				if p == nil || !p.NeedsVariables() {
+					m.state = stateSearchPicker
+					return m, nil
+				}
+				vars := map[string]string{p.Variables[0].Name: strings.TrimSpace(m.searchInput.Value())}
+				if _, err := search.Settings(p.ID, vars); err != nil {
+					m.searchErr = err.Error()
+					return m, nil
+				}
+				m.customSearchID = p.ID
+				m.customSearchVars = vars
+				m.searchInput.Blur()
+				m.state = stateCustom
+				return m, nil
+			}
+			var cmd tea.Cmd
+			m.searchInput, cmd = m.searchInput.Update(msg)
+			return m, cmd
+
+		case stateExtensions:
+			n := len(m.extIDs)
+			switch msg.String() {
+			case "q", "esc":
+				m.state = stateMain
+				return m, nil
+			case "enter":
+				return m, func() tea.Msg { return applyExtensionsMsg{} }
+			case "up", "k":
+				if m.extIdx > 0 {
+					m.extIdx--
+				}
+				return m, nil
+			case "down", "j":
+				if m.extIdx < n-1 {
+					m.extIdx++
+				}
+				return m, nil
+			case "f", "b", "a", "x", " ":
+				if m.extIdx < 0 || m.extIdx >= n {
+					return m, nil
+				}
+				mode := map[string]extensions.Mode{"f": extensions.ModeForce, "b": extensions.ModeBlock, "a": extensions.ModeAllow}[msg.String()]
+				_ = m.extPolicy.Set(m.extIDs[m.extIdx], mode) // force on the wildcard is ignored
+				return m, nil
 			}
 			return m, nil
 
+		case stateBookmarks:
+			return m.updateBookmarks(msg)
+
+		case stateBookmarkEdit:
+			return m.updateBookmarkEdit(msg)
+
+		case stateDNSPicker:
+			return m.updateDNSPicker(msg)
+
+		case stateDNSVar:
+			return m.updateDNSVar(msg)
+
+		case stateContent:
+			return m.updateContent(msg)
+
+		case stateContentAdd:
+			return m.updateContentAdd(msg)
+
+		case stateOverrides:
+			return m.updateOverrides(msg)
+
+		case stateOverrideAdd:
+			return m.updateOverrideAdd(msg)
+
+		case stateStartup:
+			return m.updateStartup(msg)
+
+		case stateComparePick:
+			return m.updateComparePick(msg)
+
+		case stateCompare:
+			return m.updateCompare(msg)
+
 		case stateViewSettings:
 			switch msg.String() {
 			case "q", "esc", "enter":
 				m.state = stateMain
 				return m, nil
+			case "o":
+				return m.openOverrides()
 			case "up", "k":
 				if m.viewScroll > 0 {
 					m.viewScroll--
@@ -243,9 +453,16 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					return m, nil
 				}
 				return m, func() tea.Msg {
-					backupPath, _ := brave.BackupUserPlist()
-					hadManaged, managedRemoved, err := brave.Reset()
-					return resetDoneMsg{err: err, backupPath: backupPath, hadManaged: hadManaged, managedRemoved: managedRemoved}
+					l, err := lock.Acquire(m.paths.State)
+					if err != nil {
+						return resetDoneMsg{err: err}
+					}
+					defer l.Release()
+					backupMsg := m.backup(l, brave.BackupInfo{Reason: brave.BackupReset})
+					before := history.Take()
+					hadManaged, managedRemoved, err := brave.Reset(l)
+					_ = history.Record(m.paths, history.ActionReset, "", before)
+					return resetDoneMsg{err: err, backupMsg: backupMsg, hadManaged: hadManaged, managedRemoved: managedRemoved}
 				}
 			case "n", "N", "q", "esc":
 				m.state = stateMain
@@ -254,38 +471,36 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 
 		case stateBackups:
+			// While typing a filter, every key goes to the list (Enter accepts the filter, esc drops it).
+			if m.backupList.FilterState() == list.Filtering {
+				var cmd tea.Cmd
+				m.backupList, cmd = m.backupList.Update(msg)
+				return m, tea.Batch(cmd, m.syncBackupPreview())
+			}
 			switch msg.String() {
 			case "q", "esc":
+				if m.backupList.FilterState() == list.FilterApplied {
+					m.backupList.ResetFilter()
+					return m, m.syncBackupPreview()
+				}
 				m.state = stateMain
 				return m, nil
-			case "enter":
-				if len(m.backupPaths) == 0 {
-					return m, nil
-				}
-				idx := m.backupList.Index()
-				if idx < 0 || idx >= len(m.backupPaths) {
+			case "enter", "d":
+				sel, ok := m.backupList.SelectedItem().(backupItem)
+				if !ok {
 					return m, nil
 				}
-				m.confirmPath = m.backupPaths[idx]
+				m.confirmPath = sel.backup.Path
 				m.confirmAction = "restore"
-				m.state = stateBackupConfirm
-				return m, nil
-			case "d":
-				if len(m.backupPaths) == 0 {
-					return m, nil
+				if msg.String() == "d" {
+					m.confirmAction = "delete"
 				}
-				idx := m.backupList.Index()
-				if idx < 0 || idx >= len(m.backupPaths) {
-					return m, nil
-				}
-				m.confirmPath = m.backupPaths[idx]
-				m.confirmAction = "delete"
 				m.state = stateBackupConfirm
 				return m, nil
 			}
 			var cmd tea.Cmd
 			m.backupList, cmd = m.backupList.Update(msg)
-			return m, cmd
+			return m, tea.Batch(cmd, m.syncBackupPreview())
 
 		case stateBackupConfirm:
 			switch msg.String() {
@@ -296,7 +511,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					var err error
 					var doneMsg string
 					if action == "restore" {
-						err = brave.RestoreFromBackup(path)
+						err = m.restore(path)
 						if err == nil {
 							doneMsg = "Restored backup. Restart Brave for changes to take effect."
 						}
@@ -328,8 +543,8 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.state = statePrivacyGuidesBase
 			m.presetList.ResetSelected()
 			m.privacyGuidesBasePresetID = ""
-			baseItems := presetListItems(true)
-			desired, _ := userconfig.Read()
+			desired, _ := m.store.Read()
+			baseItems := presetListItems(desired)
 			m.privacyGuidesHasCustom = desired != nil && desired.Preset == "custom" && len(desired.Settings) > 0
 			m.presetList.SetItems(baseItems)
 		}
@@ -341,6 +556,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.mainList.SetSize(msg.Width/2, msg.Height/2)
 		m.presetList.SetSize(msg.Width/2, msg.Height/2)
 		m.backupList.SetSize(msg.Width/2, msg.Height/2)
+		m.searchList.SetSize(msg.Width/2, msg.Height/2)
+		m.dnsList.SetSize(msg.Width/2, msg.Height/2)
+		m.compareList.SetSize(msg.Width/2, msg.Height/2)
 		return m, nil
 
 	case applyPresetMsg:
@@ -355,16 +573,25 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		} else {
 			m.msg = ""
 		}
-		if path, err := brave.BackupUserPlist(); err == nil {
-			m.msg += fmt.Sprintf("Backed up to:\n%s\n\n", path)
+		l, st, err := m.lockState()
+		if err != nil {
+			m.err = err.Error()
+			m.msg = ""
+			m.state = stateMain
+			return m, nil
 		}
-		managed, err := brave.ApplySettings(p.Settings)
+		defer l.Release()
+		m.msg += m.backup(l, brave.BackupInfo{Reason: brave.BackupApply, Source: p.ID})
+		before := history.Take()
+		managed, err := brave.ApplySettings(l, st.WithLayers(p.Settings))
+		_ = history.Record(m.paths, history.ActionApply, p.ID, before)
 		if err != nil {
 			m.err = err.Error()
 			m.msg = ""
 		} else {
 			m.settingsReverted = false
-			_ = userconfig.WritePreset(p.ID, p.Settings)
+			_ = st.WritePreset(p.ID, p.Settings)
+			_ = st.WriteTarget(managed)
 			if managed {
 				m.msg += fmt.Sprintf("Applied preset: %s (enforced). Restart Brave for changes.", p.Name)
 			} else {
@@ -372,7 +599,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 		m.state = stateMain
-		return m, nil
+		return m, loadScore
 
 	case applyPrivacyGuidesMsg:
 		baseID := msg.basePresetID
@@ -382,7 +609,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		var settings []brave.Setting
 		var err error
 		if baseID == "custom" {
-			desired, _ := userconfig.Read()
+			desired, _ := m.store.Read()
 			if desired == nil || len(desired.Settings) == 0 {
 				m.err = "No custom settings in config to use as base"
 				m.state = stateMain
@@ -408,16 +635,25 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		} else {
 			m.msg = ""
 		}
-		if path, err := brave.BackupUserPlist(); err == nil {
-			m.msg += fmt.Sprintf("Backed up to:\n%s\n\n", path)
+		l, st, err := m.lockState()
+		if err != nil {
+			m.err = err.Error()
+			m.msg = ""
+			m.state = stateMain
+			return m, nil
 		}
-		managed, err := brave.ApplySettings(settings)
+		defer l.Release()
+		m.msg += m.backup(l, brave.BackupInfo{Reason: brave.BackupApply, Source: "privacy-guides:" + baseID})
+		before := history.Take()
+		managed, err := brave.ApplySettings(l, st.WithLayers(settings))
+		_ = history.Record(m.paths, history.ActionApply, "privacy-guides:"+baseID, before)
 		if err != nil {
 			m.err = err.Error()
 			m.msg = ""
 		} else {
 			m.settingsReverted = false
-			_ = userconfig.WritePrivacyGuides(baseID)
+			_ = st.WritePrivacyGuides(baseID)
+			_ = st.WriteTarget(managed)
 			if managed {
 				m.msg += fmt.Sprintf("Applied Privacy Guides recommendations (enforced). Restart Brave for changes.\n\nSource: %s", presets.PrivacyGuidesURL)
 			} else {
@@ -425,7 +661,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 		m.state = stateMain
-		return m, nil
+		return m, loadScore
 
 	case applyCustomMsg:
 		var toApply []brave.Setting
@@ -434,6 +670,16 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				toApply = append(toApply, brave.Setting{Key: cs.Key, Value: cs.Value, Type: cs.Type})
 			}
 		}
+		if m.customSearchID != "" {
+			searchSettings, err := search.Settings(m.customSearchID, m.customSearchVars)
+			if err != nil {
+				m.err = err.Error()
+				m.state = stateMain
+				return m, nil
+			}
+			toApply = presets.MergeSettingsWithSupplement(toApply, searchSettings)
+		}
+		toApply = presets.MergeSettingsWithSupplement(toApply, m.customStartup.Settings())
 		if len(toApply) == 0 {
 			m.msg = "No settings selected. Toggle with Space, Apply with Enter."
 			m.state = stateMain
@@ -444,16 +690,25 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		} else {
 			m.msg = ""
 		}
-		if path, err := brave.BackupUserPlist(); err == nil {
-			m.msg += fmt.Sprintf("Backed up to:\n%s\n\n", path)
+		l, st, err := m.lockState()
+		if err != nil {
+			m.err = err.Error()
+			m.msg = ""
+			m.state = stateMain
+			return m, nil
 		}
-		managed, err := brave.ApplySettings(toApply)
+		defer l.Release()
+		m.msg += m.backup(l, brave.BackupInfo{Reason: brave.BackupApply, Source: "custom"})
+		before := history.Take()
+		managed, err := brave.ApplySettings(l, st.WithLayers(toApply))
+		_ = history.Record(m.paths, history.ActionApply, "custom", before)
 		if err != nil {
 			m.err = err.Error()
 			m.msg = ""
 		} else {
 			m.settingsReverted = false
-			_ = userconfig.WriteSettings(toApply)
+			_ = st.WriteSettings(toApply)
+			_ = st.WriteTarget(managed)
 			if managed {
 				m.msg += fmt.Sprintf("Applied %d setting(s) (enforced). Restart Brave for changes.", len(toApply))
 			} else {
@@ -461,15 +716,58 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 		m.state = stateMain
+		return m, loadScore
+
+	case applyExtensionsMsg:
+		if err := m.store.WriteExtensions(m.extPolicy); err != nil {
+			m.err = err.Error()
+			m.state = stateMain
+			return m, nil
+		}
+		m.applyDesiredState("extension policy")
+		return m, nil
+
+	case applyBookmarksMsg:
+		if err := m.store.WriteBookmarks(m.bmTree); err != nil {
+			m.err = err.Error()
+			m.state = stateMain
+			return m, nil
+		}
+		m.applyDesiredState("managed bookmarks")
+		return m, nil
+
+	case applyDNSMsg:
+		if err := m.store.WriteDNS(msg.sel); err != nil {
+			m.err = err.Error()
+			m.state = stateMain
+			return m, nil
+		}
+		m.applyDesiredState("DNS over HTTPS")
+		return m, nil
+
+	case applyContentMsg:
+		if err := m.store.WriteContent(m.contentCfg); err != nil {
+			m.err = err.Error()
+			m.state = stateMain
+			return m, nil
+		}
+		m.applyDesiredState("content settings")
+		return m, nil
+
+	case applyOverridesMsg:
+		if err := m.store.WriteOverrides(m.overrides); err != nil {
+			m.err = err.Error()
+			m.state = stateMain
+			return m, nil
+		}
+		m.applyDesiredState("overrides")
 		return m, nil
 
 	case resetDoneMsg:
 		if msg.err != nil {
 			m.err = msg.err.Error()
 		} else {
-			if msg.backupPath != "" {
-				m.msg = "Backed up to:\n" + msg.backupPath + "\n\n"
-			}
+			m.msg = msg.backupMsg
 			if !msg.hadManaged {
 				m.msg += "User preferences cleared. No managed policy file was present, so no authentication was needed. Restart Brave."
 			} else if msg.managedRemoved {
@@ -479,7 +777,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 		m.state = stateMain
-		return m, nil
+		return m, loadScore
 
 	case backupsListMsg:
 		if msg.err != nil {
@@ -487,19 +785,26 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.state = stateMain
 			return m, nil
 		}
-		m.backupPaths = msg.paths
-		items := make([]list.Item, len(msg.paths))
-		for i, p := range msg.paths {
-			items[i] = item{title: filepath.Base(p), desc: p}
+		m.backups = msg.backups
+		items := make([]list.Item, len(msg.backups))
+		for i, b := range msg.backups {
+			items[i] = backupItem{backup: b}
 		}
 		m.backupList = list.New(items, braveListDelegate(), 0, 0)
-		m.backupList.Title = "Backups (Enter restore, d delete, esc back)"
+		m.backupList.Title = "Backups (Enter restore, d delete, / filter, esc back)"
 		m.backupList.Styles = braveListStyles()
 		m.backupList.SetShowStatusBar(false)
 		if m.width > 0 && m.height > 0 {
 			m.backupList.SetSize(m.width/2, m.height/2)
 		}
 		m.state = stateBackups
+		m.backupPreviewPath = ""
+		return m, m.syncBackupPreview()
+
+	case backupPreviewMsg:
+		if msg.path == m.backupPreviewPath {
+			m.backupPreview = msg.text
+		}
 		return m, nil
 
 	case backupDoneMsg:
@@ -511,11 +816,17 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		} else {
 			m.msg = msg.msg
 		}
+		return m, loadScore
+
+	case scoreMsg:
+		m.score = &msg.result
 		return m, nil
 
 	case settingsRevertedMsg:
 		m.settingsReverted = msg.reverted
 		m.revertedPreset = msg.preset
+		m.revertedTarget = msg.target
+		m.profile = msg.profile
 		return m, nil
 
 	case reapplyDoneMsg:
@@ -533,7 +844,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.managed {
 			m.msg += " (Enforced.)"
 		}
-		return m, nil
+		return m, loadScore
 	}
 
 	return m, nil
@@ -554,17 +865,36 @@ func (m model) View() string {
 	switch m.state {
 	case stateMain:
 		mainView := titleStyle.Render(tuiTitle()) + "\n"
+		if len(m.channels) > 1 {
+			mainView += dimStyle.Render("Channel: "+brave.CurrentVariant().AppName()+" (c to switch; each channel has its own saved state and backups)") + "\n\n"
+		}
+		if m.profile != "" && m.profile != userconfig.DefaultProfile {
+			mainView += dimStyle.Render("Profile: "+m.profile+" (switch with: cowardly profile switch <name>)") + "\n\n"
+		}
 		if m.settingsReverted {
-			revertHint := "Settings may have been reverted (e.g. after restart). Press " + activeStyle.Render("R") + " to re-apply your saved preset."
+			where := ""
+			switch m.revertedTarget {
+			case brave.TargetManaged:
+				where = " in the managed plist"
+			case brave.TargetUser:
+				where = " in user preferences"
+			}
+			revertHint := "Settings may have been reverted" + where + " (e.g. after restart). Press " + activeStyle.Render("R") + " to re-apply your saved preset."
 			if m.revertedPreset != "" {
-				revertHint = "Settings may have been reverted. Press " + activeStyle.Render("R") + " to re-apply preset \"" + m.revertedPreset + "\"."
+				revertHint = "Settings may have been reverted" + where + ". Press " + activeStyle.Render("R") + " to re-apply preset \"" + m.revertedPreset + "\"."
 			}
 			mainView += dimStyle.Render(revertHint) + "\n\n"
 		}
+		if m.score != nil {
+			mainView += dimStyle.Render(m.score.Summary()) + "\n\n"
+		}
 		mainView += m.mainList.View() + dimStyle.Render("\n↑/k up  ↓/j down  enter select  q quit")
 		if m.settingsReverted {
 			mainView += dimStyle.Render("  r re-apply")
 		}
+		if len(m.channels) > 1 {
+			mainView += dimStyle.Render("  c channel")
+		}
 		return mainView
 	case statePreset:
 		return titleStyle.Render("Choose a preset") + "\n" + m.presetList.View() + dimStyle.Render("\nenter apply  esc back")
@@ -590,8 +920,34 @@ func (m model) View() string {
 			"Press " + activeStyle.Render("y") + " or " + activeStyle.Render("Enter") + " to apply, " + activeStyle.Render("n") + " or " + activeStyle.Render("Esc") + " to go back."
 	case stateCustom:
 		return m.customView()
+	case stateSearchPicker:
+		return titleStyle.Render("Custom — Default search provider") + "\n" + m.searchList.View() + dimStyle.Render("\nenter select  esc back")
+	case stateSearchVar:
+		name := m.searchPending
+		if p := search.Find(m.searchPending); p != nil {
+			name = p.Name
+		}
+		v := titleStyle.Render("Custom — "+name) + "\n\n" + m.searchInput.View() + "\n\n"
+		if m.searchErr != "" {
+			v += errorStyle.Render(m.searchErr) + "\n\n"
+		}
+		return v + dimStyle.Render("enter confirm  esc back")
 	case stateViewSettings:
 		return m.viewSettingsView()
+	case stateExtensions:
+		return m.extensionsView()
+	case stateBookmarks, stateBookmarkEdit:
+		return m.bookmarksView()
+	case stateDNSPicker, stateDNSVar:
+		return m.dnsView()
+	case stateContent, stateContentAdd:
+		return m.contentView()
+	case stateOverrides, stateOverrideAdd:
+		return m.overridesView()
+	case stateStartup:
+		return m.startupView()
+	case stateComparePick, stateCompare:
+		return m.compareView()
 	case stateResetConfirm:
 		return titleStyle.Render("Reset all settings?") + "\n\n" +
 			"This will remove ALL Brave policy settings and restore defaults.\n\n" +
@@ -599,15 +955,25 @@ func (m model) View() string {
 			dimStyle.Render("You will only see an authentication dialog if a managed policy file exists.\n") + "\n" +
 			"Press " + activeStyle.Render("y") + " or " + activeStyle.Render("Enter") + " to confirm, " + activeStyle.Render("n") + " or " + activeStyle.Render("Esc") + " to cancel."
 	case stateBackups:
-		if len(m.backupPaths) == 0 {
+		if len(m.backups) == 0 {
 			return titleStyle.Render("Backups") + "\n\n" + dimStyle.Render("No backups yet. Apply a preset or reset to create one.") + "\n\n" + dimStyle.Render("esc back")
 		}
-		return titleStyle.Render("Backups") + "\n" + m.backupList.View() + dimStyle.Render("\nEnter restore  d delete  esc back")
+		return titleStyle.Render("Backups") + "\n" + lipgloss.JoinHorizontal(lipgloss.Top, m.backupList.View(), m.backupPreviewView()) +
+			dimStyle.Render("\nEnter restore  d delete  / filter  esc back")
 	case stateBackupConfirm:
 		name := filepath.Base(m.confirmPath)
 		if m.confirmAction == "restore" {
+			over := "current user preferences"
+			if b, err := brave.OpenBackup(m.confirmPath); err == nil && b.Managed != "" {
+				over = "the current managed plist (authentication dialog) and user preferences"
+				if b.User == "" {
+					over = "the current managed plist (authentication dialog)"
+				}
+			} else if err == nil && b.RemovesManaged() && brave.ManagedPlistExists() {
+				over = "current user preferences, removing the managed plist (authentication dialog)"
+			}
 			return titleStyle.Render("Restore backup?") + "\n\n" +
-				"Restore " + activeStyle.Render(name) + " over current user preferences.\n\n" +
+				"Restore " + activeStyle.Render(name) + " over " + over + ".\n\n" +
 				"Press " + activeStyle.Render("y") + " or " + activeStyle.Render("Enter") + " to restore, " + activeStyle.Render("n") + " or " + activeStyle.Render("Esc") + " to cancel."
 		}
 		return titleStyle.Render("Delete backup?") + "\n\n" +
@@ -622,9 +988,27 @@ func (m model) customView() string {
 	var b strings.Builder
 	b.WriteString(titleStyle.Render("Custom — Toggle settings (Space), Apply (Enter)"))
 	b.WriteString("\n")
-	b.WriteString(dimStyle.Render("[a] select all  [n] select none  [esc] back"))
+	b.WriteString(dimStyle.Render("[a] select all  [n] select none  [s] default search  [h] startup & homepage  [esc] back"))
 	b.WriteString("\n\n") // raw newlines so the next line is not inside any style
 
+	searchName := "Unchanged"
+	if p := search.Find(m.customSearchID); p != nil {
+		searchName = p.Name
+		for _, v := range p.Variables {
+			if val := m.customSearchVars[v.Name]; val != "" {
+				searchName += " (" + val + ")"
+			}
+		}
+	}
+	b.WriteString("Default search: " + activeStyle.Render(searchName))
+	b.WriteString("\n")
+	startupDesc := "Unchanged"
+	if !m.customStartup.IsEmpty() {
+		startupDesc = m.customStartup.Describe()
+	}
+	b.WriteString("Startup & homepage: " + activeStyle.Render(startupDesc))
+	b.WriteString("\n\n")
+
 	// Inline(true) prevents block-level reflow so the category stays at column 0
 	catStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#ff631c")).Inline(true)
 	byCat := config.CustomSettingsByCategory()
@@ -650,10 +1034,104 @@ func (m model) customView() string {
 		}
 		b.WriteString("\n")
 	}
-	b.WriteString(dimStyle.Render("↑/k up  ↓/j down  space toggle  enter apply  esc back"))
+	b.WriteString(dimStyle.Render("↑/k up  ↓/j down  space toggle  s search  h startup  enter apply  esc back"))
+	return b.String()
+}
+
+func (m model) extensionsView() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Extensions — Force-install, block, or allow"))
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render("[f] force-install  [b] block  [a] allow  [x] unmanage  [esc] back (discard)"))
+	b.WriteString("\n\n")
+	modeStyle := lipgloss.NewStyle().Width(7).Inline(true)
+	for i, id := range m.extIDs {
+		cursor := " "
+		if i == m.extIdx {
+			cursor = activeStyle.Render(">")
+		}
+		mode := string(m.extPolicy.ModeOf(id))
+		label := extensions.NameFor(id)
+		if label == "" {
+			label = id
+		}
+		switch m.extPolicy.ModeOf(id) {
+		case extensions.ModeForce, extensions.ModeAllow:
+			mode = checkStyle.Render(modeStyle.Render(mode))
+		case extensions.ModeBlock:
+			mode = errorStyle.Render(modeStyle.Render(mode))
+		default:
+			mode = dimStyle.Render(modeStyle.Render("-"))
+		}
+		b.WriteString(fmt.Sprintf("  %s %s %s\n", cursor, mode, label))
+	}
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render("↑/k up  ↓/j down  enter save & apply  esc back"))
 	return b.String()
 }
 
+// nextChannel switches to the next installed channel and reloads what the main screen shows for it.
+func (m model) nextChannel() (tea.Model, tea.Cmd) {
+	next := m.channels[0]
+	for i, v := range m.channels {
+		if v == brave.CurrentVariant() && i+1 < len(m.channels) {
+			next = m.channels[i+1]
+		}
+	}
+	brave.UseVariant(next)
+	m.mainList.Title = tuiTitle()
+	m.score = nil
+	m.settingsReverted = false
+	m.revertedPreset = ""
+	m.revertedTarget = ""
+	return m, m.Init()
+}
+
+// applyDesiredState backs up user prefs and applies the full desired state after a layer (what) was saved,
+// deleting the keys of emptied layers from user prefs.
+func (m *model) applyDesiredState(what string) {
+	l, st, err := m.lockState()
+	if err != nil {
+		m.err = err.Error()
+		m.msg = ""
+		m.state = stateMain
+		return
+	}
+	defer l.Release()
+	desired, _ := st.Read()
+	var settings []brave.Setting
+	var saved brave.Target
+	if desired != nil {
+		settings = desired.Effective()
+		saved = desired.Target
+	}
+	if brave.BraveRunning() {
+		m.msg = "Brave is running — quit for a clean apply. "
+	} else {
+		m.msg = ""
+	}
+	m.msg += m.backup(l, brave.BackupInfo{Reason: brave.BackupApply, Source: what})
+	before := history.Take()
+	managed, err := brave.ApplySettingsTo(l, settings, brave.ResolveTarget(saved))
+	if err == nil {
+		err = brave.DeleteAll(userconfig.StaleLayerKeys(settings))
+	}
+	_ = history.Record(m.paths, history.ActionApply, what, before)
+	if err != nil {
+		m.err = err.Error()
+		m.msg = ""
+		m.state = stateMain
+		return
+	}
+	_ = st.WriteTarget(managed)
+	if managed {
+		m.msg += "Applied " + what + " (enforced). Restart Brave for changes."
+	} else {
+		m.msg += "Applied " + what + ". Restart Brave. For enforced policies, approve the macOS authentication dialog when you apply."
+	}
+	m.state = stateMain
+}
+
 func (m model) viewSettingsView() string {
 	headerStyle := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#ff631c")).Inline(true)
 	var b strings.Builder
@@ -671,9 +1149,11 @@ func (m model) viewSettingsView() string {
 		}
 	}
 	prefixRaw := "  ✓ " // unstyled for width calculation
+	names := presets.ValueNames()
 	for _, key := range m.viewKeys {
 		managedVal, managedOK := brave.ReadManaged(key)
 		userVal, userOK := brave.Read(key)
+		managedVal, userVal = names.Label(key, managedVal), names.Label(key, userVal)
 		paddedKey := key + strings.Repeat(" ", maxKeyWidth-runewidth.StringWidth(key))
 		var valuePart, suffix string
 		var line string
@@ -708,7 +1188,57 @@ func (m model) viewSettingsView() string {
 			b.WriteString(line)
 		}
 	}
+	if desired, _ := m.store.Read(); desired != nil && len(desired.Overrides) > 0 {
+		b.WriteString("\n")
+		b.WriteString(headerStyle.Render("Overrides (saved, applied on top of the preset)"))
+		b.WriteString("\n")
+		for _, s := range desired.Overrides {
+			b.WriteString("  " + s.Key + " = " + compare.FormatValue(s) + "\n")
+		}
+	}
 	b.WriteString("\n")
-	b.WriteString(dimStyle.Render("q/esc back"))
+	b.WriteString(dimStyle.Render("o edit overrides  q/esc back"))
 	return b.String()
 }
+
+// lockState takes the cowardly lock for a change to the Brave preferences and returns it with a store
+// whose config writes run under it. The caller releases the lock.
+func (m model) lockState() (*lock.Lock, userconfig.Store, error) {
+	l, err := lock.Acquire(m.paths.State)
+	if err != nil {
+		return nil, userconfig.Store{}, err
+	}
+	return l, m.store.Holding(l), nil
+}
+
+// restore puts the backup at path back and journals it, holding the cowardly lock throughout.
+func (m model) restore(path string) error {
+	l, err := lock.Acquire(m.paths.State)
+	if err != nil {
+		return err
+	}
+	defer l.Release()
+	before := history.Take()
+	err = brave.RestoreFromBackup(l, path)
+	_ = history.Record(m.paths, history.ActionRestore, filepath.Base(path), before)
+	return err
+}
+
+// backup backs up the Brave preferences before a change (l is the cowardly lock the change holds),
+// pruning old backups by the retention policy in cowardly.yaml (none if it cannot be read), and returns
+// the message to show; "" if the backup failed.
+func (m model) backup(l *lock.Lock, info brave.BackupInfo) string {
+	r, err := m.store.Retention()
+	if err != nil {
+		r = brave.Retention{}
+	}
+	path, pruned, err := brave.CreateBackup(l, m.paths, r, info)
+	if err != nil {
+		return ""
+	}
+	out := fmt.Sprintf("Backed up to:\n%s\n\n", path)
+	if len(pruned) > 0 {
+		out += fmt.Sprintf("Pruned %d old backup(s) (%s).\n\n", len(pruned), r)
+	}
+	return out
+}
@@ -0,0 +1,49 @@
+package ui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/cowardly/cowardly/internal/compare"
+)
+
+// backupPreviewMsg carries what restoring the backup at path would change.
+type backupPreviewMsg struct {
+	path string
+	text string
+}
+
+// syncBackupPreview starts loading the preview of the selected backup if it is not the one shown.
+func (m *model) syncBackupPreview() tea.Cmd {
+	sel, ok := m.backupList.SelectedItem().(backupItem)
+	if !ok {
+		m.backupPreviewPath, m.backupPreview = "", ""
+		return nil
+	}
+	if sel.backup.Path == m.backupPreviewPath {
+		return nil
+	}
+	path := sel.backup.Path
+	m.backupPreviewPath, m.backupPreview = path, "Comparing with the current preferences…"
+	return func() tea.Msg {
+		d, err := compare.DiffBackup(m.paths, path)
+		if err != nil {
+			return backupPreviewMsg{path: path, text: "Preview failed: " + err.Error()}
+		}
+		return backupPreviewMsg{path: path, text: d.Format()}
+	}
+}
+
+// backupPreviewView renders the preview pane beside the backups list, cut to the list's height.
+func (m model) backupPreviewView() string {
+	width := m.width - m.width/2 - 4
+	if width < 20 {
+		width = 20
+	}
+	lines := strings.Split(lipgloss.NewStyle().Width(width).Render(m.backupPreview), "\n")
+	if h := m.height / 2; h > 2 && len(lines) > h {
+		lines = append(lines[:h-1], dimStyle.Render("…"))
+	}
+	return lipgloss.NewStyle().PaddingLeft(2).Render(strings.Join(lines, "\n"))
+}
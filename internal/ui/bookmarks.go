@@ -0,0 +1,189 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/cowardly/cowardly/internal/bookmarks"
+)
+
+// updateBookmarks handles keys in the managed bookmarks tree view.
+func (m model) updateBookmarks(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	rows := m.bmTree.Flatten()
+	switch msg.String() {
+	case "q", "esc":
+		m.state = stateMain
+		return m, nil
+	case "enter":
+		if err := m.bmTree.Validate(); err != nil {
+			m.bmErr = err.Error()
+			return m, nil
+		}
+		return m, func() tea.Msg { return applyBookmarksMsg{} }
+	case "up", "k":
+		if m.bmIdx > 0 {
+			m.bmIdx--
+		}
+	case "down", "j":
+		if m.bmIdx < len(rows) {
+			m.bmIdx++
+		}
+	case "l":
+		return m.startBookmarkEdit("link", ""), nil
+	case "f":
+		return m.startBookmarkEdit("folder", ""), nil
+	case "e", "r":
+		if m.bmIdx == 0 {
+			return m.startBookmarkEdit("title", m.bmTree.TopLevelName), nil
+		}
+		return m.startBookmarkEdit("edit", rows[m.bmIdx-1].Node.Name), nil
+	case "x", "delete", "backspace":
+		if m.bmIdx == 0 {
+			return m, nil
+		}
+		if err := m.bmTree.Delete(rows[m.bmIdx-1].Path); err != nil {
+			m.bmErr = err.Error()
+			return m, nil
+		}
+		if m.bmIdx > len(m.bmTree.Flatten()) {
+			m.bmIdx--
+		}
+	}
+	m.bmErr = ""
+	return m, nil
+}
+
+// startBookmarkEdit switches to the text input for kind ("link", "folder", "edit" or "title"), prefilled with name.
+func (m model) startBookmarkEdit(kind, name string) model {
+	m.bmEditKind = kind
+	m.bmEditStep = 0
+	m.bmEditName = ""
+	m.bmErr = ""
+	m.bmInput.SetValue(name)
+	m.bmInput.Placeholder = "Name"
+	m.bmInput.CursorEnd()
+	m.bmInput.Focus()
+	m.state = stateBookmarkEdit
+	return m
+}
+
+// updateBookmarkEdit handles the name/URL input for adding or editing a node.
+func (m model) updateBookmarkEdit(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.bmInput.Blur()
+		m.bmErr = ""
+		m.state = stateBookmarks
+		return m, nil
+	case "enter":
+		val := strings.TrimSpace(m.bmInput.Value())
+		if val == "" {
+			m.bmErr = "value cannot be empty"
+			return m, nil
+		}
+		rows := m.bmTree.Flatten()
+		var sel *bookmarks.Row
+		if m.bmIdx > 0 && m.bmIdx <= len(rows) {
+			sel = &rows[m.bmIdx-1]
+		}
+		needsURL := m.bmEditKind == "link" || (m.bmEditKind == "edit" && sel != nil && !sel.Node.IsFolder())
+		if m.bmEditStep == 0 && needsURL {
+			m.bmEditName = val
+			m.bmEditStep = 1
+			m.bmInput.SetValue("https://")
+			if m.bmEditKind == "edit" {
+				m.bmInput.SetValue(sel.Node.URL)
+			}
+			m.bmInput.Placeholder = "https://example.com"
+			m.bmInput.CursorEnd()
+			m.bmErr = ""
+			return m, nil
+		}
+		var err error
+		switch m.bmEditKind {
+		case "title":
+			m.bmTree.TopLevelName = val
+		case "folder":
+			err = m.bmTree.Insert(rowPath(sel), bookmarks.Node{Name: val})
+		case "link":
+			if err = bookmarks.ValidateURL(val); err == nil {
+				err = m.bmTree.Insert(rowPath(sel), bookmarks.Node{Name: m.bmEditName, URL: val})
+			}
+		case "edit":
+			if sel == nil {
+				break
+			}
+			if sel.Node.IsFolder() {
+				err = m.bmTree.Update(sel.Path, val, "")
+			} else {
+				err = m.bmTree.Update(sel.Path, m.bmEditName, val)
+			}
+		}
+		if err != nil {
+			m.bmErr = err.Error()
+			return m, nil
+		}
+		m.bmInput.Blur()
+		m.bmErr = ""
+		m.state = stateBookmarks
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.bmInput, cmd = m.bmInput.Update(msg)
+	return m, cmd
+}
+
+// rowPath returns the tree path of r, or nil (top level) when no row is selected.
+func rowPath(r *bookmarks.Row) []int {
+	if r == nil {
+		return nil
+	}
+	return r.Path
+}
+
+func (m model) bookmarksView() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Managed bookmarks"))
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render("[l] add link  [f] add folder  [e] edit  [x] delete  [esc] back (discard)"))
+	b.WriteString("\n\n")
+	cursor := func(i int) string {
+		if i == m.bmIdx {
+			return activeStyle.Render(">")
+		}
+		return " "
+	}
+	b.WriteString(fmt.Sprintf("  %s %s\n", cursor(0), activeStyle.Render(m.bmTree.TopLevelName+"/")))
+	rows := m.bmTree.Flatten()
+	for i, r := range rows {
+		indent := strings.Repeat("  ", r.Depth+1)
+		if r.Node.IsFolder() {
+			b.WriteString(fmt.Sprintf("  %s %s%s/\n", cursor(i+1), indent, r.Node.Name))
+		} else {
+			b.WriteString(fmt.Sprintf("  %s %s%s  %s\n", cursor(i+1), indent, r.Node.Name, dimStyle.Render(r.Node.URL)))
+		}
+	}
+	if len(rows) == 0 {
+		b.WriteString(dimStyle.Render("    (empty — press l to add a link)") + "\n")
+	}
+	b.WriteString("\n")
+	if m.state == stateBookmarkEdit {
+		label := map[string]string{"link": "New link", "folder": "New folder", "edit": "Edit", "title": "Folder name in the bookmarks bar"}[m.bmEditKind]
+		if m.bmEditStep == 1 {
+			label += " — URL"
+		} else if m.bmEditKind != "title" {
+			label += " — name"
+		}
+		b.WriteString(label + "\n" + m.bmInput.View() + "\n\n")
+	}
+	if m.bmErr != "" {
+		b.WriteString(errorStyle.Render(m.bmErr) + "\n\n")
+	}
+	if m.state == stateBookmarkEdit {
+		b.WriteString(dimStyle.Render("enter confirm  esc cancel"))
+	} else {
+		b.WriteString(dimStyle.Render("↑/k up  ↓/j down  enter save & apply  esc back"))
+	}
+	return b.String()
+}
@@ -0,0 +1,135 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/cowardly/cowardly/internal/brave"
+	"github.com/cowardly/cowardly/internal/compare"
+	"github.com/cowardly/cowardly/internal/presets"
+)
+
+// compareSources returns the picker items and the compare.Load spec for each:
+// presets, Privacy Guides, the current state, then backups (newest first).
+func (m model) compareSources() ([]list.Item, []string) {
+	items := []list.Item{item{title: "← Back", desc: "Return to main menu"}}
+	specs := []string{""}
+	for _, p := range presets.All() {
+		items = append(items, item{title: p.Name, desc: p.ID})
+		specs = append(specs, p.ID)
+	}
+	items = append(items, item{title: "Privacy Guides", desc: "Quick Debloat + Privacy Guides supplement"})
+	specs = append(specs, "privacy-guides")
+	items = append(items, item{title: "Current settings", desc: "What Brave uses now (managed overrides user)"})
+	specs = append(specs, compare.Current)
+	backups, _ := brave.ListBackups(m.paths)
+	for _, b := range backups {
+		items = append(items, item{title: "Backup " + b.Name(), desc: strings.Join(b.Scopes(), " + ") + " — " + b.Path})
+		specs = append(specs, b.Path)
+	}
+	return items, specs
+}
+
+// openCompare shows the source picker for the first side of a comparison.
+func (m model) openCompare() (tea.Model, tea.Cmd) {
+	items, specs := m.compareSources()
+	m.compareList.SetItems(items)
+	m.compareList.ResetSelected()
+	m.compareList.Title = "Compare — choose the first source"
+	m.compareSpecs = specs
+	m.compareA = ""
+	m.compareErr = ""
+	m.state = stateComparePick
+	return m, nil
+}
+
+// updateComparePick handles the source picker; the second pick runs the comparison.
+func (m model) updateComparePick(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "esc":
+		m.state = stateMain
+		return m, nil
+	case "enter":
+		idx := m.compareList.Index()
+		if idx <= 0 || idx >= len(m.compareSpecs) {
+			m.state = stateMain
+			return m, nil
+		}
+		spec := m.compareSpecs[idx]
+		if m.compareA == "" {
+			m.compareA = spec
+			m.compareList.Title = "Compare " + m.compareList.SelectedItem().(item).title + " with…"
+			m.compareList.ResetSelected()
+			return m, nil
+		}
+		a, err := compare.Load(m.paths, m.compareA)
+		if err == nil {
+			var b compare.Source
+			b, err = compare.Load(m.paths, spec)
+			if err == nil {
+				m.compareText = compare.Compare(a, b).Format()
+			}
+		}
+		if err != nil {
+			m.compareErr = err.Error()
+			return m, nil
+		}
+		m.compareScroll = 0
+		m.state = stateCompare
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.compareList, cmd = m.compareList.Update(msg)
+	return m, cmd
+}
+
+// updateCompare scrolls the comparison table.
+func (m model) updateCompare(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	lines := strings.Count(m.compareText, "\n") + 1
+	switch msg.String() {
+	case "q", "esc", "enter":
+		m.state = stateMain
+	case "up", "k":
+		if m.compareScroll > 0 {
+			m.compareScroll--
+		}
+	case "down", "j":
+		if m.compareScroll < lines-1 {
+			m.compareScroll++
+		}
+	case "b":
+		return m.openCompare()
+	}
+	return m, nil
+}
+
+func (m model) compareView() string {
+	if m.state == stateComparePick {
+		v := titleStyle.Render("Compare presets") + "\n" + m.compareList.View() + "\n"
+		if m.compareErr != "" {
+			v += errorStyle.Render(m.compareErr) + "\n"
+		}
+		return v + dimStyle.Render("enter select  esc back")
+	}
+	lines := strings.Split(m.compareText, "\n")
+	height := len(lines)
+	if m.height > 6 {
+		height = m.height - 6
+	}
+	start := m.compareScroll
+	if start > len(lines) {
+		start = len(lines)
+	}
+	end := start + height
+	if end > len(lines) {
+		end = len(lines)
+	}
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Compare presets"))
+	b.WriteString("\n")
+	b.WriteString(strings.Join(lines[start:end], "\n"))
+	b.WriteString("\n\n")
+	b.WriteString(dimStyle.Render("↑/k up  ↓/j down  b compare others  esc back"))
+	return b.String()
+}
@@ -0,0 +1,207 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/cowardly/cowardly/internal/brave"
+	"github.com/cowardly/cowardly/internal/content"
+	"github.com/cowardly/cowardly/internal/presets"
+)
+
+// contentRow is one line in the content settings editor.
+type contentRow struct {
+	typ     content.Type
+	kind    string         // "default", "list" or "pattern"
+	action  content.Action // list the row belongs to (list and pattern rows)
+	pattern string         // pattern rows only
+}
+
+// contentRows returns the editor rows for c: per type, its default, then each list and its patterns.
+func contentRows(c content.Config) []contentRow {
+	var rows []contentRow
+	for _, t := range content.Types {
+		rows = append(rows, contentRow{typ: t, kind: "default"})
+		for _, a := range t.Actions() {
+			rows = append(rows, contentRow{typ: t, kind: "list", action: a})
+			for _, p := range c[t.Name].List(a) {
+				rows = append(rows, contentRow{typ: t, kind: "pattern", action: a, pattern: p})
+			}
+		}
+	}
+	return rows
+}
+
+// nextDefault cycles cur through unset and the defaults t supports.
+func nextDefault(t content.Type, cur content.Action) content.Action {
+	order := []content.Action{""}
+	for _, a := range []content.Action{content.ActionAllow, content.ActionBlock, content.ActionSessionOnly, content.ActionAsk} {
+		if _, ok := t.Defaults[a]; ok {
+			order = append(order, a)
+		}
+	}
+	for i, a := range order {
+		if a == cur {
+			return order[(i+1)%len(order)]
+		}
+	}
+	return ""
+}
+
+// updateContent handles keys in the content settings editor.
+func (m model) updateContent(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	rows := contentRows(m.contentCfg)
+	if m.contentIdx >= len(rows) {
+		m.contentIdx = len(rows) - 1
+	}
+	row := rows[m.contentIdx]
+	m.contentErr = ""
+	switch msg.String() {
+	case "q", "esc":
+		m.state = stateMain
+		return m, nil
+	case "enter":
+		if err := m.contentCfg.Validate(); err != nil {
+			m.contentErr = err.Error()
+			return m, nil
+		}
+		return m, func() tea.Msg { return applyContentMsg{} }
+	case "up", "k":
+		if m.contentIdx > 0 {
+			m.contentIdx--
+		}
+		m.contentDiff = ""
+	case "down", "j":
+		if m.contentIdx < len(rows)-1 {
+			m.contentIdx++
+		}
+		m.contentDiff = ""
+	case " ", "right", "l":
+		if row.kind == "default" {
+			r := m.contentCfg[row.typ.Name]
+			r.Default = nextDefault(row.typ, r.Default)
+			m.contentCfg[row.typ.Name] = r
+		}
+	case "a":
+		m.contentPending = row
+		m.contentInput.Reset()
+		m.contentInput.Placeholder = "[*.]example.com or https://sso.example.com"
+		m.contentInput.Focus()
+		m.state = stateContentAdd
+		return m, textinput.Blink
+	case "x", "delete", "backspace":
+		if row.kind != "pattern" {
+			return m, nil
+		}
+		r := m.contentCfg[row.typ.Name]
+		var kept []string
+		for _, p := range r.List(row.action) {
+			if p != row.pattern {
+				kept = append(kept, p)
+			}
+		}
+		r.SetList(row.action, kept)
+		m.contentCfg[row.typ.Name] = r
+	case "v":
+		diff := brave.Diff(m.contentCfg.Settings(), presets.ValueNames())
+		if diff == "" {
+			diff = "No changes."
+		}
+		m.contentDiff = diff
+	}
+	return m, nil
+}
+
+// updateContentAdd handles the pattern input for the list selected in the editor.
+func (m model) updateContentAdd(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.contentInput.Blur()
+		m.contentErr = ""
+		m.state = stateContent
+		return m, nil
+	case "enter":
+		p := strings.TrimSpace(m.contentInput.Value())
+		if err := content.ValidatePattern(p); err != nil {
+			m.contentErr = err.Error()
+			return m, nil
+		}
+		row := m.contentPending
+		action := row.action
+		if row.kind == "default" {
+			action = row.typ.Actions()[0]
+		}
+		r := m.contentCfg[row.typ.Name]
+		r.SetList(action, append(r.List(action), p))
+		next := m.contentCfg.Clone()
+		next[row.typ.Name] = r
+		if err := next.Validate(); err != nil {
+			m.contentErr = err.Error()
+			return m, nil
+		}
+		m.contentCfg = next
+		m.contentInput.Blur()
+		m.contentErr = ""
+		m.state = stateContent
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.contentInput, cmd = m.contentInput.Update(msg)
+	return m, cmd
+}
+
+func (m model) contentView() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Site permissions — Content settings"))
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render("[space] cycle default  [a] add site  [x] remove site  [v] diff  [esc] back (discard)"))
+	b.WriteString("\n\n")
+	for i, row := range contentRows(m.contentCfg) {
+		cursor := " "
+		if i == m.contentIdx {
+			cursor = activeStyle.Render(">")
+		}
+		switch row.kind {
+		case "default":
+			def := string(m.contentCfg[row.typ.Name].Default)
+			if def == "" {
+				def = dimStyle.Render("(browser default)")
+			} else {
+				def = activeStyle.Render(def)
+			}
+			b.WriteString(fmt.Sprintf("%s %s  default: %s\n", cursor, row.typ.Label, def))
+		case "list":
+			n := len(m.contentCfg[row.typ.Name].List(row.action))
+			b.WriteString(fmt.Sprintf("%s     %s (%d)\n", cursor, row.action, n))
+		case "pattern":
+			mark := checkStyle.Render("✓")
+			if row.action == content.ActionBlock {
+				mark = errorStyle.Render("✗")
+			}
+			b.WriteString(fmt.Sprintf("%s       %s %s\n", cursor, mark, row.pattern))
+		}
+	}
+	b.WriteString("\n")
+	if m.state == stateContentAdd {
+		row := m.contentPending
+		action := row.action
+		if row.kind == "default" {
+			action = row.typ.Actions()[0]
+		}
+		b.WriteString(fmt.Sprintf("Add site to %s %s\n%s\n\n", row.typ.Label, action, m.contentInput.View()))
+	}
+	if m.contentErr != "" {
+		b.WriteString(errorStyle.Render(m.contentErr) + "\n\n")
+	}
+	if m.contentDiff != "" {
+		b.WriteString(dimStyle.Render("Would change (current -> new):") + "\n" + m.contentDiff + "\n\n")
+	}
+	if m.state == stateContentAdd {
+		b.WriteString(dimStyle.Render("enter add  esc cancel"))
+	} else {
+		b.WriteString(dimStyle.Render("↑/k up  ↓/j down  enter save & apply  esc back"))
+	}
+	return b.String()
+}
@@ -0,0 +1,108 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/cowardly/cowardly/internal/doh"
+)
+
+// dnsFixedItems is the number of items before the resolver catalog in the DNS picker.
+const dnsFixedItems = 4
+
+// updateDNSPicker handles keys in the DNS over HTTPS resolver list.
+func (m model) updateDNSPicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "esc":
+		m.state = stateMain
+		return m, nil
+	case "enter":
+		idx := m.dnsList.Index()
+		switch idx {
+		case 0:
+			m.state = stateMain
+			return m, nil
+		case 1:
+			return m, func() tea.Msg { return applyDNSMsg{} }
+		case 2:
+			return m, func() tea.Msg { return applyDNSMsg{sel: doh.Selection{Mode: doh.ModeOff}} }
+		case 3:
+			return m, func() tea.Msg { return applyDNSMsg{sel: doh.Selection{Mode: doh.ModeAutomatic}} }
+		}
+		resolvers, _ := doh.Catalog()
+		if idx-dnsFixedItems >= len(resolvers) {
+			return m, nil
+		}
+		r := resolvers[idx-dnsFixedItems]
+		if r.NeedsVariables() {
+			m.dnsPending = r.ID
+			m.dnsErr = ""
+			m.dnsInput.Reset()
+			m.dnsInput.Placeholder = r.Variables[0].Description
+			if desired, _ := m.store.Read(); desired != nil && desired.DNS.Resolver == r.ID {
+				m.dnsInput.SetValue(desired.DNS.Vars[r.Variables[0].Name])
+				m.dnsInput.CursorEnd()
+			}
+			m.dnsInput.Focus()
+			m.state = stateDNSVar
+			return m, textinput.Blink
+		}
+		sel := doh.Selection{Mode: doh.ModeSecure, Resolver: r.ID}
+		return m, func() tea.Msg { return applyDNSMsg{sel: sel} }
+	}
+	var cmd tea.Cmd
+	m.dnsList, cmd = m.dnsList.Update(msg)
+	return m, cmd
+}
+
+// updateDNSVar handles the variable input (e.g. NextDNS profile ID) for the pending resolver.
+func (m model) updateDNSVar(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.dnsInput.Blur()
+		m.state = stateDNSPicker
+		return m, nil
+	case "enter":
+		r := doh.Find(m.dnsPending)
+		if r == nil || !r.NeedsVariables() {
+			m.state = stateDNSPicker
+			return m, nil
+		}
+		sel := doh.Selection{
+			Mode:     doh.ModeSecure,
+			Resolver: r.ID,
+			Vars:     map[string]string{r.Variables[0].Name: strings.TrimSpace(m.dnsInput.Value())},
+		}
+		if err := sel.Validate(); err != nil {
+			m.dnsErr = err.Error()
+			return m, nil
+		}
+		m.dnsInput.Blur()
+		return m, func() tea.Msg { return applyDNSMsg{sel: sel} }
+	}
+	var cmd tea.Cmd
+	m.dnsInput, cmd = m.dnsInput.Update(msg)
+	return m, cmd
+}
+
+func (m model) dnsView() string {
+	if m.state == stateDNSVar {
+		name := m.dnsPending
+		if r := doh.Find(m.dnsPending); r != nil {
+			name = r.Name
+		}
+		v := titleStyle.Render("DNS over HTTPS — "+name) + "\n\n" + m.dnsInput.View() + "\n\n"
+		if m.dnsErr != "" {
+			v += errorStyle.Render(m.dnsErr) + "\n\n"
+		}
+		return v + dimStyle.Render("enter save & apply  esc back")
+	}
+	current := "not managed"
+	if desired, _ := m.store.Read(); desired != nil && !desired.DNS.IsEmpty() {
+		current = desired.DNS.Describe()
+	}
+	return titleStyle.Render("DNS over HTTPS") + "\n" +
+		dimStyle.Render("Current: ") + activeStyle.Render(current) + "\n\n" +
+		m.dnsList.View() + dimStyle.Render("\nenter save & apply  esc back")
+}
@@ -2,11 +2,23 @@
 package ui
 
 import (
+	"strings"
+
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/cowardly/cowardly/internal/bookmarks"
 	"github.com/cowardly/cowardly/internal/brave"
 	"github.com/cowardly/cowardly/internal/config"
+	"github.com/cowardly/cowardly/internal/content"
+	"github.com/cowardly/cowardly/internal/doh"
+	"github.com/cowardly/cowardly/internal/extensions"
+	"github.com/cowardly/cowardly/internal/paths"
 	"github.com/cowardly/cowardly/internal/presets"
+	"github.com/cowardly/cowardly/internal/score"
+	"github.com/cowardly/cowardly/internal/search"
+	"github.com/cowardly/cowardly/internal/shields"
+	"github.com/cowardly/cowardly/internal/startup"
 	"github.com/cowardly/cowardly/internal/userconfig"
 )
 
@@ -22,14 +34,32 @@ const (
 	stateResetConfirm
 	stateBackups
 	stateBackupConfirm
+	stateSearchPicker
+	stateSearchVar
+	stateExtensions
+	stateBookmarks
+	stateBookmarkEdit
+	stateDNSPicker
+	stateDNSVar
+	stateContent
+	stateContentAdd
+	stateStartup
+	stateComparePick
+	stateCompare
+	stateOverrides
+	stateOverrideAdd
 )
 
 type model struct {
+	paths                     paths.Paths      // config file, backup and state dirs
+	store                     userconfig.Store // cowardly.yaml at paths.Config
 	state                     state
 	mainList                  list.Model
 	presetList                list.Model
 	backupList                list.Model
-	backupPaths               []string
+	backups                   []brave.Backup
+	backupPreview             string // what restoring the selected backup would change
+	backupPreviewPath         string // backup the preview is for
 	confirmPath               string
 	confirmAction             string // "restore" or "delete"
 	customIdx                 int
@@ -42,10 +72,56 @@ type model struct {
 	height                    int
 	err                       string
 	msg                       string
-	settingsReverted          bool   // true if desired state exists but current differs (e.g. after MDM revert)
-	revertedPreset            string // preset id from desired state, for message
-	privacyGuidesBasePresetID string // selected base preset when applying Privacy Guides
-	privacyGuidesHasCustom    bool   // Custom was added to base preset list (config has preset.custom)
+	settingsReverted          bool            // true if desired state exists but current differs (e.g. after MDM revert)
+	revertedPreset            string          // preset id from desired state, for message
+	revertedTarget            brave.Target    // scope the desired state was last applied to, for message
+	profile                   string          // active profile in cowardly.yaml
+	channels                  []brave.Variant // installed Brave channels; c on the main screen cycles through them
+	privacyGuidesBasePresetID string          // selected base preset when applying Privacy Guides
+	privacyGuidesHasCustom    bool            // Custom was added to base preset list (config has preset.custom)
+	searchList                list.Model
+	searchInput               textinput.Model
+	searchPending             string            // provider id awaiting variable input
+	searchErr                 string            // validation error shown under the variable input
+	customSearchID            string            // default search provider chosen in Custom ("" = unchanged)
+	customSearchVars          map[string]string // variables for customSearchID (e.g. SearXNG url)
+	customStartup             startup.Config    // startup & homepage chosen in Custom (empty = unchanged)
+	extPolicy                 extensions.Policy // extension policy being edited
+	extIDs                    []string          // rows in the Extensions screen (catalog, then other managed IDs)
+	extIdx                    int
+	bmTree                    bookmarks.Tree // managed bookmarks being edited
+	bmIdx                     int            // 0 = top-level folder, then rows of bmTree.Flatten()
+	bmInput                   textinput.Model
+	bmEditKind                string // "link", "folder", "edit" or "title"
+	bmEditStep                int    // 0 = name, 1 = URL
+	bmEditName                string // name entered in step 0
+	bmErr                     string // validation error shown in the bookmarks editor
+	dnsList                   list.Model
+	dnsInput                  textinput.Model
+	dnsPending                string         // resolver id awaiting variable input
+	dnsErr                    string         // validation error shown under the variable input
+	contentCfg                content.Config // content settings being edited
+	contentIdx                int
+	contentInput              textinput.Model
+	contentPending            contentRow // row the pattern input adds to
+	contentErr                string
+	contentDiff               string         // output of the last diff (v)
+	startupCfg                startup.Config // startup & homepage being edited in the wizard
+	startupStep               int            // startupStep* constant
+	startupIdx                int            // cursor on choice steps
+	startupInput              textinput.Model
+	startupErr                string
+	compareList               list.Model
+	compareSpecs              []string // compare.Load spec for each compareList item
+	compareA                  string   // spec picked for the first side ("" while picking it)
+	compareText               string   // formatted comparison
+	compareScroll             int
+	compareErr                string
+	score                     *score.Result   // shown on the main screen; nil until loaded
+	overrides                 []brave.Setting // per-key overrides being edited
+	overrideIdx               int
+	overrideInput             textinput.Model
+	overrideErr               string
 }
 
 // Brave brand orange and palette (Brave orange #ff631c, lighter accent #ff9f5c).
@@ -74,12 +150,9 @@ func braveListDelegate() list.DefaultDelegate {
 	return d
 }
 
-// tuiTitle returns the main TUI title (Brave or Brave Beta).
+// tuiTitle returns the main TUI title for the current channel (e.g. Brave Browser Nightly).
 func tuiTitle() string {
-	if brave.IsBeta() {
-		return "Cowardly — Brave Browser Beta Debloater"
-	}
-	return "Cowardly — Brave Browser Debloater"
+	return "Cowardly — " + brave.CurrentVariant().AppName() + " Debloater"
 }
 
 // braveListStyles returns list styles with Brave orange title bar and filter cursor.
@@ -93,13 +166,18 @@ func braveListStyles() list.Styles {
 	return sty
 }
 
-// NewModel returns the initial Bubble Tea model for the TUI.
-func NewModel() model {
+// NewModel returns the initial Bubble Tea model for the TUI, reading and writing the files in p.
+func NewModel(p paths.Paths) model {
 	mainItems := []list.Item{
 		item{title: "Apply a preset", desc: "Quick Debloat, Maximum Privacy, Balanced, etc."},
 		item{title: "Privacy Guides recommendations", desc: "Apply Privacy Guides recommended Brave configuration"},
 		item{title: "Custom", desc: "Choose exactly which settings to apply"},
+		item{title: "Extensions", desc: "Force-install, block, or allow extensions"},
+		item{title: "Managed bookmarks", desc: "Edit the managed bookmarks folder"},
+		item{title: "DNS over HTTPS", desc: "Choose a secure DNS resolver (Quad9, Mullvad, NextDNS, ...)"},
+		item{title: "Site permissions", desc: "Per-site cookies, JavaScript, pop-ups, notifications, images"},
 		item{title: "View current settings", desc: "See what's currently configured"},
+		item{title: "Compare presets", desc: "Presets, current settings or a backup, side by side"},
 		item{title: "Reset all to default", desc: "Remove all Brave policy settings"},
 		item{title: "Backups", desc: "List, restore, or delete backup plists"},
 		item{title: "Exit", desc: "Quit cowardly"},
@@ -109,7 +187,7 @@ func NewModel() model {
 	mainList.Styles = braveListStyles()
 	mainList.SetShowStatusBar(false)
 
-	presetItems := presetListItems(false)
+	presetItems := presetListItems(nil)
 	presetList := list.New(presetItems, braveListDelegate(), 0, 0)
 	presetList.Title = "Choose a preset"
 	presetList.Styles = braveListStyles()
@@ -141,19 +219,62 @@ func NewModel() model {
 		"BraveAIChatEnabled", "TorDisabled", "SyncDisabled",
 		"ShoppingListEnabled", "AlwaysOpenPdfExternally", "TranslateEnabled",
 		"SpellcheckEnabled", "PromotionsEnabled", "DnsOverHttpsMode",
+		"DnsOverHttpsTemplates",
 	}
+	viewKeys = append(viewKeys, shields.Keys()...)
+	viewKeys = append(viewKeys, startup.Keys...)
 
 	backupList := list.New([]list.Item{}, braveListDelegate(), 0, 0)
 	backupList.Title = "Backups"
 	backupList.Styles = braveListStyles()
 	backupList.SetShowStatusBar(false)
 
+	searchList := list.New(searchListItems(), braveListDelegate(), 0, 0)
+	searchList.Title = "Default search provider"
+	searchList.Styles = braveListStyles()
+	searchList.SetShowStatusBar(false)
+
+	searchInput := textinput.New()
+	searchInput.CharLimit = 256
+	searchInput.Width = 60
+
+	bmInput := textinput.New()
+	bmInput.CharLimit = 2048
+	bmInput.Width = 60
+
+	dnsList := list.New(dnsListItems(), braveListDelegate(), 0, 0)
+	dnsList.Title = "DNS over HTTPS"
+	dnsList.Styles = braveListStyles()
+	dnsList.SetShowStatusBar(false)
+
+	dnsInput := textinput.New()
+	dnsInput.CharLimit = 512
+	dnsInput.Width = 60
+
+	contentInput := textinput.New()
+	contentInput.CharLimit = 256
+	contentInput.Width = 60
+
+	compareList := list.New([]list.Item{}, braveListDelegate(), 0, 0)
+	compareList.Styles = braveListStyles()
+	compareList.SetShowStatusBar(false)
+
+	startupInput := textinput.New()
+	startupInput.CharLimit = 1024
+	startupInput.Width = 60
+
+	overrideInput := textinput.New()
+	overrideInput.CharLimit = 1024
+	overrideInput.Width = 60
+
 	return model{
+		paths:            p,
+		store:            userconfig.New(p),
 		state:            stateMain,
 		mainList:         mainList,
 		presetList:       presetList,
 		backupList:       backupList,
-		backupPaths:      nil,
+		backups:          nil,
 		confirmPath:      "",
 		confirmAction:    "",
 		customIdx:        0,
@@ -164,25 +285,83 @@ func NewModel() model {
 		viewScroll:       0,
 		settingsReverted: false,
 		revertedPreset:   "",
+		searchList:       searchList,
+		searchInput:      searchInput,
+		bmInput:          bmInput,
+		dnsList:          dnsList,
+		dnsInput:         dnsInput,
+		contentInput:     contentInput,
+		startupInput:     startupInput,
+		compareList:      compareList,
+		overrideInput:    overrideInput,
+		channels:         brave.InstalledVariants(),
 	}
 }
 
+// searchListItems returns list items for the Custom default search picker.
+// The first item keeps the current default search; the rest come from the search catalog.
+func searchListItems() []list.Item {
+	items := []list.Item{item{title: "Unchanged", desc: "Do not set a default search provider"}}
+	providers, _ := search.Catalog()
+	for _, p := range providers {
+		desc := p.SearchURL
+		if p.NeedsVariables() {
+			desc = p.Variables[0].Description
+		}
+		items = append(items, item{title: p.Name, desc: desc})
+	}
+	return items
+}
+
+// dnsListItems returns list items for the DNS over HTTPS picker: fixed choices, then the resolver catalog (secure mode).
+func dnsListItems() []list.Item {
+	items := []list.Item{
+		item{title: "← Back", desc: "Return to main menu"},
+		item{title: "Not managed", desc: "Stop managing DoH (the preset's value, if any, applies)"},
+		item{title: "Off", desc: "Disable DNS over HTTPS"},
+		item{title: "Automatic", desc: "Use DoH when the system resolver supports it"},
+	}
+	resolvers, _ := doh.Catalog()
+	for _, r := range resolvers {
+		items = append(items, item{title: r.Name, desc: r.Description})
+	}
+	return items
+}
+
 // presetListItems returns list items for the preset list.
-// If includeCustom is true and config has preset.custom, appends Custom as last item.
-func presetListItems(includeCustom bool) []list.Item {
+// If desired (from cowardly.yaml; may be nil) has preset.custom, appends Custom as last item.
+func presetListItems(desired *userconfig.DesiredState) []list.Item {
 	items := []list.Item{item{title: "← Back", desc: "Return to main menu"}}
 	for _, p := range presets.All() {
 		items = append(items, item{title: p.Name, desc: p.Description})
 	}
-	if includeCustom {
-		desired, _ := userconfig.Read()
-		if desired != nil && desired.Preset == "custom" && len(desired.Settings) > 0 {
-			items = append(items, item{title: "Custom", desc: "Your saved custom settings"})
-		}
+	if desired != nil && desired.Preset == "custom" && len(desired.Settings) > 0 {
+		items = append(items, item{title: "Custom", desc: "Your saved custom settings"})
 	}
 	return items
 }
 
+// extensionRows returns the IDs shown in the Extensions screen: the catalog, then any other managed IDs.
+func extensionRows(p extensions.Policy) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	catalog, _ := extensions.Catalog()
+	for _, e := range catalog {
+		ids = append(ids, e.ID)
+		seen[e.ID] = true
+	}
+	for _, id := range p.IDs() {
+		if !seen[id] {
+			ids = append(ids, id)
+			seen[id] = true
+		}
+	}
+	if !seen[extensions.Wildcard] {
+		ids = append(ids, extensions.Wildcard)
+	}
+	return ids
+}
+
 type item struct {
 	title, desc string
 }
@@ -190,3 +369,26 @@ type item struct {
 func (i item) Title() string       { return i.title }
 func (i item) Description() string { return i.desc }
 func (i item) FilterValue() string { return i.title }
+
+// backupItem is a row of the backups list. Filtering matches the name and the whole manifest
+// (label, reason, preset, channel, Brave version).
+type backupItem struct {
+	backup brave.Backup
+}
+
+func (i backupItem) Title() string {
+	if i.backup.Info != nil && i.backup.Info.Label != "" {
+		return i.backup.Info.Label + "  (" + i.backup.Name() + ")"
+	}
+	return i.backup.Name()
+}
+
+func (i backupItem) Description() string { return i.backup.Summary() }
+
+func (i backupItem) FilterValue() string {
+	v := i.backup.Name() + " " + strings.Join(i.backup.Scopes(), " ")
+	if info := i.backup.Info; info != nil {
+		v += " " + strings.Join([]string{info.Label, info.Reason, info.Source, string(info.Channel), info.Brave}, " ")
+	}
+	return v
+}
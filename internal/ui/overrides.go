@@ -0,0 +1,131 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/cowardly/cowardly/internal/brave"
+	"github.com/cowardly/cowardly/internal/compare"
+	"github.com/cowardly/cowardly/internal/userconfig"
+)
+
+// openOverrides loads the active profile's overrides into the editor.
+func (m model) openOverrides() (tea.Model, tea.Cmd) {
+	desired, err := m.store.Read()
+	if err != nil {
+		m.err = err.Error()
+		m.state = stateMain
+		return m, nil
+	}
+	m.overrides = nil
+	if desired != nil {
+		m.overrides = append([]brave.Setting(nil), desired.Overrides...)
+	}
+	m.overrideIdx = 0
+	m.overrideErr = ""
+	m.state = stateOverrides
+	return m, nil
+}
+
+// updateOverrides handles keys in the overrides editor.
+func (m model) updateOverrides(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.overrideErr = ""
+	switch msg.String() {
+	case "q", "esc":
+		m.state = stateViewSettings
+		return m, nil
+	case "enter":
+		return m, func() tea.Msg { return applyOverridesMsg{} }
+	case "up", "k":
+		if m.overrideIdx > 0 {
+			m.overrideIdx--
+		}
+	case "down", "j":
+		if m.overrideIdx < len(m.overrides)-1 {
+			m.overrideIdx++
+		}
+	case "a":
+		m.overrideInput.Reset()
+		m.overrideInput.Placeholder = "Key=Value (e.g. TorDisabled=false)"
+		m.overrideInput.Focus()
+		m.state = stateOverrideAdd
+		return m, textinput.Blink
+	case "x", "delete", "backspace":
+		if m.overrideIdx >= len(m.overrides) {
+			return m, nil
+		}
+		m.overrides = append(m.overrides[:m.overrideIdx:m.overrideIdx], m.overrides[m.overrideIdx+1:]...)
+		if m.overrideIdx > 0 && m.overrideIdx >= len(m.overrides) {
+			m.overrideIdx--
+		}
+	}
+	return m, nil
+}
+
+// updateOverrideAdd handles the Key=Value input; an existing override for the key is replaced.
+func (m model) updateOverrideAdd(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.overrideInput.Blur()
+		m.overrideErr = ""
+		m.state = stateOverrides
+		return m, nil
+	case "enter":
+		s, err := userconfig.ParseOverride(strings.TrimSpace(m.overrideInput.Value()), false)
+		if err != nil {
+			m.overrideErr = err.Error()
+			return m, nil
+		}
+		m.overrideIdx = len(m.overrides)
+		for i, o := range m.overrides {
+			if o.Key == s.Key {
+				m.overrideIdx = i
+			}
+		}
+		if m.overrideIdx == len(m.overrides) {
+			m.overrides = append(m.overrides, s)
+		} else {
+			m.overrides[m.overrideIdx] = s
+		}
+		m.overrideInput.Blur()
+		m.overrideErr = ""
+		m.state = stateOverrides
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.overrideInput, cmd = m.overrideInput.Update(msg)
+	return m, cmd
+}
+
+func (m model) overridesView() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Overrides"))
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render("Single keys applied on top of the preset and layers; kept when you apply another preset."))
+	b.WriteString("\n\n")
+	if len(m.overrides) == 0 {
+		b.WriteString(dimStyle.Render("  No overrides. Press a to add one.") + "\n")
+	}
+	for i, s := range m.overrides {
+		cursor := " "
+		if i == m.overrideIdx {
+			cursor = activeStyle.Render(">")
+		}
+		b.WriteString(fmt.Sprintf("%s %s = %s\n", cursor, s.Key, compare.FormatValue(s)))
+	}
+	b.WriteString("\n")
+	if m.state == stateOverrideAdd {
+		b.WriteString("Add override\n" + m.overrideInput.View() + "\n\n")
+	}
+	if m.overrideErr != "" {
+		b.WriteString(errorStyle.Render(m.overrideErr) + "\n\n")
+	}
+	if m.state == stateOverrideAdd {
+		b.WriteString(dimStyle.Render("enter add  esc cancel"))
+	} else {
+		b.WriteString(dimStyle.Render("↑/k up  ↓/j down  a add  x remove  enter save & apply  esc back (discard)"))
+	}
+	return b.String()
+}
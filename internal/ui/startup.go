@@ -0,0 +1,279 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/cowardly/cowardly/internal/presets"
+	"github.com/cowardly/cowardly/internal/startup"
+)
+
+// Steps of the startup & homepage wizard. Choice steps use startupIdx; input steps use startupInput.
+const (
+	startupStepOnStartup = iota
+	startupStepURLs
+	startupStepHomepage
+	startupStepHomepageURL
+	startupStepHomeButton
+	startupStepSave
+	startupStepPath
+)
+
+// onStartupChoices are the choices of startupStepOnStartup, in display order; "" is not managed.
+var onStartupChoices = []struct {
+	value startup.OnStartup
+	label string
+}{
+	{startup.RestoreSession, "Continue where you left off (restore session)"},
+	{startup.OpenURLs, "Open specific pages"},
+	{startup.RestoreSessionAndURLs, "Restore session and open specific pages"},
+	{startup.NewTabPage, "Open the New Tab page"},
+	{"", "Not managed"},
+}
+
+// startupChoices returns the options for a choice step.
+func startupChoices(step int) []string {
+	switch step {
+	case startupStepOnStartup:
+		out := make([]string, len(onStartupChoices))
+		for i, c := range onStartupChoices {
+			out[i] = c.label
+		}
+		return out
+	case startupStepHomepage:
+		return []string{"New Tab page", "A URL…", "Not managed"}
+	case startupStepHomeButton:
+		return []string{"Show", "Hide", "Not managed"}
+	case startupStepSave:
+		return []string{"Use in Custom (apply from the Custom screen)", "Save as preset file…"}
+	}
+	return nil
+}
+
+// startupSelected returns the choice matching the current value of cfg for step.
+func startupSelected(step int, cfg startup.Config) int {
+	switch step {
+	case startupStepOnStartup:
+		for i, c := range onStartupChoices {
+			if c.value == cfg.OnStartup {
+				return i
+			}
+		}
+		return len(onStartupChoices) - 1
+	case startupStepHomepage:
+		switch cfg.Homepage {
+		case startup.HomepageNewTab:
+			return 0
+		case "":
+			return 2
+		}
+		return 1
+	case startupStepHomeButton:
+		if cfg.ShowHomeButton == nil {
+			return 2
+		}
+		if *cfg.ShowHomeButton {
+			return 0
+		}
+		return 1
+	}
+	return 0
+}
+
+// startStartupWizard opens the wizard on the startup choice, prefilled from the Custom selection.
+func (m model) startStartupWizard() (tea.Model, tea.Cmd) {
+	m.startupCfg = m.customStartup
+	m.startupCfg.URLs = append([]string(nil), m.customStartup.URLs...)
+	m.startupErr = ""
+	m.state = stateStartup
+	return m.startupGoto(startupStepOnStartup)
+}
+
+// startupGoto moves the wizard to step and prepares its cursor or input.
+func (m model) startupGoto(step int) (tea.Model, tea.Cmd) {
+	m.startupStep = step
+	m.startupErr = ""
+	m.startupInput.Blur()
+	switch step {
+	case startupStepURLs, startupStepHomepageURL, startupStepPath:
+		m.startupInput.Reset()
+		switch step {
+		case startupStepURLs:
+			m.startupInput.Placeholder = "https://intranet.example/dashboard, https://mail.example"
+			m.startupInput.SetValue(strings.Join(m.startupCfg.URLs, ", "))
+		case startupStepHomepageURL:
+			m.startupInput.Placeholder = "https://intranet.example/dashboard"
+			if m.startupCfg.Homepage != startup.HomepageNewTab {
+				m.startupInput.SetValue(m.startupCfg.Homepage)
+			}
+		case startupStepPath:
+			m.startupInput.Placeholder = "path to preset YAML"
+			m.startupInput.SetValue(filepath.Join(m.store.Dir(), "startup.yaml"))
+		}
+		m.startupInput.CursorEnd()
+		m.startupInput.Focus()
+		return m, textinput.Blink
+	}
+	m.startupIdx = startupSelected(step, m.startupCfg)
+	return m, nil
+}
+
+// updateStartup handles keys in the startup & homepage wizard.
+func (m model) updateStartup(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() == "esc" {
+		m.startupInput.Blur()
+		m.state = stateCustom
+		return m, nil
+	}
+	switch m.startupStep {
+	case startupStepURLs, startupStepHomepageURL, startupStepPath:
+		return m.updateStartupInput(msg)
+	}
+	n := len(startupChoices(m.startupStep))
+	switch msg.String() {
+	case "up", "k":
+		if m.startupIdx > 0 {
+			m.startupIdx--
+		}
+	case "down", "j":
+		if m.startupIdx < n-1 {
+			m.startupIdx++
+		}
+	case "enter":
+		return m.startupChoose()
+	}
+	return m, nil
+}
+
+// startupChoose records the selected choice and moves to the next step.
+func (m model) startupChoose() (tea.Model, tea.Cmd) {
+	switch m.startupStep {
+	case startupStepOnStartup:
+		m.startupCfg.OnStartup = onStartupChoices[m.startupIdx].value
+		if m.startupCfg.OnStartup.NeedsURLs() {
+			return m.startupGoto(startupStepURLs)
+		}
+		m.startupCfg.URLs = nil
+		return m.startupGoto(startupStepHomepage)
+	case startupStepHomepage:
+		switch m.startupIdx {
+		case 0:
+			m.startupCfg.Homepage = startup.HomepageNewTab
+		case 1:
+			return m.startupGoto(startupStepHomepageURL)
+		default:
+			m.startupCfg.Homepage = ""
+		}
+		return m.startupGoto(startupStepHomeButton)
+	case startupStepHomeButton:
+		switch m.startupIdx {
+		case 0, 1:
+			show := m.startupIdx == 0
+			m.startupCfg.ShowHomeButton = &show
+		default:
+			m.startupCfg.ShowHomeButton = nil
+		}
+		return m.startupGoto(startupStepSave)
+	case startupStepSave:
+		if err := m.startupCfg.Validate(); err != nil {
+			m.startupErr = err.Error()
+			return m, nil
+		}
+		if m.startupIdx == 1 {
+			return m.startupGoto(startupStepPath)
+		}
+		m.customStartup = m.startupCfg
+		m.state = stateCustom
+		return m, nil
+	}
+	return m, nil
+}
+
+// updateStartupInput handles the URL list, homepage URL and preset path inputs.
+func (m model) updateStartupInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() != "enter" {
+		var cmd tea.Cmd
+		m.startupInput, cmd = m.startupInput.Update(msg)
+		return m, cmd
+	}
+	val := strings.TrimSpace(m.startupInput.Value())
+	switch m.startupStep {
+	case startupStepURLs:
+		urls, err := startup.ParseURLs(val)
+		if err == nil && len(urls) == 0 {
+			err = fmt.Errorf("enter at least one URL")
+		}
+		if err != nil {
+			m.startupErr = err.Error()
+			return m, nil
+		}
+		m.startupCfg.URLs = urls
+		return m.startupGoto(startupStepHomepage)
+	case startupStepHomepageURL:
+		if err := startup.ValidateURL(val); err != nil {
+			m.startupErr = err.Error()
+			return m, nil
+		}
+		m.startupCfg.Homepage = val
+		return m.startupGoto(startupStepHomeButton)
+	case startupStepPath:
+		if val == "" {
+			m.startupErr = "enter a file path"
+			return m, nil
+		}
+		if err := presets.WriteStartupPreset(val, "startup", "Startup & homepage", m.startupCfg); err != nil {
+			m.startupErr = err.Error()
+			return m, nil
+		}
+		m.startupInput.Blur()
+		m.err = ""
+		m.msg = fmt.Sprintf("Saved startup preset to %s\n\nApply it with: cowardly --apply-file=%s", val, val)
+		m.state = stateMain
+	}
+	return m, nil
+}
+
+func (m model) startupView() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Custom — Startup & homepage"))
+	b.WriteString("\n")
+	b.WriteString(dimStyle.Render("So far: " + m.startupCfg.Describe()))
+	b.WriteString("\n\n")
+	switch m.startupStep {
+	case startupStepURLs:
+		b.WriteString("Pages to open on startup (comma-separated):\n" + m.startupInput.View() + "\n\n")
+	case startupStepHomepageURL:
+		b.WriteString("Homepage URL:\n" + m.startupInput.View() + "\n\n")
+	case startupStepPath:
+		b.WriteString("Save preset to:\n" + m.startupInput.View() + "\n\n")
+	default:
+		question := map[int]string{
+			startupStepOnStartup:  "When Brave starts:",
+			startupStepHomepage:   "Homepage:",
+			startupStepHomeButton: "Home button in the toolbar:",
+			startupStepSave:       "Save to:",
+		}[m.startupStep]
+		b.WriteString(question + "\n")
+		for i, c := range startupChoices(m.startupStep) {
+			cursor := " "
+			if i == m.startupIdx {
+				cursor = activeStyle.Render(">")
+				c = activeStyle.Render(c)
+			}
+			b.WriteString(fmt.Sprintf("  %s %s\n", cursor, c))
+		}
+		b.WriteString("\n")
+	}
+	if m.startupErr != "" {
+		b.WriteString(errorStyle.Render(m.startupErr) + "\n\n")
+	}
+	if m.startupInput.Focused() {
+		b.WriteString(dimStyle.Render("enter next  esc back to Custom (discard)"))
+	} else {
+		b.WriteString(dimStyle.Render("↑/k up  ↓/j down  enter select  esc back to Custom (discard)"))
+	}
+	return b.String()
+}
@@ -0,0 +1,393 @@
+// Package urlfilter manages the URLBlocklist and URLAllowlist policies: normalizing entries to
+// Chromium URL filter syntax and importing hosts files, plain domain lists and AdGuard-style lists.
+package urlfilter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/cowardly/cowardly/internal/brave"
+)
+
+// Policy keys written for URL filtering.
+const (
+	KeyBlocklist = "URLBlocklist"
+	KeyAllowlist = "URLAllowlist"
+)
+
+// Keys lists all policy keys managed by this package.
+var Keys = []string{KeyBlocklist, KeyAllowlist}
+
+// MaxEntries is the per-list limit Chromium enforces for URLBlocklist / URLAllowlist; extra entries are ignored by Brave.
+const MaxEntries = 1000
+
+// maxImportBytes caps how much of an import file is read.
+const maxImportBytes = 32 << 20
+
+// Format is the syntax of an imported list.
+type Format string
+
+const (
+	FormatAuto    Format = "auto"    // detect per line
+	FormatHosts   Format = "hosts"   // "0.0.0.0 example.com"
+	FormatDomains Format = "domains" // "example.com"
+	FormatAdGuard Format = "adguard" // "||example.com^", "@@||example.com^"
+)
+
+// ParseFormat returns the Format for s ("" means auto).
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case "", FormatAuto:
+		return FormatAuto, nil
+	case FormatHosts, FormatDomains, FormatAdGuard:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown list format %q (use auto, hosts, domains or adguard)", s)
+}
+
+// hostLabelRegex matches one DNS label (underscores allowed; some blocklists use them).
+var hostLabelRegex = regexp.MustCompile(`^[a-z0-9_]([a-z0-9_-]*[a-z0-9_])?$`)
+
+// hostsIgnored are names found in stock hosts files that must never be filtered.
+var hostsIgnored = map[string]bool{
+	"localhost": true, "localhost.localdomain": true, "local": true, "broadcasthost": true,
+	"ip6-localhost": true, "ip6-loopback": true, "ip6-localnet": true, "ip6-mcastprefix": true,
+	"ip6-allnodes": true, "ip6-allrouters": true, "ip6-allhosts": true, "0.0.0.0": true,
+}
+
+// adguardModifiersAllowed are AdGuard rule options that do not narrow a rule below "block the whole site".
+var adguardModifiersAllowed = map[string]bool{"important": true, "all": true, "document": true, "doc": true}
+
+// Lists is the desired URL filter state saved in userconfig.
+type Lists struct {
+	Block []string `yaml:"block,omitempty"`
+	Allow []string `yaml:"allow,omitempty"`
+}
+
+// IsEmpty returns true if neither list has entries.
+func (l Lists) IsEmpty() bool {
+	return len(l.Block) == 0 && len(l.Allow) == 0
+}
+
+// Add normalizes entries and appends them to the block or allow list, skipping duplicates.
+// Returns the number of entries added. Fails without changing l if an entry is invalid or a limit is exceeded.
+func (l *Lists) Add(allow bool, entries ...string) (int, error) {
+	target := &l.Block
+	if allow {
+		target = &l.Allow
+	}
+	normalized := make([]string, 0, len(entries))
+	for _, e := range entries {
+		n, err := Normalize(e)
+		if err != nil {
+			return 0, err
+		}
+		normalized = append(normalized, n)
+	}
+	merged, added := dedupe(*target, normalized)
+	if len(merged) > MaxEntries {
+		return 0, fmt.Errorf("%d entries exceeds the limit of %d per list (Brave ignores the rest); use a smaller list", len(merged), MaxEntries)
+	}
+	*target = merged
+	return added, nil
+}
+
+// Remove deletes entries (normalized) from both lists. Returns the number removed.
+func (l *Lists) Remove(entries ...string) int {
+	drop := make(map[string]bool)
+	for _, e := range entries {
+		if n, err := Normalize(e); err == nil {
+			drop[n] = true
+		}
+		drop[strings.TrimSpace(e)] = true
+	}
+	var removed int
+	for _, list := range []*[]string{&l.Block, &l.Allow} {
+		out := (*list)[:0]
+		for _, x := range *list {
+			if drop[x] {
+				removed++
+				continue
+			}
+			out = append(out, x)
+		}
+		*list = out
+	}
+	return removed
+}
+
+// Settings returns the URLBlocklist / URLAllowlist settings. Empty lists are omitted.
+func (l Lists) Settings() []brave.Setting {
+	var out []brave.Setting
+	if len(l.Block) > 0 {
+		out = append(out, brave.Setting{Key: KeyBlocklist, Value: toList(l.Block), Type: brave.TypeList})
+	}
+	if len(l.Allow) > 0 {
+		out = append(out, brave.Setting{Key: KeyAllowlist, Value: toList(l.Allow), Type: brave.TypeList})
+	}
+	return out
+}
+
+// Normalize converts a domain, URL or wildcard into Chromium URL filter syntax
+// ([scheme://][.]host[:port][/path]). "*.example.com" becomes "example.com", since a
+// host filter already matches subdomains; a leading "." (exact host only) is kept.
+func Normalize(entry string) (string, error) {
+	e := strings.TrimSpace(entry)
+	if e == "" {
+		return "", fmt.Errorf("empty URL filter entry")
+	}
+	if e == "*" {
+		return e, nil
+	}
+	var scheme, rest string
+	if i := strings.Index(e, "://"); i >= 0 {
+		scheme = strings.ToLower(e[:i])
+		rest = e[i+3:]
+		if scheme == "" {
+			return "", fmt.Errorf("invalid URL filter %q: empty scheme", entry)
+		}
+	} else {
+		rest = e
+	}
+	hostport, path := rest, ""
+	if i := strings.IndexAny(rest, "/?#"); i >= 0 {
+		hostport, path = rest[:i], rest[i:]
+		path = strings.SplitN(path, "#", 2)[0]
+		if path == "/" {
+			path = ""
+		}
+	}
+	exact := strings.HasPrefix(hostport, ".")
+	hostport = strings.TrimPrefix(hostport, ".")
+	hostport = strings.TrimPrefix(hostport, "*.")
+	host, port := hostport, ""
+	if h, p, err := net.SplitHostPort(hostport); err == nil {
+		host, port = h, p
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "" && scheme == "" {
+		return "", fmt.Errorf("invalid URL filter %q: no host", entry)
+	}
+	if host != "" && host != "*" && !validHost(host) {
+		return "", fmt.Errorf("invalid URL filter %q: bad host %q", entry, host)
+	}
+	var b strings.Builder
+	if scheme != "" {
+		b.WriteString(scheme + "://")
+	}
+	if exact {
+		b.WriteString(".")
+	}
+	b.WriteString(host)
+	if port != "" {
+		b.WriteString(":" + port)
+	}
+	if path != "" {
+		if u, err := url.Parse(path); err == nil && u.RawQuery != "" {
+			path = u.EscapedPath() + "@" + u.RawQuery // Chromium uses @ for query filters
+		}
+		b.WriteString(path)
+	}
+	return b.String(), nil
+}
+
+func validHost(host string) bool {
+	if net.ParseIP(strings.Trim(host, "[]")) != nil {
+		return true
+	}
+	if len(host) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(host, ".") {
+		if len(label) > 63 || !hostLabelRegex.MatchString(label) {
+			return false
+		}
+	}
+	return true
+}
+
+// ImportResult is the outcome of parsing a list file.
+type ImportResult struct {
+	Block   []string // normalized, deduplicated block entries
+	Allow   []string // normalized, deduplicated allow entries (AdGuard @@ exceptions)
+	Skipped int      // lines that are not comments but could not be converted (cosmetic rules, regexes, modifiers)
+}
+
+// ImportFile reads a local list file; see Parse.
+func ImportFile(path string, format Format) (*ImportResult, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open list: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	return Parse(io.LimitReader(f, maxImportBytes), format)
+}
+
+// Parse reads a hosts file, plain domain list or AdGuard-style list.
+// Comments and blank lines are ignored; unsupported rules are counted in Skipped.
+func Parse(r io.Reader, format Format) (*ImportResult, error) {
+	res := &ImportResult{}
+	seenBlock, seenAllow := make(map[string]bool), make(map[string]bool)
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		f := format
+		if f == FormatAuto {
+			f = detectLine(line)
+		}
+		var entries []string
+		var allow, ok bool
+		switch f {
+		case FormatHosts:
+			entries, ok = parseHostsLine(line)
+		case FormatAdGuard:
+			var e string
+			e, allow, ok = parseAdGuardLine(line)
+			if e != "" {
+				entries = []string{e}
+			}
+		default:
+			entries, ok = parseDomainLine(line)
+		}
+		if !ok {
+			res.Skipped++
+			continue
+		}
+		for _, e := range entries {
+			n, err := Normalize(e)
+			if err != nil {
+				res.Skipped++
+				continue
+			}
+			if allow {
+				if !seenAllow[n] {
+					seenAllow[n] = true
+					res.Allow = append(res.Allow, n)
+				}
+			} else if !seenBlock[n] {
+				seenBlock[n] = true
+				res.Block = append(res.Block, n)
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read list: %w", err)
+	}
+	return res, nil
+}
+
+func detectLine(line string) Format {
+	switch {
+	case strings.HasPrefix(line, "||"), strings.HasPrefix(line, "@@"), strings.HasPrefix(line, "|"),
+		strings.HasPrefix(line, "!"), strings.HasPrefix(line, "[Adblock"), strings.Contains(line, "##"):
+		return FormatAdGuard
+	}
+	if fields := strings.Fields(line); len(fields) > 1 && net.ParseIP(fields[0]) != nil {
+		return FormatHosts
+	}
+	return FormatDomains
+}
+
+// parseHostsLine returns the host names on a hosts-file line. ok is true for comments (no entries).
+func parseHostsLine(line string) ([]string, bool) {
+	if i := strings.Index(line, "#"); i >= 0 {
+		line = line[:i]
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil, true
+	}
+	if net.ParseIP(fields[0]) == nil || len(fields) < 2 {
+		return nil, false
+	}
+	var hosts []string
+	for _, h := range fields[1:] {
+		if !hostsIgnored[strings.ToLower(h)] {
+			hosts = append(hosts, h)
+		}
+	}
+	return hosts, true
+}
+
+// parseDomainLine returns the domain on a plain-list line. ok is true for comments (no entries).
+func parseDomainLine(line string) ([]string, bool) {
+	if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") || strings.HasPrefix(line, "//") {
+		return nil, true
+	}
+	if i := strings.Index(line, " #"); i >= 0 {
+		line = strings.TrimSpace(line[:i])
+	}
+	if strings.ContainsAny(line, " \t") {
+		return nil, false
+	}
+	return []string{line}, true
+}
+
+// parseAdGuardLine converts a network rule blocking a whole site. Returns ("", false, true) for comments.
+func parseAdGuardLine(line string) (entry string, allow, ok bool) {
+	if strings.HasPrefix(line, "!") || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "[") {
+		return "", false, true
+	}
+	if strings.Contains(line, "##") || strings.Contains(line, "#@#") || strings.Contains(line, "#?#") || strings.Contains(line, "#$#") {
+		return "", false, false // cosmetic rule
+	}
+	if strings.HasPrefix(line, "@@") {
+		allow = true
+		line = line[2:]
+	}
+	if i := strings.Index(line, "$"); i >= 0 {
+		for _, opt := range strings.Split(line[i+1:], ",") {
+			if !adguardModifiersAllowed[strings.ToLower(strings.TrimSpace(opt))] {
+				return "", false, false
+			}
+		}
+		line = line[:i]
+	}
+	if strings.HasPrefix(line, "/") && strings.HasSuffix(line, "/") {
+		return "", false, false // regex rule
+	}
+	line = strings.TrimPrefix(line, "||")
+	line = strings.TrimPrefix(line, "|")
+	line = strings.TrimSuffix(line, "|")
+	line = strings.TrimSuffix(line, "^")
+	if line == "" || strings.ContainsAny(line, "*^|") {
+		return "", false, false
+	}
+	return line, allow, true
+}
+
+// dedupe appends add to list, skipping entries already present. Returns the merged list and the count added.
+func dedupe(list, add []string) ([]string, int) {
+	seen := make(map[string]bool, len(list))
+	out := append([]string(nil), list...)
+	for _, x := range list {
+		seen[x] = true
+	}
+	var added int
+	for _, x := range add {
+		if !seen[x] {
+			seen[x] = true
+			out = append(out, x)
+			added++
+		}
+	}
+	return out, added
+}
+
+func toList(entries []string) []interface{} {
+	out := make([]interface{}, len(entries))
+	for i, e := range entries {
+		out[i] = e
+	}
+	return out
+}
@@ -0,0 +1,106 @@
+package urlfilter
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"example.com", "example.com", false},
+		{"  Example.COM. ", "example.com", false},
+		{"*.example.com", "example.com", false},
+		{".example.com", ".example.com", false},
+		{"https://www.example.com/", "https://www.example.com", false},
+		{"example.com:8080/games", "example.com:8080/games", false},
+		{"example.com/search?q=x", "example.com/search@q=x", false},
+		{"file://*", "file://*", false},
+		{"*", "*", false},
+		{"", "", true},
+		{"bad host.com", "", true},
+		{"exa$mple.com", "", true},
+	}
+	for _, tt := range tests {
+		got, err := Normalize(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("Normalize(%q) = %q, %v; want %q, err %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParseHosts(t *testing.T) {
+	in := `# StevenBlack-style hosts
+127.0.0.1 localhost
+::1 ip6-localhost
+0.0.0.0 ads.example.com tracker.example.net # trailing comment
+0.0.0.0 ads.example.com
+`
+	res, err := Parse(strings.NewReader(in), FormatAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(res.Block, ","); got != "ads.example.com,tracker.example.net" {
+		t.Errorf("Block = %s", got)
+	}
+}
+
+func TestParseAdGuard(t *testing.T) {
+	in := `[Adblock Plus 2.0]
+! Title: test
+||ads.example.com^
+||casino.example.org^$important
+@@||school.example.edu^
+example.com##.banner
+||example.com/ads/*.js
+/banner\d+/
+||cdn.example.com^$third-party
+`
+	res, err := Parse(strings.NewReader(in), FormatAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(res.Block, ","); got != "ads.example.com,casino.example.org" {
+		t.Errorf("Block = %s", got)
+	}
+	if got := strings.Join(res.Allow, ","); got != "school.example.edu" {
+		t.Errorf("Allow = %s", got)
+	}
+	if res.Skipped != 4 {
+		t.Errorf("Skipped = %d, want 4", res.Skipped)
+	}
+}
+
+func TestParseDomains(t *testing.T) {
+	res, err := Parse(strings.NewReader("# list\nexample.com\nEXAMPLE.com\nsub.example.org # note\n"), FormatDomains)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(res.Block, ","); got != "example.com,sub.example.org" {
+		t.Errorf("Block = %s", got)
+	}
+}
+
+func TestListsAddLimit(t *testing.T) {
+	var l Lists
+	if n, err := l.Add(false, "example.com", "example.com", "Example.com"); err != nil || n != 1 {
+		t.Fatalf("Add = %d, %v; want 1, nil", n, err)
+	}
+	many := make([]string, MaxEntries)
+	for i := range many {
+		many[i] = fmt.Sprintf("site%d.example", i)
+	}
+	if _, err := l.Add(false, many...); err == nil {
+		t.Error("expected limit error")
+	}
+	if len(l.Block) != 1 {
+		t.Errorf("list changed on error: %d entries", len(l.Block))
+	}
+	if l.Remove("EXAMPLE.com") != 1 || !l.IsEmpty() {
+		t.Errorf("expected empty lists after remove, got %+v", l)
+	}
+}
@@ -0,0 +1,385 @@
+package userconfig
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/cowardly/cowardly/internal/atomicfile"
+	"github.com/cowardly/cowardly/internal/brave"
+	"github.com/cowardly/cowardly/internal/presets"
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the `version:` written to cowardly.yaml.
+//
+//	0  single state, legacy: preset: <id>, base_preset, supplement: [rows], settings
+//	1  single state: preset.<id>.settings, supplement.<id>.settings, layers (extensions, dns, ...)
+//	2  profiles: active_profile, profiles.<name> (each a version 1 state)
+//	3  profiles.<name>.overrides: per-key overrides applied on top of everything else
+//	4  channels.<channel>: active_profile and profiles per Brave channel (stable, beta)
+//	5  profiles.<name>.target: where the last apply wrote (managed or user)
+//	6  backups: retention policy (keep_last, keep_daily, keep_weekly)
+const CurrentVersion = 6
+
+// Migration is one schema step, from version From to From+1.
+type Migration struct {
+	From        int
+	Description string
+	apply       func(data []byte) ([]byte, error)
+}
+
+// migrations holds every step, in order; migrations[i].From == i.
+// A format change adds a step here and bumps CurrentVersion.
+var migrations = []Migration{
+	{From: 0, Description: "legacy single state (preset: <id>) to preset.<id>.settings", apply: migrateV0},
+	{From: 1, Description: "single state to profiles (active_profile: default, profiles.default)", apply: migrateV1},
+	{From: 2, Description: "add per-profile overrides section", apply: migrateV2},
+	{From: 3, Description: "profiles to channels.stable (beta starts empty)", apply: migrateV3},
+	{From: 4, Description: "add per-profile apply target", apply: migrateV4},
+	{From: 5, Description: "add backup retention policy", apply: migrateV5},
+}
+
+// profilesFileV3 is the version 2 and 3 shape: one set of profiles shared by every channel.
+type profilesFileV3 struct {
+	Version      int `yaml:"version"`
+	profilesFile `yaml:",inline"`
+}
+
+// legacyFileV0 and fileV1 are the version 0 and 1 shapes with the optional version key; files written
+// before version 2 have none, but DetectVersion honours one.
+type legacyFileV0 struct {
+	Version         int `yaml:"version,omitempty"`
+	fileShapeLegacy `yaml:",inline"`
+}
+
+type fileV1 struct {
+	Version      int `yaml:"version,omitempty"`
+	fileShapeNew `yaml:",inline"`
+}
+
+// migrateV0 converts the legacy shape to the version 1 shape.
+func migrateV0(data []byte) ([]byte, error) {
+	var v0 legacyFileV0
+	if err := decodeStrict(data, &v0); err != nil {
+		return nil, err
+	}
+	d, err := readLegacyFormat(&v0.fileShapeLegacy)
+	if err != nil {
+		return nil, err
+	}
+	out := &fileV1{Version: 1}
+	if d != nil {
+		rows := settingsToRows(d.Settings)
+		switch {
+		case d.Preset == "privacy-guides":
+			base := d.BasePreset
+			if base == "" {
+				base = "custom"
+			}
+			sup := v0.Supplement
+			if len(sup) == 0 {
+				s, err := presets.LoadPrivacyGuides()
+				if err != nil {
+					return nil, err
+				}
+				sup = settingsToRows(s)
+			}
+			out.Preset = map[string]block{base: {Settings: rows}}
+			out.Supplement = map[string]block{"privacy_guides": {Settings: sup}}
+		case d.ApplyFile != "":
+			out.ApplyFile, out.Settings = d.ApplyFile, rows
+		case d.Preset != "":
+			out.Preset = map[string]block{d.Preset: {Settings: rows}}
+		default:
+			out.Settings = rows
+		}
+	}
+	return yaml.Marshal(out)
+}
+
+// migrateV1 moves a single state into the default profile.
+func migrateV1(data []byte) ([]byte, error) {
+	var f fileV1
+	if err := decodeStrict(data, &f); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(&profilesFileV3{
+		Version:      2,
+		profilesFile: profilesFile{Active: DefaultProfile, Profiles: map[string]*fileShapeNew{DefaultProfile: &f.fileShapeNew}},
+	})
+}
+
+// migrateV2 only bumps the version: overrides is a new, optional section. The bump makes older
+// cowardly versions refuse the file instead of failing on an unknown field.
+func migrateV2(data []byte) ([]byte, error) {
+	var pf profilesFileV3
+	if err := decodeStrict(data, &pf); err != nil {
+		return nil, err
+	}
+	pf.Version = 3
+	return yaml.Marshal(&pf)
+}
+
+// migrateV3 moves the shared profiles to the stable channel. Before version 4, --beta read and wrote
+// the same state; it was most likely last written for stable, so beta starts empty.
+func migrateV3(data []byte) ([]byte, error) {
+	var pf profilesFileV3
+	if err := decodeStrict(data, &pf); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(&configFile{
+		Version:  4,
+		Channels: map[string]*profilesFile{string(brave.VariantStable): &pf.profilesFile},
+	})
+}
+
+// migrateV4 only bumps the version: target is a new, optional field (see migrateV2).
+func migrateV4(data []byte) ([]byte, error) {
+	var cf configFile
+	if err := decodeStrict(data, &cf); err != nil {
+		return nil, err
+	}
+	cf.Version = 5
+	return yaml.Marshal(&cf)
+}
+
+// migrateV5 only bumps the version: backups is a new, optional section (see migrateV2).
+func migrateV5(data []byte) ([]byte, error) {
+	var cf configFile
+	if err := decodeStrict(data, &cf); err != nil {
+		return nil, err
+	}
+	cf.Version = 6
+	return yaml.Marshal(&cf)
+}
+
+// decodeStrict decodes data into v, rejecting unknown fields. Errors carry YAML line numbers.
+func decodeStrict(data []byte, v interface{}) error {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// topLevel returns the top-level mapping of data, or nil if data is empty.
+func topLevel(data []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: expected a mapping at the top level", root.Line)
+	}
+	return root, nil
+}
+
+// mappingValue returns the value node for key in mapping m, or nil.
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// DetectVersion returns the schema version of a config file: its `version:` field, or for files written
+// without one, 4 if it has channels, 2 if it has profiles, 0 if it has the legacy preset/supplement shape,
+// and 1 otherwise.
+func DetectVersion(data []byte) (int, error) {
+	root, err := topLevel(data)
+	if err != nil || root == nil {
+		return CurrentVersion, err
+	}
+	if v := mappingValue(root, "version"); v != nil {
+		var n int
+		if err := v.Decode(&n); err != nil {
+			return 0, fmt.Errorf("line %d: version must be an integer", v.Line)
+		}
+		if n < 0 {
+			return 0, fmt.Errorf("line %d: invalid version %d", v.Line, n)
+		}
+		return n, nil
+	}
+	if mappingValue(root, "channels") != nil {
+		return 4, nil
+	}
+	if mappingValue(root, "profiles") != nil || mappingValue(root, "active_profile") != nil {
+		return 2, nil
+	}
+	if p := mappingValue(root, "preset"); p != nil && p.Kind == yaml.ScalarNode {
+		return 0, nil
+	}
+	if s := mappingValue(root, "supplement"); s != nil && s.Kind == yaml.SequenceNode {
+		return 0, nil
+	}
+	if mappingValue(root, "base_preset") != nil {
+		return 0, nil
+	}
+	return 1, nil
+}
+
+// upgrade runs the migrations from version to CurrentVersion, returning the steps it ran.
+func upgrade(data []byte, version int) ([]byte, []Migration, error) {
+	if version > CurrentVersion {
+		return nil, nil, fmt.Errorf("version %d is newer than this cowardly supports (%d); upgrade cowardly", version, CurrentVersion)
+	}
+	var steps []Migration
+	for v := version; v < CurrentVersion; v++ {
+		m := migrations[v]
+		out, err := m.apply(data)
+		if err != nil {
+			return nil, steps, fmt.Errorf("migrate v%d to v%d: %w", v, v+1, err)
+		}
+		data = out
+		steps = append(steps, m)
+	}
+	return data, steps, nil
+}
+
+// decodeFile parses a config file of any version into the current shape.
+func decodeFile(data []byte) (*configFile, []Migration, error) {
+	cf := &configFile{Version: CurrentVersion, Channels: make(map[string]*profilesFile)}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return cf, nil, nil
+	}
+	version, err := DetectVersion(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := validatePresets(data); err != nil {
+		return nil, nil, err
+	}
+	data, steps, err := upgrade(data, version)
+	if err != nil {
+		return nil, steps, err
+	}
+	var onDisk configFile
+	if err := decodeStrict(data, &onDisk); err != nil {
+		return nil, steps, err
+	}
+	cf.Backups = onDisk.Backups
+	for channel, disk := range onDisk.Channels {
+		if _, err := brave.ParseVariant(channel); err != nil {
+			return nil, steps, err
+		}
+		pf := &profilesFile{Active: DefaultProfile, Profiles: make(map[string]*fileShapeNew)}
+		if disk != nil {
+			for name, f := range disk.Profiles {
+				if err := ValidateProfileName(name); err != nil {
+					return nil, steps, err
+				}
+				if f == nil {
+					f = &fileShapeNew{}
+				}
+				pf.Profiles[name] = f
+			}
+			if disk.Active != "" {
+				pf.Active = disk.Active
+			}
+		}
+		cf.Channels[channel] = pf
+	}
+	return cf, steps, nil
+}
+
+// validatePresets rejects a state with more than one preset block, since only one can be the desired
+// state. It checks the top level (version 1), every profile (versions 2 and 3) and every profile of
+// every channel (version 4).
+func validatePresets(data []byte) error {
+	root, err := topLevel(data)
+	if err != nil || root == nil {
+		return err
+	}
+	check := func(state *yaml.Node, where string) error {
+		p := mappingValue(state, "preset")
+		if p == nil || p.Kind != yaml.MappingNode || len(p.Content) <= 2 {
+			return nil
+		}
+		var ids []string
+		for j := 0; j < len(p.Content); j += 2 {
+			ids = append(ids, p.Content[j].Value)
+		}
+		sort.Strings(ids)
+		return fmt.Errorf("line %d: %s has %d presets (%s); keep one", p.Line, where, len(ids), strings.Join(ids, ", "))
+	}
+	if err := check(root, "config"); err != nil {
+		return err
+	}
+	checkProfiles := func(m *yaml.Node, where string) error {
+		profiles := mappingValue(m, "profiles")
+		if profiles == nil || profiles.Kind != yaml.MappingNode {
+			return nil
+		}
+		for i := 0; i+1 < len(profiles.Content); i += 2 {
+			if err := check(profiles.Content[i+1], fmt.Sprintf("%sprofile %q", where, profiles.Content[i].Value)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := checkProfiles(root, ""); err != nil {
+		return err
+	}
+	channels := mappingValue(root, "channels")
+	if channels == nil || channels.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(channels.Content); i += 2 {
+		if err := checkProfiles(channels.Content[i+1], channels.Content[i].Value+" "); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Migrate upgrades the config file to CurrentVersion. It returns the detected version, the steps run and
+// the upgraded file. Unless dryRun, a file that needed migrating is saved, with the original kept
+// next to it as cowardly.yaml.v<N>.bak.
+func (st Store) Migrate(dryRun bool) (from int, steps []Migration, out []byte, err error) {
+	path := st.Path()
+	if !dryRun {
+		release, err := st.lock()
+		if err != nil {
+			return 0, nil, nil, err
+		}
+		defer release()
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("read config: %w", err)
+	}
+	from, err = DetectVersion(data)
+	if err != nil {
+		return 0, nil, nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	cf, steps, err := decodeFile(data)
+	if err != nil {
+		return from, steps, nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	out, err = marshalFile(cf)
+	if err != nil {
+		return from, steps, nil, err
+	}
+	if dryRun || from == CurrentVersion {
+		return from, steps, out, nil
+	}
+	if err := atomicfile.WriteFile(fmt.Sprintf("%s.v%d.bak", path, from), data, 0600); err != nil {
+		return from, steps, nil, fmt.Errorf("back up config: %w", err)
+	}
+	return from, steps, out, st.save(cf)
+}
@@ -0,0 +1,106 @@
+package userconfig
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDetectVersion(t *testing.T) {
+	tests := []struct {
+		data string
+		want int
+	}{
+		{"version: 4\nchannels: {}\n", 4},
+		{"channels:\n  beta: {}\n", 4},
+		{"version: 3\nprofiles: {}\n", 3},
+		{"version: 2\nprofiles: {}\n", 2},
+		{"version: 1\nsettings: []\n", 1},
+		{"profiles:\n  default: {}\n", 2},
+		{"preset: quick\nsettings: []\n", 0},
+		{"supplement:\n  - key: A\n    value: true\n    type: bool\n", 0},
+		{"preset:\n  quick:\n    settings: []\n", 1},
+		{"dns:\n  mode: off\n", 1},
+		{"", CurrentVersion},
+	}
+	for _, tt := range tests {
+		got, err := DetectVersion([]byte(tt.data))
+		if err != nil || got != tt.want {
+			t.Errorf("DetectVersion(%q) = %d, %v; want %d", tt.data, got, err, tt.want)
+		}
+	}
+}
+
+func TestDecodeFileMigratesLegacy(t *testing.T) {
+	cf, steps, err := decodeFile([]byte("preset: quick\nsettings:\n  - key: TorDisabled\n    value: true\n    type: bool\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 6 || steps[0].From != 0 || steps[5].From != 5 {
+		t.Fatalf("steps = %+v, want v0->v1->v2->v3->v4->v5->v6", steps)
+	}
+	d, err := cf.Channels["stable"].Profiles[DefaultProfile].desired()
+	if err != nil || d == nil || d.Preset != "quick" || len(d.Settings) != 1 {
+		t.Fatalf("default profile = %+v, %v", d, err)
+	}
+}
+
+func TestDecodeFileStrict(t *testing.T) {
+	tests := []struct {
+		name, data, want string
+	}{
+		{"unknown field", "version: 2\nprofiles:\n  default:\n    dns:\n      mode: off\n    colour: blue\n", "line 6: field colour not found"},
+		{"unknown field v1", "preset:\n  quick:\n    settings: []\nextras: 1\n", "line 4: field extras not found"},
+		{"several presets", "version: 2\nprofiles:\n  work:\n    preset:\n      quick: {settings: []}\n      max-privacy: {settings: []}\n", `line 5: profile "work" has 2 presets (max-privacy, quick)`},
+		{"newer version", "version: 99\n", "newer than this cowardly supports"},
+		{"bad profile name", "version: 2\nprofiles:\n  Work: {}\n", "invalid profile name"},
+		{"several presets v4", "version: 4\nchannels:\n  beta:\n    profiles:\n      work:\n        preset:\n          quick: {settings: []}\n          balanced: {settings: []}\n", `line 7: beta profile "work" has 2 presets`},
+		{"unknown channel", "version: 4\nchannels:\n  canary: {}\n", `unknown channel "canary"`},
+		{"unknown retention field", "version: 6\nbackups:\n  keep_hourly: 3\n", "line 3: field keep_hourly not found"},
+	}
+	for _, tt := range tests {
+		_, _, err := decodeFile([]byte(tt.data))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: decodeFile() error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestDecodeFileExplicitVersion(t *testing.T) {
+	for _, data := range []string{
+		"version: 0\npreset: quick\nsettings:\n  - key: TorDisabled\n    value: true\n    type: bool\n",
+		"version: 1\npreset:\n  quick:\n    settings:\n      - key: TorDisabled\n        value: true\n        type: bool\n",
+	} {
+		cf, _, err := decodeFile([]byte(data))
+		if err != nil {
+			t.Errorf("decodeFile(%q) = %v", data, err)
+			continue
+		}
+		if d, err := cf.Channels["stable"].Profiles[DefaultProfile].desired(); err != nil || d == nil || d.Preset != "quick" || len(d.Settings) != 1 {
+			t.Errorf("decodeFile(%q): default profile = %+v, %v", data, d, err)
+		}
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	st := writeConfig(t, "preset:\n  quick:\n    settings:\n      - key: TorDisabled\n        value: true\n        type: bool\n")
+	from, steps, out, err := st.Migrate(true)
+	if err != nil || from != 1 || len(steps) != 5 || !strings.HasPrefix(string(out), "version: 6\n") {
+		t.Fatalf("Migrate(dry run) = %d, %+v, %q, %v", from, steps, out, err)
+	}
+	if data, _ := os.ReadFile(st.Path()); strings.Contains(string(data), "version") {
+		t.Fatal("dry run wrote the config file")
+	}
+	if _, _, _, err := st.Migrate(false); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(st.Path()); !strings.HasPrefix(string(data), "version: 6\n") {
+		t.Fatalf("config not migrated:\n%s", data)
+	}
+	if _, err := os.Stat(st.Path() + ".v1.bak"); err != nil {
+		t.Errorf("no backup of the v1 file: %v", err)
+	}
+	if from, steps, _, err := st.Migrate(false); err != nil || from != CurrentVersion || len(steps) != 0 {
+		t.Errorf("Migrate(current) = %d, %+v, %v", from, steps, err)
+	}
+}
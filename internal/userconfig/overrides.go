@@ -0,0 +1,152 @@
+package userconfig
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cowardly/cowardly/internal/bookmarks"
+	"github.com/cowardly/cowardly/internal/brave"
+	"github.com/cowardly/cowardly/internal/config"
+	"github.com/cowardly/cowardly/internal/content"
+	"github.com/cowardly/cowardly/internal/doh"
+	"github.com/cowardly/cowardly/internal/extensions"
+	"github.com/cowardly/cowardly/internal/presets"
+	"github.com/cowardly/cowardly/internal/proxy"
+	"github.com/cowardly/cowardly/internal/search"
+	"github.com/cowardly/cowardly/internal/shields"
+	"github.com/cowardly/cowardly/internal/startup"
+	"github.com/cowardly/cowardly/internal/urlfilter"
+	"gopkg.in/yaml.v3"
+)
+
+// knownType returns the value type of key from the Custom settings or the built-in presets, or "".
+func knownType(key string) brave.ValueType {
+	for _, s := range config.CustomSettings() {
+		if s.Key == key {
+			return s.Type
+		}
+	}
+	for _, p := range presets.All() {
+		for _, s := range p.Settings {
+			if s.Key == key {
+				return s.Type
+			}
+		}
+	}
+	return ""
+}
+
+// knownKey reports whether key is a policy cowardly knows: a Custom setting, a preset key, or a key one of
+// the layers (extensions, URL filters, bookmarks, DNS, proxy, content, startup, search, Shields) writes.
+func knownKey(key string) bool {
+	if knownType(key) != "" {
+		return true
+	}
+	for _, keys := range [][]string{
+		extensions.Keys, urlfilter.Keys, {bookmarks.Key}, doh.Keys, proxy.Keys, content.Keys(),
+		startup.Keys, search.Keys, shields.Keys(),
+	} {
+		for _, k := range keys {
+			if k == key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ParseOverride parses a Key=Value argument into a setting. The value is read as YAML (true, 3, [a, b],
+// {k: v}, text); for keys cowardly knows, the type comes from the key instead, so "3" stays a string
+// where the policy is a string. Shields and startup keys accept their level names. A key cowardly does
+// not know is most likely a typo and is rejected unless force is set.
+func ParseOverride(arg string, force bool) (brave.Setting, error) {
+	key, raw, ok := strings.Cut(arg, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return brave.Setting{}, fmt.Errorf("%q: expected Key=Value", arg)
+	}
+	var val interface{}
+	if err := yaml.Unmarshal([]byte(raw), &val); err != nil {
+		return brave.Setting{}, fmt.Errorf("%s: %w", key, err)
+	}
+	typ := knownType(key)
+	if typ == brave.TypeString {
+		val = raw
+	}
+	if typ == "" {
+		switch val.(type) {
+		case bool:
+			typ = brave.TypeBool
+		case int:
+			typ = brave.TypeInteger
+		case []interface{}:
+			typ = brave.TypeList
+		case map[string]interface{}:
+			typ = brave.TypeDict
+		default:
+			typ, val = brave.TypeString, raw
+		}
+	}
+	s, err := presets.ConvertSettingRow(presets.SettingRow{Key: key, Value: val, Type: string(typ)})
+	if err != nil {
+		// Shields and startup errors already name the key.
+		if !strings.HasPrefix(err.Error(), key+":") {
+			err = fmt.Errorf("%s: %w", key, err)
+		}
+		return brave.Setting{}, err
+	}
+	if !force && !knownKey(key) {
+		return brave.Setting{}, fmt.Errorf("%s: not a policy cowardly knows (check the spelling, or use --set --force to set it anyway)", key)
+	}
+	return s, nil
+}
+
+// WriteOverrides saves the per-key overrides, keeping the rest of the desired state.
+func (st Store) WriteOverrides(settings []brave.Setting) error {
+	return st.updateActive(func(f *fileShapeNew) {
+		f.Overrides = nil
+		if len(settings) > 0 {
+			f.Overrides = settingsToRows(settings)
+		}
+	})
+}
+
+// SetOverride adds or replaces the override for s.Key.
+func (st Store) SetOverride(s brave.Setting) error {
+	return st.updateOverrides(func(current []brave.Setting) []brave.Setting {
+		return mergeSettings(current, []brave.Setting{s})
+	})
+}
+
+// UnsetOverride removes the override for key. It reports whether there was one.
+func (st Store) UnsetOverride(key string) (bool, error) {
+	found := false
+	err := st.updateOverrides(func(current []brave.Setting) []brave.Setting {
+		var out []brave.Setting
+		for _, s := range current {
+			if s.Key == key {
+				found = true
+				continue
+			}
+			out = append(out, s)
+		}
+		return out
+	})
+	return found, err
+}
+
+// updateOverrides replaces the overrides of the active profile with fn(current overrides) and saves them.
+func (st Store) updateOverrides(fn func(current []brave.Setting) []brave.Setting) error {
+	return st.update(func(_ *configFile, pf *profilesFile) error {
+		f := pf.active()
+		current, err := rowsToSettings(f.Overrides)
+		if err != nil {
+			return fmt.Errorf("overrides: %w", err)
+		}
+		f.Overrides = nil
+		if out := fn(current); len(out) > 0 {
+			f.Overrides = settingsToRows(out)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,75 @@
+package userconfig
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cowardly/cowardly/internal/brave"
+)
+
+func TestParseOverride(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want brave.Setting
+	}{
+		{"MetricsReportingEnabled=true", brave.Setting{Key: "MetricsReportingEnabled", Value: true, Type: brave.TypeBool}},
+		{"SafeBrowsingProtectionLevel=1", brave.Setting{Key: "SafeBrowsingProtectionLevel", Value: 1, Type: brave.TypeInteger}},
+		{"WebRtcIPHandling=default", brave.Setting{Key: "WebRtcIPHandling", Value: "default", Type: brave.TypeString}},
+		{"SomeNewPolicy=42", brave.Setting{Key: "SomeNewPolicy", Value: 42, Type: brave.TypeInteger}},
+		{"SomeNewPolicy=hello world", brave.Setting{Key: "SomeNewPolicy", Value: "hello world", Type: brave.TypeString}},
+		{"SomeList=[a, b]", brave.Setting{Key: "SomeList", Value: []interface{}{"a", "b"}, Type: brave.TypeList}},
+	}
+	for _, tt := range tests {
+		got, err := ParseOverride(tt.arg, true)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseOverride(%q) = %+v, %v; want %+v", tt.arg, got, err, tt.want)
+		}
+	}
+	for _, arg := range []string{"NoValue", "=true", "bad-key=1", "SafeBrowsingProtectionLevel=high"} {
+		if _, err := ParseOverride(arg, true); err == nil {
+			t.Errorf("ParseOverride(%q) = nil error", arg)
+		}
+	}
+	if _, err := ParseOverride("SafeBrowsingProtectionLevel=high", true); err == nil || !strings.HasPrefix(err.Error(), "SafeBrowsingProtectionLevel: ") {
+		t.Errorf("ParseOverride() error = %v, want it to start with the key", err)
+	}
+	// Without force, only keys cowardly knows are accepted.
+	if _, err := ParseOverride("SomeNewPolicy=42", false); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("ParseOverride(unknown key) = %v, want an error mentioning --force", err)
+	}
+	for _, arg := range []string{"MetricsReportingEnabled=false", "DnsOverHttpsMode=secure", "CookiesAllowedForUrls=[a.example]", "DefaultBraveAdblockSetting=block"} {
+		if _, err := ParseOverride(arg, false); err != nil {
+			t.Errorf("ParseOverride(%q, false) = %v", arg, err)
+		}
+	}
+}
+
+func TestOverridesSurvivePresetApply(t *testing.T) {
+	st := writeConfig(t, "")
+	if err := st.WritePreset("quick", []brave.Setting{{Key: "TorDisabled", Value: true, Type: brave.TypeBool}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.SetOverride(brave.Setting{Key: "TorDisabled", Value: false, Type: brave.TypeBool}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.WritePreset("max-privacy", []brave.Setting{{Key: "TorDisabled", Value: true, Type: brave.TypeBool}}); err != nil {
+		t.Fatal(err)
+	}
+	d, err := st.Read()
+	if err != nil || d == nil || d.Preset != "max-privacy" || len(d.Overrides) != 1 {
+		t.Fatalf("Read() = %+v, %v", d, err)
+	}
+	if eff := d.Effective(); len(eff) != 1 || eff[0].Value != false {
+		t.Errorf("Effective() = %+v, want the override to win", eff)
+	}
+	if removed, err := st.UnsetOverride("TorDisabled"); err != nil || !removed {
+		t.Fatalf("UnsetOverride() = %v, %v", removed, err)
+	}
+	if removed, err := st.UnsetOverride("TorDisabled"); err != nil || removed {
+		t.Errorf("second st.UnsetOverride() = %v, %v", removed, err)
+	}
+	if d, _ := st.Read(); d == nil || len(d.Overrides) != 0 || d.Effective()[0].Value != true {
+		t.Errorf("after unset: %+v", d)
+	}
+}
@@ -0,0 +1,224 @@
+package userconfig
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/cowardly/cowardly/internal/atomicfile"
+	"github.com/cowardly/cowardly/internal/brave"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultProfile is the profile a single-state config file is migrated into, and the active
+// profile when none is set.
+const DefaultProfile = "default"
+
+// configFile is the on-disk shape of cowardly.yaml: the schema version, the backup retention policy and,
+// per Brave channel (brave.Variant: stable, beta), its profiles.
+type configFile struct {
+	Version  int                      `yaml:"version"`
+	Backups  *retentionFile           `yaml:"backups,omitempty"`
+	Channels map[string]*profilesFile `yaml:"channels"`
+}
+
+// profilesFile is the desired state of one channel: the active profile name and one desired state per profile.
+type profilesFile struct {
+	Active   string                   `yaml:"active_profile"`
+	Profiles map[string]*fileShapeNew `yaml:"profiles"`
+}
+
+// Channel returns the channel that reads and writes use: the Brave variant selected with --beta or --channel.
+func Channel() string {
+	return string(brave.CurrentVariant())
+}
+
+// channel returns the profiles of the current channel, creating them if they do not exist.
+func (cf *configFile) channel() *profilesFile {
+	pf := cf.Channels[Channel()]
+	if pf == nil {
+		pf = &profilesFile{Active: DefaultProfile, Profiles: make(map[string]*fileShapeNew)}
+		cf.Channels[Channel()] = pf
+	}
+	return pf
+}
+
+// active returns the active profile, creating it if it does not exist.
+func (pf *profilesFile) active() *fileShapeNew {
+	f := pf.Profiles[pf.Active]
+	if f == nil {
+		f = &fileShapeNew{}
+		pf.Profiles[pf.Active] = f
+	}
+	return f
+}
+
+// loadFile reads the config file, migrating older versions in memory (see migrations.go), and returns
+// it with the profiles of the current channel. A migrated file is rewritten in the current shape on the next save.
+func (st Store) loadFile() (*configFile, *profilesFile, error) {
+	path := st.Path()
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("read config: %w", err)
+	}
+	cf, _, err := decodeFile(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	return cf, cf.channel(), nil
+}
+
+// marshalFile encodes cf as YAML at CurrentVersion.
+func marshalFile(cf *configFile) ([]byte, error) {
+	cf.Version = CurrentVersion
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(cf); err != nil {
+		return nil, fmt.Errorf("marshal config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("close encoder: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// update loads the config file, lets fn change it and saves it. It holds the cowardly lock (see package
+// lock and Holding) from load to save, so concurrent processes do not overwrite each other's changes.
+func (st Store) update(fn func(cf *configFile, pf *profilesFile) error) error {
+	release, err := st.lock()
+	if err != nil {
+		return err
+	}
+	defer release()
+	cf, pf, err := st.loadFile()
+	if err != nil {
+		return err
+	}
+	if err := fn(cf, pf); err != nil {
+		return err
+	}
+	return st.save(cf)
+}
+
+// save writes cf to the config file (temp file and rename, so a reader never sees half a file).
+func (st Store) save(cf *configFile) error {
+	path := st.Path()
+	data, err := marshalFile(cf)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create config dir: %w", err)
+	}
+	return atomicfile.WriteFile(path, data, 0600)
+}
+
+var profileNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// ValidateProfileName checks that name is lower-case letters, digits, '-' and '_'.
+func ValidateProfileName(name string) error {
+	if !profileNameRe.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q (use lower-case letters, digits, - and _)", name)
+	}
+	return nil
+}
+
+// ActiveProfile returns the name of the active profile.
+func (st Store) ActiveProfile() (string, error) {
+	_, pf, err := st.loadFile()
+	if err != nil {
+		return "", err
+	}
+	return pf.Active, nil
+}
+
+// Profiles returns the profile names of the current channel, sorted. The active profile is always included.
+func (st Store) Profiles() ([]string, error) {
+	_, pf, err := st.loadFile()
+	if err != nil {
+		return nil, err
+	}
+	pf.active()
+	names := make([]string, 0, len(pf.Profiles))
+	for name := range pf.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ReadProfile loads the desired state of the named profile. Returns (nil, nil) if it is empty.
+func (st Store) ReadProfile(name string) (*DesiredState, error) {
+	_, pf, err := st.loadFile()
+	if err != nil {
+		return nil, err
+	}
+	f, ok := pf.Profiles[name]
+	if !ok {
+		if name == pf.Active {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("profile %q not found", name)
+	}
+	return f.desired()
+}
+
+// CreateProfile adds an empty profile, or a copy of the profile named from.
+func (st Store) CreateProfile(name, from string) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	return st.update(func(_ *configFile, pf *profilesFile) error {
+		pf.active()
+		if _, ok := pf.Profiles[name]; ok {
+			return fmt.Errorf("profile %q already exists", name)
+		}
+		f := &fileShapeNew{}
+		if from != "" {
+			src, ok := pf.Profiles[from]
+			if !ok {
+				return fmt.Errorf("profile %q not found", from)
+			}
+			// Round-trip through YAML for a deep copy.
+			data, err := yaml.Marshal(src)
+			if err != nil {
+				return fmt.Errorf("copy profile: %w", err)
+			}
+			if err := yaml.Unmarshal(data, f); err != nil {
+				return fmt.Errorf("copy profile: %w", err)
+			}
+		}
+		pf.Profiles[name] = f
+		return nil
+	})
+}
+
+// SwitchProfile makes name the active profile. Read, --reapply and the writers use it from then on.
+func (st Store) SwitchProfile(name string) error {
+	return st.update(func(_ *configFile, pf *profilesFile) error {
+		pf.active()
+		if _, ok := pf.Profiles[name]; !ok {
+			return fmt.Errorf("profile %q not found (create it with: cowardly profile create %s)", name, name)
+		}
+		pf.Active = name
+		return nil
+	})
+}
+
+// DeleteProfile removes a profile. The active profile cannot be deleted.
+func (st Store) DeleteProfile(name string) error {
+	return st.update(func(_ *configFile, pf *profilesFile) error {
+		if name == pf.Active {
+			return fmt.Errorf("profile %q is active; switch to another profile first", name)
+		}
+		if _, ok := pf.Profiles[name]; !ok {
+			return fmt.Errorf("profile %q not found", name)
+		}
+		delete(pf.Profiles, name)
+		return nil
+	})
+}
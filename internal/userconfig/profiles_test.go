@@ -0,0 +1,321 @@
+package userconfig
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/cowardly/cowardly/internal/brave"
+	"github.com/cowardly/cowardly/internal/doh"
+	"github.com/cowardly/cowardly/internal/lock"
+	"github.com/cowardly/cowardly/internal/paths"
+	"github.com/cowardly/cowardly/internal/urlfilter"
+)
+
+// writeConfig returns a store in a temp dir and writes data as its cowardly.yaml.
+func writeConfig(t *testing.T, data string) Store {
+	t.Helper()
+	dir := t.TempDir()
+	st := New(paths.Paths{Config: filepath.Join(dir, "config", "cowardly.yaml"), State: filepath.Join(dir, "state")})
+	if data != "" {
+		if err := os.MkdirAll(st.Dir(), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(st.Path(), []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return st
+}
+
+func TestMigrateSingleState(t *testing.T) {
+	st := writeConfig(t, `preset:
+  quick:
+    settings:
+      - key: BraveRewardsDisabled
+        value: true
+        type: bool
+dns:
+  mode: off
+`)
+	active, err := st.ActiveProfile()
+	if err != nil || active != DefaultProfile {
+		t.Fatalf("ActiveProfile() = %q, %v", active, err)
+	}
+	d, err := st.Read()
+	if err != nil || d == nil {
+		t.Fatalf("Read() = %v, %v", d, err)
+	}
+	if d.Preset != "quick" || len(d.Settings) != 1 || d.DNS.Mode != doh.ModeOff {
+		t.Fatalf("Read() = %+v", d)
+	}
+	// The next write stores the file in the profiles shape.
+	if err := st.WriteDNS(doh.Selection{}); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(st.Path())
+	if !strings.Contains(string(data), "active_profile: default") || !strings.Contains(string(data), "profiles:") {
+		t.Fatalf("config not migrated:\n%s", data)
+	}
+	d, err = st.Read()
+	if err != nil || d == nil || d.Preset != "quick" || !d.DNS.IsEmpty() {
+		t.Fatalf("Read() after migration = %+v, %v", d, err)
+	}
+}
+
+func TestMigrateLegacy(t *testing.T) {
+	st := writeConfig(t, `preset: quick
+settings:
+  - key: BraveRewardsDisabled
+    value: true
+    type: bool
+`)
+	d, err := st.Read()
+	if err != nil || d == nil || d.Preset != "quick" || len(d.Settings) != 1 {
+		t.Fatalf("Read() = %+v, %v", d, err)
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	st := writeConfig(t, "")
+	rewards := []brave.Setting{{Key: "BraveRewardsDisabled", Value: true, Type: brave.TypeBool}}
+	if err := st.WritePreset("quick", rewards); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.CreateProfile("work", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.CreateProfile("presentation", DefaultProfile); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.CreateProfile("work", ""); err == nil {
+		t.Error("CreateProfile(existing) = nil, want error")
+	}
+	if err := st.CreateProfile("Bad Name", ""); err == nil {
+		t.Error("CreateProfile(invalid name) = nil, want error")
+	}
+	names, err := st.Profiles()
+	if err != nil || strings.Join(names, ",") != "default,presentation,work" {
+		t.Fatalf("Profiles() = %v, %v", names, err)
+	}
+
+	if err := st.SwitchProfile("work"); err != nil {
+		t.Fatal(err)
+	}
+	if d, err := st.Read(); err != nil || d != nil {
+		t.Fatalf("Read() on empty profile = %+v, %v", d, err)
+	}
+	if err := st.WriteSettings(rewards); err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := st.Read(); d == nil || d.Preset != "custom" {
+		t.Fatalf("Read() on work = %+v", d)
+	}
+	if d, _ := st.ReadProfile(DefaultProfile); d == nil || d.Preset != "quick" {
+		t.Fatalf("ReadProfile(default) = %+v", d)
+	}
+	if d, _ := st.ReadProfile("presentation"); d == nil || d.Preset != "quick" {
+		t.Fatalf("ReadProfile(presentation) = %+v", d)
+	}
+
+	if err := st.DeleteProfile("work"); err == nil {
+		t.Error("DeleteProfile(active) = nil, want error")
+	}
+	if err := st.DeleteProfile("presentation"); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.SwitchProfile("presentation"); err == nil {
+		t.Error("SwitchProfile(deleted) = nil, want error")
+	}
+}
+
+func TestChannelsAreSeparate(t *testing.T) {
+	st := writeConfig(t, "")
+	t.Cleanup(func() { brave.UseVariant(brave.VariantStable) })
+	rewards := []brave.Setting{{Key: "BraveRewardsDisabled", Value: true, Type: brave.TypeBool}}
+	if err := st.WritePreset("quick", rewards); err != nil {
+		t.Fatal(err)
+	}
+	brave.UseVariant(brave.VariantBeta)
+	if d, err := st.Read(); err != nil || d != nil {
+		t.Fatalf("Read() on beta = %+v, %v; want empty", d, err)
+	}
+	if err := st.WritePreset("max-privacy", rewards); err != nil {
+		t.Fatal(err)
+	}
+	brave.UseVariant(brave.VariantStable)
+	if d, _ := st.Read(); d == nil || d.Preset != "quick" {
+		t.Fatalf("Read() on stable = %+v, want quick", d)
+	}
+	brave.UseVariant(brave.VariantBeta)
+	if d, _ := st.Read(); d == nil || d.Preset != "max-privacy" {
+		t.Fatalf("Read() on beta = %+v, want max-privacy", d)
+	}
+}
+
+func TestWriteHonoursLock(t *testing.T) {
+	st := writeConfig(t, "")
+	state := st.paths.State
+	if err := os.MkdirAll(state, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	// Hold the lock through another open file, as a second cowardly process would.
+	f, err := os.OpenFile(filepath.Join(state, lock.FileName), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		t.Fatal(err)
+	}
+	var busy *lock.BusyError
+	if err := st.CreateProfile("work", ""); !errors.As(err, &busy) {
+		t.Fatalf("CreateProfile() while locked = %v, want BusyError", err)
+	}
+	if _, err := os.Stat(st.Path()); !os.IsNotExist(err) {
+		t.Fatalf("config written while locked: %v", err)
+	}
+	_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+	if err := st.CreateProfile("work", ""); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWriteHolding(t *testing.T) {
+	st := writeConfig(t, "")
+	l, err := lock.Acquire(st.paths.State)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Writes through the holding store run under l instead of waiting for it.
+	if err := st.Holding(l).CreateProfile("work", ""); err != nil {
+		t.Fatal(err)
+	}
+	other, err := lock.Acquire(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Release()
+	if err := st.Holding(other).CreateProfile("home", ""); err == nil {
+		t.Error("CreateProfile() holding the lock of another state dir succeeded")
+	}
+	l.Release()
+	if err := st.Holding(l).CreateProfile("home", ""); err == nil {
+		t.Error("CreateProfile() holding a released lock succeeded")
+	}
+	if err := st.CreateProfile("home", ""); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWriteTarget(t *testing.T) {
+	st := writeConfig(t, "")
+	rewards := []brave.Setting{{Key: "BraveRewardsDisabled", Value: true, Type: brave.TypeBool}}
+	if err := st.WritePreset("quick", rewards); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.WriteTarget(false); err != nil {
+		t.Fatal(err)
+	}
+	// A new apply keeps the target until the caller records the new one.
+	if err := st.WritePreset("max-privacy", rewards); err != nil {
+		t.Fatal(err)
+	}
+	if d, err := st.Read(); err != nil || d == nil || d.Target != brave.TargetUser {
+		t.Fatalf("Read() = %+v, %v; want target user", d, err)
+	}
+	data, _ := os.ReadFile(st.Path())
+	if err := os.WriteFile(st.Path(), []byte(strings.Replace(string(data), "target: user", "target: auto", 1)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.Read(); err == nil || !strings.Contains(err.Error(), `target "auto"`) {
+		t.Errorf("Read() with target auto = %v, want error", err)
+	}
+}
+
+func TestUpdateURLFilters(t *testing.T) {
+	st := writeConfig(t, "")
+	add := func(host string) func(l *urlfilter.Lists) error {
+		return func(l *urlfilter.Lists) error {
+			l.Block = append(l.Block, host)
+			return nil
+		}
+	}
+	if err := st.UpdateURLFilters(add("a.example")); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.UpdateURLFilters(add("b.example")); err != nil {
+		t.Fatal(err)
+	}
+	failed := errors.New("bad entry")
+	if err := st.UpdateURLFilters(func(l *urlfilter.Lists) error {
+		l.Block = nil
+		return failed
+	}); !errors.Is(err, failed) {
+		t.Fatalf("UpdateURLFilters() = %v, want %v", err, failed)
+	}
+	d, err := st.Read()
+	if err != nil || d == nil {
+		t.Fatalf("Read() = %+v, %v", d, err)
+	}
+	if got := strings.Join(d.URLFilters.Block, ","); got != "a.example,b.example" {
+		t.Errorf("block list = %q, want a.example,b.example", got)
+	}
+	if err := st.UpdateURLFilters(func(l *urlfilter.Lists) error {
+		l.Block = nil
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if d, err := st.Read(); err != nil || d != nil {
+		t.Errorf("Read() after emptying the lists = %+v, %v; want nil", d, err)
+	}
+}
+
+func TestStaleLayerKeys(t *testing.T) {
+	st := writeConfig(t, "")
+	if err := st.WriteURLFilters(urlfilter.Lists{Block: []string{"a.example"}}); err != nil {
+		t.Fatal(err)
+	}
+	d, err := st.Read()
+	if err != nil || d == nil {
+		t.Fatalf("Read() = %+v, %v", d, err)
+	}
+	stale := strings.Join(StaleLayerKeys(d.Effective()), ",")
+	if strings.Contains(stale, urlfilter.KeyBlocklist) || !strings.Contains(stale, urlfilter.KeyAllowlist) {
+		t.Errorf("StaleLayerKeys() with a block list = %s", stale)
+	}
+	// Clearing the lists leaves nothing to write, so the block list key must be deleted.
+	if err := st.WriteURLFilters(urlfilter.Lists{}); err != nil {
+		t.Fatal(err)
+	}
+	if d, err = st.Read(); err != nil || d != nil {
+		t.Fatalf("Read() after clearing = %+v, %v", d, err)
+	}
+	if got, want := len(StaleLayerKeys(nil)), len(LayerKeys()); got != want {
+		t.Errorf("StaleLayerKeys(nil) has %d keys, want all %d layer keys", got, want)
+	}
+	preset := []brave.Setting{{Key: doh.KeyMode, Value: "off", Type: brave.TypeString}}
+	for _, k := range StaleLayerKeys(preset) {
+		if k == doh.KeyMode {
+			t.Errorf("StaleLayerKeys() includes %s, which the settings set", k)
+		}
+	}
+}
+
+func TestReadRejectsInvalidLayers(t *testing.T) {
+	for _, tc := range []struct{ layer, want string }{
+		{"dns:\n  mode: secure\n  resolver: no-such-resolver\n", "dns:"},
+		{"proxy:\n  mode: direct\n  server: proxy.example:8080\n", "proxy:"},
+		{"content:\n  cookies:\n    default: sometimes\n", "content:"},
+	} {
+		st := writeConfig(t, tc.layer)
+		if _, err := st.Read(); err == nil || !strings.HasPrefix(err.Error(), tc.want) {
+			t.Errorf("Read() with %q: err = %v, want %s ...", tc.layer, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package userconfig
+
+import (
+	"fmt"
+
+	"github.com/cowardly/cowardly/internal/brave"
+)
+
+// retentionFile is the backups section of cowardly.yaml, shared by every channel. Unset fields use
+// brave.DefaultRetention; 0 turns a rule off.
+type retentionFile struct {
+	KeepLast   *int `yaml:"keep_last,omitempty"`
+	KeepDaily  *int `yaml:"keep_daily,omitempty"`
+	KeepWeekly *int `yaml:"keep_weekly,omitempty"`
+}
+
+// Retention returns the backup retention policy from cowardly.yaml, or brave.DefaultRetention if the
+// file has no backups section.
+func (st Store) Retention() (brave.Retention, error) {
+	cf, _, err := st.loadFile()
+	if err != nil {
+		return brave.Retention{}, err
+	}
+	r := brave.DefaultRetention
+	if cf.Backups == nil {
+		return r, nil
+	}
+	for _, f := range []struct {
+		name string
+		src  *int
+		dst  *int
+	}{
+		{"keep_last", cf.Backups.KeepLast, &r.KeepLast},
+		{"keep_daily", cf.Backups.KeepDaily, &r.KeepDaily},
+		{"keep_weekly", cf.Backups.KeepWeekly, &r.KeepWeekly},
+	} {
+		if f.src == nil {
+			continue
+		}
+		if *f.src < 0 {
+			return brave.Retention{}, fmt.Errorf("backups.%s: %d is negative", f.name, *f.src)
+		}
+		*f.dst = *f.src
+	}
+	return r, nil
+}
@@ -0,0 +1,36 @@
+package userconfig
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/cowardly/cowardly/internal/brave"
+)
+
+func TestRetention(t *testing.T) {
+	st := writeConfig(t, "")
+	if r, err := st.Retention(); err != nil || r != brave.DefaultRetention {
+		t.Fatalf("Retention() without a file = %+v, %v; want the default", r, err)
+	}
+
+	st = writeConfig(t, "version: 6\nbackups:\n  keep_last: 3\n  keep_weekly: 0\nchannels: {}\n")
+	want := brave.Retention{KeepLast: 3, KeepDaily: brave.DefaultRetention.KeepDaily}
+	if r, err := st.Retention(); err != nil || r != want {
+		t.Fatalf("Retention() = %+v, %v; want %+v", r, err, want)
+	}
+	// Writes keep the section.
+	if err := st.WritePreset("quick", []brave.Setting{{Key: "TorDisabled", Value: true, Type: brave.TypeBool}}); err != nil {
+		t.Fatal(err)
+	}
+	if r, err := st.Retention(); err != nil || r != want {
+		t.Errorf("Retention() after a write = %+v, %v; want %+v", r, err, want)
+	}
+
+	if err := os.WriteFile(st.Path(), []byte("version: 6\nbackups:\n  keep_daily: -1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.Retention(); err == nil || !strings.Contains(err.Error(), "backups.keep_daily") {
+		t.Errorf("Retention() with a negative value = %v, want error", err)
+	}
+}
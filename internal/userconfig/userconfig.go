@@ -1,20 +1,66 @@
-// Package userconfig manages the user's desired Brave settings in ~/.config/cowardly/cowardly.yaml.
+// Package userconfig manages the user's desired Brave settings in cowardly.yaml (by default
+// ~/.config/cowardly/cowardly.yaml; see the paths package).
 // Used for --reapply and to detect when settings have been reverted (e.g. by MDM after restart).
+// The file holds named profiles per Brave channel (see profiles.go); everything here reads and writes
+// the active profile of the current channel.
 package userconfig
 
 import (
-	"bytes"
 	"fmt"
-	"os"
-	"path/filepath"
+	"sort"
 
+	"github.com/cowardly/cowardly/internal/bookmarks"
 	"github.com/cowardly/cowardly/internal/brave"
+	"github.com/cowardly/cowardly/internal/content"
+	"github.com/cowardly/cowardly/internal/doh"
+	"github.com/cowardly/cowardly/internal/extensions"
+	"github.com/cowardly/cowardly/internal/lock"
+	"github.com/cowardly/cowardly/internal/paths"
 	"github.com/cowardly/cowardly/internal/presets"
-	"gopkg.in/yaml.v3"
+	"github.com/cowardly/cowardly/internal/proxy"
+	"github.com/cowardly/cowardly/internal/urlfilter"
 )
 
 // ConfigFileName is the name of the config file in the config directory.
-const ConfigFileName = "cowardly.yaml"
+const ConfigFileName = paths.ConfigFileName
+
+// Store reads and writes one config file (paths.Paths.Config). Writes hold the cowardly lock in the
+// state dir (paths.Paths.State): the one given to Holding, or one taken for the write.
+type Store struct {
+	paths paths.Paths
+	held  *lock.Lock
+}
+
+// New returns the store for the config file and state dir of p.
+func New(p paths.Paths) Store {
+	return Store{paths: p}
+}
+
+// Holding returns a copy of the store whose writes run under l, a cowardly lock the caller holds,
+// instead of taking their own (which would wait for l).
+func (st Store) Holding(l *lock.Lock) Store {
+	st.held = l
+	return st
+}
+
+// lock makes sure a write holds the cowardly lock and returns the func that ends the write: a no-op for
+// the lock given to Holding (released by its owner), Release for a newly acquired one.
+func (st Store) lock() (release func(), err error) {
+	if st.held != nil {
+		if err := st.held.Check(); err != nil {
+			return nil, err
+		}
+		if st.held.Dir() != st.paths.State {
+			return nil, fmt.Errorf("cowardly lock is in %s, not %s", st.held.Dir(), st.paths.State)
+		}
+		return func() {}, nil
+	}
+	l, err := lock.Acquire(st.paths.State)
+	if err != nil {
+		return nil, err
+	}
+	return l.Release, nil
+}
 
 // settingRow matches the on-disk YAML shape for one setting (same as presets).
 type settingRow struct {
@@ -28,15 +74,23 @@ type block struct {
 	Settings []settingRow `yaml:"settings"`
 }
 
-// fileShapeNew is the new on-disk shape: preset.<id>.settings, supplement.<id>.settings.
+// fileShapeNew is the on-disk shape of one profile: preset.<id>.settings, supplement.<id>.settings.
 type fileShapeNew struct {
-	Preset     map[string]block `yaml:"preset,omitempty"`
-	Supplement map[string]block `yaml:"supplement,omitempty"`
-	ApplyFile  string           `yaml:"apply_file,omitempty"`
-	Settings   []settingRow     `yaml:"settings,omitempty"` // for apply_file, legacy
+	Preset     map[string]block   `yaml:"preset,omitempty"`
+	Supplement map[string]block   `yaml:"supplement,omitempty"`
+	ApplyFile  string             `yaml:"apply_file,omitempty"`
+	Settings   []settingRow       `yaml:"settings,omitempty"` // for apply_file, legacy
+	Extensions *extensions.Policy `yaml:"extensions,omitempty"`
+	URLFilters *urlfilter.Lists   `yaml:"url_filters,omitempty"`
+	Bookmarks  *bookmarks.Tree    `yaml:"managed_bookmarks,omitempty"`
+	DNS        *doh.Selection     `yaml:"dns,omitempty"`
+	Proxy      *proxy.Config      `yaml:"proxy,omitempty"`
+	Content    content.Config     `yaml:"content,omitempty"`
+	Overrides  []settingRow       `yaml:"overrides,omitempty"` // per-key overrides, applied last
+	Target     string             `yaml:"target,omitempty"`    // where the last apply wrote: managed or user
 }
 
-// fileShapeLegacy supports the old format for backward compat.
+// fileShapeLegacy is the version 0 shape (preset: <id>), read only to migrate it (see migrations.go).
 type fileShapeLegacy struct {
 	Preset     string       `yaml:"preset,omitempty"`
 	BasePreset string       `yaml:"base_preset,omitempty"`
@@ -52,61 +106,163 @@ type DesiredState struct {
 	Supplement []brave.Setting // for preset=privacy-guides: supplement settings
 	ApplyFile  string          // path to file, if last apply was from file
 	Settings   []brave.Setting // snapshot of settings (used by reapply)
+	Extensions extensions.Policy
+	URLFilters urlfilter.Lists
+	Bookmarks  bookmarks.Tree
+	DNS        doh.Selection
+	Proxy      proxy.Config
+	Content    content.Config
+	Overrides  []brave.Setting // per-key overrides (cowardly --set), applied on top of everything else
+	Target     brave.Target    // where the last apply wrote (managed or user); "" if unknown
 }
 
-// ConfigDir returns ~/.config/cowardly. Creates the directory if it does not exist.
-func ConfigDir() (string, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("home dir: %w", err)
+// Effective returns Settings with the saved layers (extensions, URL filters, managed bookmarks, DNS, proxy, content settings, overrides) applied on top.
+// This is what apply, reapply and revert detection should use.
+func (d *DesiredState) Effective() []brave.Setting {
+	return mergeSettings(d.Settings, d.layers())
+}
+
+// layers returns the settings of all saved layers, in apply order. Overrides come last so they win.
+func (d *DesiredState) layers() []brave.Setting {
+	var out []brave.Setting
+	out = append(out, d.Extensions.Settings()...)
+	out = append(out, d.URLFilters.Settings()...)
+	out = append(out, d.Bookmarks.Settings()...)
+	out = append(out, d.DNS.Settings()...)
+	out = append(out, d.Proxy.Settings()...)
+	out = append(out, d.Content.Settings()...)
+	out = append(out, d.Overrides...)
+	return out
+}
+
+// LayerKeys returns the policy keys the layers (extensions, URL filters, managed bookmarks, DNS, proxy,
+// content settings) can write.
+func LayerKeys() []string {
+	var keys []string
+	keys = append(keys, extensions.Keys...)
+	keys = append(keys, urlfilter.Keys...)
+	keys = append(keys, bookmarks.Key)
+	keys = append(keys, doh.Keys...)
+	keys = append(keys, proxy.Keys...)
+	keys = append(keys, content.Keys()...)
+	return keys
+}
+
+// StaleLayerKeys returns the LayerKeys that settings do not set. An emptied layer writes nothing, so
+// applying the desired state must delete these keys from user prefs or their old values stay in effect.
+func StaleLayerKeys(settings []brave.Setting) []string {
+	set := make(map[string]bool, len(settings))
+	for _, s := range settings {
+		set[s.Key] = true
 	}
-	dir := filepath.Join(home, ".config", "cowardly")
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return "", fmt.Errorf("create config dir: %w", err)
+	var stale []string
+	for _, k := range LayerKeys() {
+		if !set[k] {
+			stale = append(stale, k)
+		}
 	}
-	return dir, nil
+	return stale
 }
 
-// ConfigPath returns the full path to ~/.config/cowardly/cowardly.yaml.
-func ConfigPath() (string, error) {
-	dir, err := ConfigDir()
-	if err != nil {
-		return "", err
+// hasLayers returns true if f has any layer section.
+func (f *fileShapeNew) hasLayers() bool {
+	return f.Extensions != nil || f.URLFilters != nil || f.Bookmarks != nil || f.DNS != nil || f.Proxy != nil || len(f.Content) > 0 || len(f.Overrides) > 0
+}
+
+// readLayers copies the layer sections of f into d. A layer whose Settings would silently drop an
+// invalid section (DNS, proxy, content settings) is validated here so the error reaches the user.
+func readLayers(d *DesiredState, f *fileShapeNew) error {
+	if f.Extensions != nil {
+		d.Extensions = *f.Extensions
 	}
-	return filepath.Join(dir, ConfigFileName), nil
+	if f.URLFilters != nil {
+		d.URLFilters = *f.URLFilters
+	}
+	if f.Bookmarks != nil {
+		d.Bookmarks = *f.Bookmarks
+	}
+	if f.DNS != nil {
+		if err := f.DNS.Validate(); err != nil {
+			return fmt.Errorf("dns: %w", err)
+		}
+		d.DNS = *f.DNS
+	}
+	if f.Proxy != nil {
+		if err := f.Proxy.Validate(); err != nil {
+			return fmt.Errorf("proxy: %w", err)
+		}
+		d.Proxy = *f.Proxy
+	}
+	if err := f.Content.Validate(); err != nil {
+		return fmt.Errorf("content: %w", err)
+	}
+	d.Content = f.Content
+	if len(f.Overrides) > 0 {
+		o, err := rowsToSettings(f.Overrides)
+		if err != nil {
+			return fmt.Errorf("overrides: %w", err)
+		}
+		d.Overrides = o
+	}
+	return nil
+}
+
+// Dir returns the directory of the config file. It is not created; save creates it.
+func (st Store) Dir() string {
+	return st.paths.ConfigDir()
 }
 
-// Read loads the desired state from ~/.config/cowardly/cowardly.yaml.
-// Returns (nil, nil) if the file does not exist or is empty.
-func Read() (*DesiredState, error) {
-	path, err := ConfigPath()
+// Path returns the path of the config file.
+func (st Store) Path() string {
+	return st.paths.Config
+}
+
+// Read loads the desired state of the active profile from the config file.
+// Returns (nil, nil) if the file does not exist or the profile is empty.
+func (st Store) Read() (*DesiredState, error) {
+	f, err := st.readShape()
 	if err != nil {
 		return nil, err
 	}
-	data, err := os.ReadFile(path)
+	return f.desired()
+}
+
+// desired decodes one profile into a DesiredState. Returns (nil, nil) if f is empty.
+func (f *fileShapeNew) desired() (*DesiredState, error) {
+	if len(f.Preset) == 0 && f.ApplyFile == "" && len(f.Settings) == 0 && !f.hasLayers() {
+		return nil, nil
+	}
+	desired, err := readNewFormat(f)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil
-		}
-		return nil, fmt.Errorf("read config: %w", err)
+		return nil, err
 	}
-	// Try new format first (preset.<id>.settings, supplement.<id>.settings)
-	var fNew fileShapeNew
-	if err := yaml.Unmarshal(data, &fNew); err == nil && (len(fNew.Preset) > 0 || fNew.ApplyFile != "" || len(fNew.Settings) > 0) {
-		return readNewFormat(&fNew)
+	if f.hasLayers() {
+		if desired == nil {
+			desired = &DesiredState{}
+		}
+		if err := readLayers(desired, f); err != nil {
+			return nil, err
+		}
 	}
-	// Fall back to legacy format
-	var fLegacy fileShapeLegacy
-	if err := yaml.Unmarshal(data, &fLegacy); err != nil {
-		return nil, fmt.Errorf("parse config: %w", err)
+	if desired != nil && f.Target != "" {
+		if f.Target != string(brave.TargetManaged) && f.Target != string(brave.TargetUser) {
+			return nil, fmt.Errorf("target %q: use managed or user", f.Target)
+		}
+		desired.Target = brave.Target(f.Target)
 	}
-	return readLegacyFormat(&fLegacy)
+	return desired, nil
 }
 
 func readNewFormat(f *fileShapeNew) (*DesiredState, error) {
-	// preset + supplement (Privacy Guides)
+	// preset + supplement (Privacy Guides). Decoding rejects more than one preset; sort anyway so the choice is stable.
 	if f.Preset != nil {
-		for presetID, pblock := range f.Preset {
+		ids := make([]string, 0, len(f.Preset))
+		for id := range f.Preset {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, presetID := range ids {
+			pblock := f.Preset[presetID]
 			if len(pblock.Settings) == 0 {
 				continue
 			}
@@ -226,8 +382,8 @@ func supplementToSettings(rows []settingRow) []brave.Setting {
 }
 
 // WritePreset writes the given preset id and settings snapshot to the config file.
-func WritePreset(presetID string, settings []brave.Setting) error {
-	return write(&fileShapeNew{
+func (st Store) WritePreset(presetID string, settings []brave.Setting) error {
+	return st.write(&fileShapeNew{
 		Preset: map[string]block{
 			presetID: {Settings: settingsToRows(settings)},
 		},
@@ -235,16 +391,16 @@ func WritePreset(presetID string, settings []brave.Setting) error {
 }
 
 // WriteApplyFile writes the given apply-file path and settings snapshot to the config file.
-func WriteApplyFile(applyFilePath string, settings []brave.Setting) error {
-	return write(&fileShapeNew{
+func (st Store) WriteApplyFile(applyFilePath string, settings []brave.Setting) error {
+	return st.write(&fileShapeNew{
 		ApplyFile: applyFilePath,
 		Settings:  settingsToRows(settings),
 	})
 }
 
 // WriteSettings writes preset.custom.settings (e.g. after Custom apply in TUI).
-func WriteSettings(settings []brave.Setting) error {
-	return write(&fileShapeNew{
+func (st Store) WriteSettings(settings []brave.Setting) error {
+	return st.write(&fileShapeNew{
 		Preset: map[string]block{
 			"custom": {Settings: settingsToRows(settings)},
 		},
@@ -252,10 +408,10 @@ func WriteSettings(settings []brave.Setting) error {
 }
 
 // WritePrivacyGuides writes preset.<baseID>.settings and supplement.privacy_guides.settings.
-func WritePrivacyGuides(basePresetID string) error {
+func (st Store) WritePrivacyGuides(basePresetID string) error {
 	var baseSettings []brave.Setting
 	if basePresetID == "custom" {
-		desired, err := Read()
+		desired, err := st.Read()
 		if err != nil || desired == nil || len(desired.Settings) == 0 {
 			return fmt.Errorf("no custom settings in config to use as base")
 		}
@@ -279,14 +435,14 @@ func WritePrivacyGuides(basePresetID string) error {
 			"privacy_guides": {Settings: settingsToRows(supplement)},
 		},
 	}
-	return write(f)
+	return st.write(f)
 }
 
 // PrivacyGuidesBaseFromConfig returns the preset ID to use as base when applying Privacy Guides.
 // Uses existing config: preset (if it's a known preset ID), base_preset (for privacy-guides), or "custom".
 // Returns "" if config is empty or has no usable base (apply_file).
-func PrivacyGuidesBaseFromConfig() (string, error) {
-	desired, err := Read()
+func (st Store) PrivacyGuidesBaseFromConfig() (string, error) {
+	desired, err := st.Read()
 	if err != nil || desired == nil {
 		return "", err
 	}
@@ -304,21 +460,187 @@ func PrivacyGuidesBaseFromConfig() (string, error) {
 	return "", nil
 }
 
-func write(f *fileShapeNew) error {
-	path, err := ConfigPath()
-	if err != nil {
-		return err
-	}
-	var buf bytes.Buffer
-	enc := yaml.NewEncoder(&buf)
-	enc.SetIndent(2)
-	if err := enc.Encode(f); err != nil {
-		return fmt.Errorf("marshal config: %w", err)
+// WriteExtensions saves the extension policy, keeping the rest of the desired state.
+func (st Store) WriteExtensions(p extensions.Policy) error {
+	return st.UpdateExtensions(func(cur *extensions.Policy) error {
+		*cur = p
+		return nil
+	})
+}
+
+// UpdateExtensions applies fn to the saved extension policy and saves the result, keeping the rest of
+// the desired state. The read and the save are one update (see update); if fn fails nothing is saved.
+func (st Store) UpdateExtensions(fn func(p *extensions.Policy) error) error {
+	return st.update(func(_ *configFile, pf *profilesFile) error {
+		f := pf.active()
+		var p extensions.Policy
+		if f.Extensions != nil {
+			p = *f.Extensions
+		}
+		if err := fn(&p); err != nil {
+			return err
+		}
+		f.Extensions = nil
+		if !p.IsEmpty() {
+			f.Extensions = &p
+		}
+		return nil
+	})
+}
+
+// WriteURLFilters saves the URL block/allow lists, keeping the rest of the desired state.
+func (st Store) WriteURLFilters(l urlfilter.Lists) error {
+	return st.UpdateURLFilters(func(cur *urlfilter.Lists) error {
+		*cur = l
+		return nil
+	})
+}
+
+// UpdateURLFilters applies fn to the saved URL block/allow lists and saves the result, keeping the rest
+// of the desired state. The read and the save are one update (see update); if fn fails nothing is saved.
+func (st Store) UpdateURLFilters(fn func(l *urlfilter.Lists) error) error {
+	return st.update(func(_ *configFile, pf *profilesFile) error {
+		f := pf.active()
+		var l urlfilter.Lists
+		if f.URLFilters != nil {
+			l = *f.URLFilters
+		}
+		if err := fn(&l); err != nil {
+			return err
+		}
+		f.URLFilters = nil
+		if !l.IsEmpty() {
+			f.URLFilters = &l
+		}
+		return nil
+	})
+}
+
+// WriteBookmarks saves the managed bookmarks tree, keeping the rest of the desired state.
+func (st Store) WriteBookmarks(t bookmarks.Tree) error {
+	return st.updateActive(func(f *fileShapeNew) {
+		if t.IsEmpty() {
+			f.Bookmarks = nil
+		} else {
+			f.Bookmarks = &t
+		}
+	})
+}
+
+// WriteDNS saves the DNS-over-HTTPS selection, keeping the rest of the desired state.
+// An empty selection stops managing DoH.
+func (st Store) WriteDNS(sel doh.Selection) error {
+	return st.updateActive(func(f *fileShapeNew) {
+		if sel.IsEmpty() {
+			f.DNS = nil
+		} else {
+			f.DNS = &sel
+		}
+	})
+}
+
+// WriteProxy saves the proxy configuration, keeping the rest of the desired state.
+// An empty config stops managing the proxy.
+func (st Store) WriteProxy(c proxy.Config) error {
+	return st.updateActive(func(f *fileShapeNew) {
+		if c.IsEmpty() {
+			f.Proxy = nil
+		} else {
+			f.Proxy = &c
+		}
+	})
+}
+
+// WriteContent saves the per-site content settings (dropping empty types), keeping the rest of the desired state.
+func (st Store) WriteContent(c content.Config) error {
+	return st.UpdateContent(func(cur content.Config) error {
+		for name := range cur {
+			delete(cur, name)
+		}
+		for name, r := range c {
+			cur[name] = r
+		}
+		return nil
+	})
+}
+
+// UpdateContent applies fn to a copy of the saved content settings and saves the result (dropping empty
+// types), keeping the rest of the desired state. The read and the save are one update (see update); if
+// fn fails nothing is saved.
+func (st Store) UpdateContent(fn func(c content.Config) error) error {
+	return st.update(func(_ *configFile, pf *profilesFile) error {
+		f := pf.active()
+		c := make(content.Config, len(f.Content))
+		for name, r := range f.Content {
+			c[name] = r
+		}
+		if err := fn(c); err != nil {
+			return err
+		}
+		f.Content = nil
+		for name, r := range c {
+			if r.IsEmpty() {
+				continue
+			}
+			if f.Content == nil {
+				f.Content = make(content.Config)
+			}
+			f.Content[name] = r
+		}
+		return nil
+	})
+}
+
+// WithLayers returns settings with the saved layers (extensions, URL filters, managed bookmarks, DNS, proxy, content settings, overrides) from the config file applied on top.
+// Call it before applying a preset, file or Custom selection so those layers are not dropped.
+func (st Store) WithLayers(settings []brave.Setting) []brave.Setting {
+	desired, err := st.Read()
+	if err != nil || desired == nil {
+		return settings
 	}
-	if err := enc.Close(); err != nil {
-		return fmt.Errorf("close encoder: %w", err)
+	return mergeSettings(settings, desired.layers())
+}
+
+// readShape returns the active profile of the current channel, or an empty profile if the config file does not exist.
+func (st Store) readShape() (*fileShapeNew, error) {
+	_, pf, err := st.loadFile()
+	if err != nil {
+		return nil, err
 	}
-	return os.WriteFile(path, buf.Bytes(), 0600)
+	return pf.active(), nil
+}
+
+// write saves f as the new desired state. Layers (extensions, URL filters, managed bookmarks, DNS, proxy, content settings, overrides) from the existing file are kept.
+func (st Store) write(f *fileShapeNew) error {
+	return st.update(func(_ *configFile, pf *profilesFile) error {
+		existing := pf.active()
+		f.Extensions = existing.Extensions
+		f.URLFilters = existing.URLFilters
+		f.Bookmarks = existing.Bookmarks
+		f.DNS = existing.DNS
+		f.Proxy = existing.Proxy
+		f.Content = existing.Content
+		f.Overrides = existing.Overrides
+		f.Target = existing.Target
+		pf.Profiles[pf.Active] = f
+		return nil
+	})
+}
+
+// WriteTarget records where the last apply of the active profile wrote (managed is the result of
+// brave.ApplySettings). --reapply, the TUI and watch use it to re-apply and compare in the same scope.
+func (st Store) WriteTarget(managed bool) error {
+	return st.updateActive(func(f *fileShapeNew) {
+		f.Target = string(brave.AchievedTarget(managed))
+	})
+}
+
+// updateActive changes the active profile of the current channel in place and saves it (see update).
+func (st Store) updateActive(fn func(f *fileShapeNew)) error {
+	return st.update(func(_ *configFile, pf *profilesFile) error {
+		fn(pf.active())
+		return nil
+	})
 }
 
 func settingsToRows(settings []brave.Setting) []settingRow {
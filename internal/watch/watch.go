@@ -0,0 +1,352 @@
+// Package watch re-applies the desired state when the user or managed plist changes mid-session
+// (e.g. MDM or Brave reverting settings). Changes to the plists come from file events on their directories
+// (fsnotify), with polling (modification time and size) for a plist whose directory cannot be watched.
+// Changes are debounced, and only the drifted keys are written back.
+package watch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cowardly/cowardly/internal/brave"
+	"github.com/cowardly/cowardly/internal/history"
+	"github.com/cowardly/cowardly/internal/lock"
+	"github.com/cowardly/cowardly/internal/paths"
+	"github.com/cowardly/cowardly/internal/presets"
+	"github.com/cowardly/cowardly/internal/userconfig"
+	"github.com/fsnotify/fsnotify"
+)
+
+// Defaults for Options.
+const (
+	DefaultInterval = 2 * time.Second
+	DefaultDebounce = 3 * time.Second
+)
+
+// Options configures Run.
+type Options struct {
+	Interval time.Duration // how often a plist without file events is polled for changes
+	Debounce time.Duration // how long changes must settle before drift is checked
+	Managed  bool          // rewrite the managed plist for keys it enforces (asks for admin privileges)
+	Paths    paths.Paths   // the config file, and the state dir for the lock and the journal
+	Logf     func(format string, args ...interface{})
+}
+
+func (o *Options) defaults() {
+	if o.Interval <= 0 {
+		o.Interval = DefaultInterval
+	}
+	if o.Debounce <= 0 {
+		o.Debounce = DefaultDebounce
+	}
+	if o.Logf == nil {
+		o.Logf = func(string, ...interface{}) {}
+	}
+}
+
+// Paths returns the plists that are watched: the user plist and the managed plist.
+func Paths() ([]string, error) {
+	user, err := brave.UserPreferencesPath()
+	if err != nil {
+		return nil, err
+	}
+	return []string{user, brave.ManagedPreferencesPath() + ".plist"}, nil
+}
+
+// stamp identifies one version of a file; the zero stamp means it does not exist.
+type stamp struct {
+	mod  time.Time
+	size int64
+}
+
+func stamps(plists []string) map[string]stamp {
+	out := make(map[string]stamp, len(plists))
+	for _, p := range plists {
+		if info, err := os.Stat(p); err == nil {
+			out[p] = stamp{mod: info.ModTime(), size: info.Size()}
+		}
+	}
+	return out
+}
+
+// changed returns the paths whose stamp differs between a and b.
+func changed(plists []string, a, b map[string]stamp) []string {
+	var out []string
+	for _, p := range plists {
+		if a[p] != b[p] {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// Run checks for drift once, then watches the plists until ctx is done.
+func Run(ctx context.Context, opts Options) error {
+	opts.defaults()
+	plists, err := Paths()
+	if err != nil {
+		return err
+	}
+	if _, err := Check(opts, nil); err != nil {
+		opts.Logf("error: %v", err)
+	}
+	loop(ctx, opts, plists, func(changed []string) {
+		if _, err := Check(opts, changed); err != nil {
+			opts.Logf("error: %v", err)
+		}
+	}, loopHooks{})
+	opts.Logf("stopped")
+	return nil
+}
+
+// newWatcher returns a file event watcher on the directories of paths (the plists are replaced by a
+// rename, so watching the files themselves would lose them) and the paths it covers. Paths whose
+// directory cannot be watched, e.g. because it does not exist yet, are left out; with no watcher at all
+// it returns nil.
+func newWatcher(plists []string, logf func(string, ...interface{})) (*fsnotify.Watcher, map[string]bool) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		logf("file events unavailable, polling: %v", err)
+		return nil, nil
+	}
+	watched := map[string]bool{}
+	dirs := map[string]bool{}
+	for _, p := range plists {
+		dir := filepath.Dir(p)
+		if !dirs[dir] {
+			if err := w.Add(dir); err != nil {
+				continue
+			}
+			dirs[dir] = true
+		}
+		watched[p] = true
+	}
+	if len(watched) == 0 {
+		_ = w.Close()
+		return nil, nil
+	}
+	return w, watched
+}
+
+// loopHooks are called by loop at points tests synchronise on; nil hooks are skipped.
+type loopHooks struct {
+	ready   func() // the watcher is set up and the plists are stamped
+	checked func() // check returned and the plists are re-stamped
+}
+
+// loop calls check with the plists that changed, once changes have settled for opts.Debounce. Changes
+// come from file events; plists whose directory cannot be watched are polled every opts.Interval.
+// A change is a new stamp, so events from check's own writes (and events that change nothing) are ignored.
+func loop(ctx context.Context, opts Options, plists []string, check func(changed []string), hooks loopHooks) {
+	w, watched := newWatcher(plists, opts.Logf)
+	var events <-chan fsnotify.Event
+	var errs <-chan error
+	if w != nil {
+		defer w.Close()
+		events, errs = w.Events, w.Errors
+	}
+	var evented, polled []string
+	for _, p := range plists {
+		if watched[p] {
+			evented = append(evented, p)
+		} else {
+			polled = append(polled, p)
+		}
+	}
+	if len(evented) > 0 {
+		opts.Logf("watching %s (file events, debounce %s)", strings.Join(evented, ", "), opts.Debounce)
+	}
+	if len(polled) > 0 {
+		opts.Logf("watching %s (poll %s, debounce %s)", strings.Join(polled, ", "), opts.Interval, opts.Debounce)
+	}
+
+	prev := stamps(plists)
+	pending := map[string]bool{}
+	var last time.Time
+	note := func(ch []string, now time.Time) {
+		if len(ch) == 0 {
+			return
+		}
+		cur := stamps(plists)
+		for _, p := range ch {
+			pending[p] = true
+			prev[p] = cur[p]
+		}
+		last = now
+	}
+	// The ticker polls and ends the debounce; with file events only, it runs at the debounce resolution.
+	tick := opts.Interval
+	if len(polled) == 0 && opts.Debounce < tick {
+		tick = opts.Debounce
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	if hooks.ready != nil {
+		hooks.ready()
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-events:
+			p := filepath.Clean(ev.Name)
+			if watched[p] {
+				note(changed([]string{p}, prev, stamps([]string{p})), time.Now())
+			}
+		case err := <-errs:
+			opts.Logf("file events: %v", err)
+		case now := <-ticker.C:
+			if ch := changed(polled, prev, stamps(polled)); len(ch) > 0 {
+				note(ch, now)
+				continue
+			}
+			if len(pending) == 0 || now.Sub(last) < opts.Debounce {
+				continue
+			}
+			var ch []string
+			for p := range pending {
+				ch = append(ch, p)
+			}
+			sort.Strings(ch)
+			pending = map[string]bool{}
+			check(ch)
+			// Our own writes change the plists; do not treat them as a new event.
+			prev = stamps(plists)
+			if hooks.checked != nil {
+				hooks.checked()
+			}
+		}
+	}
+}
+
+// Event is the result of one drift check.
+type Event struct {
+	Changed []string        // plist paths that changed (nil for the initial check)
+	Drifted []brave.Setting // desired settings whose current value differs
+	User    []string        // keys re-applied to user preferences
+	Managed []string        // keys re-applied to the managed plist
+	Skipped []string        // keys enforced in the managed plist that were not rewritten (Options.Managed is false)
+}
+
+// Check compares the active profile's desired state with Brave, in the scope it was last applied to
+// (userconfig.DesiredState.Target), re-applies the drifted keys and logs the event.
+func Check(opts Options, changedPaths []string) (Event, error) {
+	opts.defaults()
+	ev := Event{Changed: changedPaths}
+	desired, err := userconfig.New(opts.Paths).Read()
+	if err != nil {
+		return ev, err
+	}
+	if desired == nil {
+		return ev, nil
+	}
+	settings := desired.Effective()
+	ev.Drifted = brave.DriftedIn(settings, desired.Target)
+	if len(ev.Drifted) == 0 {
+		return ev, nil
+	}
+	what := "drift"
+	if len(changedPaths) > 0 {
+		what = "drift after change in " + strings.Join(changedPaths, ", ")
+	}
+	opts.Logf("%s: %d key(s)\n%s", what, len(ev.Drifted), brave.DiffIn(ev.Drifted, desired.Target, presets.ValueNames()))
+
+	var enforced map[string]brave.Setting
+	if brave.ManagedPlistExists() {
+		enforced, err = brave.ReadPlistFile(brave.ManagedPreferencesPath() + ".plist")
+		if err != nil {
+			return ev, err
+		}
+	}
+	user, managed, skipped := plan(ev.Drifted, enforced, opts.Managed, desired.Target == brave.TargetManaged)
+	ev.Skipped = skipped
+	if len(ev.Skipped) > 0 {
+		opts.Logf("not re-applied (enforced in the managed plist; run watch --managed to rewrite it): %s", strings.Join(ev.Skipped, ", "))
+	}
+	if len(user) == 0 && managed == nil {
+		return ev, nil
+	}
+	// Another cowardly (e.g. the TUI) may be applying; its writes trigger a new check once it is done.
+	l, err := lock.Acquire(opts.Paths.State)
+	if err != nil {
+		return ev, err
+	}
+	defer l.Release()
+	before := history.Take()
+	defer func() {
+		if err := history.Record(opts.Paths, history.ActionReapply, "watch", before); err != nil {
+			opts.Logf("history: %v", err)
+		}
+	}()
+	for _, s := range user {
+		if err := brave.Write(s); err != nil {
+			return ev, fmt.Errorf("%s: %w", s.Key, err)
+		}
+		ev.User = append(ev.User, s.Key)
+	}
+	if managed != nil {
+		if err := brave.WriteAllToManaged(managed); err != nil {
+			return ev, fmt.Errorf("managed plist: %w", err)
+		}
+		done := make(map[string]bool, len(user)+len(skipped))
+		for _, s := range user {
+			done[s.Key] = true
+		}
+		for _, k := range skipped {
+			done[k] = true
+		}
+		for _, s := range ev.Drifted {
+			if !done[s.Key] {
+				ev.Managed = append(ev.Managed, s.Key)
+			}
+		}
+	}
+	if len(ev.User) > 0 {
+		opts.Logf("re-applied to user prefs: %s", strings.Join(ev.User, ", "))
+	}
+	if len(ev.Managed) > 0 {
+		opts.Logf("re-applied to managed plist: %s", strings.Join(ev.Managed, ", "))
+	}
+	return ev, nil
+}
+
+// plan splits drifted settings into user writes and, for keys set in the managed plist (enforced),
+// the full new content of the managed plist. Without allowManaged those keys are skipped. When
+// targetManaged (the state was last applied to the managed plist) and allowManaged, keys missing from
+// the managed plist go back into it rather than into user prefs.
+func plan(drifted []brave.Setting, enforced map[string]brave.Setting, allowManaged, targetManaged bool) (user, managed []brave.Setting, skipped []string) {
+	next := make(map[string]brave.Setting, len(enforced))
+	for k, s := range enforced {
+		next[k] = s
+	}
+	rewrite := false
+	for _, s := range drifted {
+		if _, ok := enforced[s.Key]; !ok && !(targetManaged && allowManaged) {
+			user = append(user, s)
+			continue
+		}
+		if !allowManaged {
+			skipped = append(skipped, s.Key)
+			continue
+		}
+		next[s.Key] = s
+		rewrite = true
+	}
+	if !rewrite {
+		return user, nil, skipped
+	}
+	keys := make([]string, 0, len(next))
+	for k := range next {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		managed = append(managed, next[k])
+	}
+	return user, managed, skipped
+}
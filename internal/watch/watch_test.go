@@ -0,0 +1,107 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cowardly/cowardly/internal/brave"
+)
+
+func TestPlan(t *testing.T) {
+	rewards := brave.Setting{Key: "BraveRewardsDisabled", Value: true, Type: brave.TypeBool}
+	tor := brave.Setting{Key: "TorDisabled", Value: true, Type: brave.TypeBool}
+	enforced := map[string]brave.Setting{
+		"TorDisabled":  {Key: "TorDisabled", Value: false, Type: brave.TypeBool},
+		"SyncDisabled": {Key: "SyncDisabled", Value: true, Type: brave.TypeBool},
+	}
+
+	user, managed, skipped := plan([]brave.Setting{rewards, tor}, enforced, false, false)
+	if len(user) != 1 || user[0].Key != rewards.Key || managed != nil || !reflect.DeepEqual(skipped, []string{"TorDisabled"}) {
+		t.Errorf("plan(no managed) = %v, %v, %v", user, managed, skipped)
+	}
+
+	user, managed, skipped = plan([]brave.Setting{rewards, tor}, enforced, true, false)
+	if len(user) != 1 || skipped != nil {
+		t.Errorf("plan(managed) user = %v, skipped = %v", user, skipped)
+	}
+	want := []brave.Setting{enforced["SyncDisabled"], tor}
+	if !reflect.DeepEqual(managed, want) {
+		t.Errorf("plan(managed) managed = %v, want %v (other enforced keys kept)", managed, want)
+	}
+
+	user, managed, _ = plan([]brave.Setting{rewards}, nil, true, false)
+	if len(user) != 1 || managed != nil {
+		t.Errorf("plan(no managed plist) = %v, %v", user, managed)
+	}
+
+	// Last applied to the managed plist: a key missing from it goes back there.
+	user, managed, _ = plan([]brave.Setting{rewards, tor}, enforced, true, true)
+	want = []brave.Setting{rewards, enforced["SyncDisabled"], tor}
+	if user != nil || !reflect.DeepEqual(managed, want) {
+		t.Errorf("plan(target managed) = %v, %v; want managed %v", user, managed, want)
+	}
+}
+
+func TestChanged(t *testing.T) {
+	paths := []string{"/a", "/b"}
+	now := time.Now()
+	a := map[string]stamp{"/a": {mod: now, size: 10}}
+	b := map[string]stamp{"/a": {mod: now, size: 10}, "/b": {mod: now, size: 1}}
+	if got := changed(paths, a, b); !reflect.DeepEqual(got, []string{"/b"}) {
+		t.Errorf("changed() = %v, want [/b]", got)
+	}
+	if got := changed(paths, b, b); got != nil {
+		t.Errorf("changed(same) = %v, want nil", got)
+	}
+}
+
+func TestLoop(t *testing.T) {
+	dir := t.TempDir()
+	evented := filepath.Join(dir, "user.plist")
+	polled := filepath.Join(dir, "missing", "managed.plist") // its directory cannot be watched
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	checks := make(chan []string, 4)
+	ready, checked := make(chan struct{}), make(chan struct{}, 4)
+	opts := Options{Interval: 20 * time.Millisecond, Debounce: 50 * time.Millisecond}
+	opts.defaults()
+	go loop(ctx, opts, []string{evented, polled}, func(ch []string) { checks <- ch }, loopHooks{
+		ready:   func() { close(ready) },
+		checked: func() { checked <- struct{}{} },
+	})
+	<-ready
+
+	// wait returns once loop has checked want and re-stamped the plists, so later changes are new.
+	wait := func(want []string) {
+		t.Helper()
+		select {
+		case got := <-checks:
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("check(%v), want %v", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no check for %v", want)
+		}
+		<-checked
+	}
+	if err := os.WriteFile(evented, []byte("<plist/>"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	wait([]string{evented})
+	if err := os.MkdirAll(filepath.Dir(polled), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(polled, []byte("<plist/>"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	wait([]string{polled})
+	select {
+	case got := <-checks:
+		t.Errorf("unexpected check(%v)", got)
+	case <-time.After(200 * time.Millisecond):
+	}
+}